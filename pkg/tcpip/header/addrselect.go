@@ -0,0 +1,220 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"fmt"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// ipv6PolicyTable is the default policy table defined in RFC 6724 section 2.1.
+//
+// A more human-readable version:
+//
+//  Prefix        Precedence Label
+//  ::1/128               50     0
+//  ::/0                  40     1
+//  ::ffff:0:0/96         35     4
+//  2002::/16             30     2
+//  2001::/32              5     5
+//  fc00::/7               3    13
+//  ::/96                  1     3
+//  fec0::/10              1    11
+//  3ffe::/16              1    12
+//
+// The table is sorted by prefix length so longest-prefix match can be easily
+// achieved.
+//
+// We willingly left out ::/96, fec0::/10 and 3ffe::/16 since those prefix
+// assignments are deprecated.
+//
+// As per RFC 4291 section 2.5.5.1 (for ::/96),
+//
+//   The "IPv4-Compatible IPv6 address" is now deprecated because the
+//   current IPv6 transition mechanisms no longer use these addresses.
+//   New or updated implementations are not required to support this
+//   address type.
+//
+// As per RFC 3879 section 4 (for fec0::/10),
+//
+//    This document formally deprecates the IPv6 site-local unicast prefix
+//    defined in [RFC3513], i.e., 1111111011 binary or FEC0::/10.
+//
+// As per RFC 3701 section 1 (for 3ffe::/16),
+//
+//   As clearly stated in [TEST-NEW], the addresses for the 6bone are
+//   temporary and will be reclaimed in the future. It further states
+//   that all users of these addresses (within the 3FFE::/16 prefix) will
+//   be required to renumber at some time in the future.
+//
+// and section 2,
+//
+//   Thus after the pTLA allocation cutoff date January 1, 2004, it is
+//   REQUIRED that no new 6bone 3FFE pTLAs be allocated.
+//
+// MUST NOT BE MODIFIED.
+var ipv6PolicyTable = [...]struct {
+	subnet tcpip.Subnet
+
+	label uint8
+}{
+	// ::1/128
+	{
+		subnet: IPv6Loopback.WithPrefix().Subnet(),
+		label:  0,
+	},
+	// ::ffff:0:0/96
+	{
+		subnet: IPv4MappedIPv6Subnet,
+		label:  4,
+	},
+	// 2001::/32 (Teredo prefix as per RFC 4380 section 2.6).
+	{
+		subnet: tcpip.AddressWithPrefix{
+			Address:   "\x20\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
+			PrefixLen: 32,
+		}.Subnet(),
+		label: 5,
+	},
+	// 2002::/16 (6to4 prefix as per RFC 3056 section 2).
+	{
+		subnet: tcpip.AddressWithPrefix{
+			Address:   "\x20\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
+			PrefixLen: 16,
+		}.Subnet(),
+		label: 2,
+	},
+	// fc00::/7 (Unique local addresses as per RFC 4193 section 3.1).
+	{
+		subnet: tcpip.AddressWithPrefix{
+			Address:   "\xfc\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
+			PrefixLen: 7,
+		}.Subnet(),
+		label: 13,
+	},
+	// ::/0
+	{
+		subnet: IPv6EmptySubnet,
+		label:  1,
+	},
+}
+
+// IPv6AddressLabel returns the label of the IPv6 address addr in the default
+// policy table defined in RFC 6724 section 2.1.
+func IPv6AddressLabel(addr tcpip.Address) uint8 {
+	for _, p := range ipv6PolicyTable {
+		if p.subnet.Contains(addr) {
+			return p.label
+		}
+	}
+
+	panic(fmt.Sprintf("should have a label for address = %s", addr))
+}
+
+// SelectSourceAddress returns the address among candidates that should be
+// used as the source address of a packet sent to dst, as per RFC 6724
+// section 5.
+//
+// Only candidates of the same family as dst are considered. The following
+// rules are applied, in order:
+//
+//   1. Prefer the same address as dst.
+//   2. Prefer an appropriate scope.
+//   6. Prefer a matching label.
+//   8. Use the longest matching prefix.
+//
+// Rules 3 (avoid deprecated addresses), 4 (prefer home addresses), 5 (prefer
+// outgoing interface) and 7 (prefer temporary addresses) need state that plain
+// addresses do not carry, so callers should filter candidates accordingly.
+// Remaining ties are broken in favour of the earliest candidate.
+//
+// Returns false if no candidate has the same family as dst.
+func SelectSourceAddress(candidates []tcpip.Address, dst tcpip.Address) (tcpip.Address, bool) {
+	if len(dst) != IPv4AddressSize && len(dst) != IPv6AddressSize {
+		return "", false
+	}
+
+	var best tcpip.Address
+	found := false
+	for _, c := range candidates {
+		if len(c) != len(dst) {
+			continue
+		}
+		if !found || preferSourceAddress(c, best, dst) {
+			best = c
+			found = true
+		}
+	}
+	return best, found
+}
+
+// preferSourceAddress returns true iff sa is strictly preferred over sb as the
+// source address of a packet sent to dst, as per the rules applied by
+// SelectSourceAddress.
+func preferSourceAddress(sa, sb, dst tcpip.Address) bool {
+	// Prefer same address as per RFC 6724 section 5 rule 1.
+	if sb == dst {
+		return false
+	}
+	if sa == dst {
+		return true
+	}
+
+	// Prefer appropriate scope as per RFC 6724 section 5 rule 2.
+	saScope, sbScope, dstScope := sourceSelectionScope(sa), sourceSelectionScope(sb), sourceSelectionScope(dst)
+	if saScope < sbScope {
+		return saScope >= dstScope
+	} else if sbScope < saScope {
+		return sbScope < dstScope
+	}
+
+	// Prefer matching label as per RFC 6724 section 5 rule 6. All IPv4
+	// addresses share the label of IPv4-mapped addresses.
+	if len(dst) == IPv6AddressSize {
+		dstLabel := IPv6AddressLabel(dst)
+		if saMatch, sbMatch := IPv6AddressLabel(sa) == dstLabel, IPv6AddressLabel(sb) == dstLabel; saMatch != sbMatch {
+			return saMatch
+		}
+	}
+
+	// Use longest matching prefix as per RFC 6724 section 5 rule 8.
+	return sa.MatchingPrefix(dst) > sb.MatchingPrefix(dst)
+}
+
+// sourceSelectionScope returns the scope of addr, as per RFC 6724 section 3.
+//
+// As per section 3.2, IPv4 loopback and link-local addresses are assigned
+// link-local scope and all other IPv4 addresses are assigned global scope.
+func sourceSelectionScope(addr tcpip.Address) IPv6AddressScope {
+	if len(addr) == IPv4AddressSize {
+		if IsV4LinkLocalMulticastAddress(addr) {
+			return LinkLocalScope
+		}
+		switch V4AddressScope(addr) {
+		case IPv4LoopbackScope, IPv4LinkLocalScope:
+			return LinkLocalScope
+		default:
+			return GlobalScope
+		}
+	}
+
+	scope, err := ScopeForIPv6Address(addr)
+	if err != nil {
+		// Should never happen as addr is an IPv6 address.
+		panic(fmt.Sprintf("ScopeForIPv6Address(%s): %s", addr, err))
+	}
+	return scope
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestSelectSourceAddress(t *testing.T) {
+	const (
+		v4Loopback   = tcpip.Address("\x7f\x00\x00\x01")
+		v4LinkLocal1 = tcpip.Address("\xa9\xfe\x01\x01")
+		v4LinkLocal2 = tcpip.Address("\xa9\xfe\x02\x02")
+		v4Private1   = tcpip.Address("\x0a\x00\x00\x01")
+		v4Private2   = tcpip.Address("\x0a\x00\x01\x01")
+		v4Global     = tcpip.Address("\x08\x08\x08\x08")
+
+		v6LinkLocal1 = tcpip.Address("\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+		v6LinkLocal2 = tcpip.Address("\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02")
+		v6Global1    = tcpip.Address("\x20\x01\x0d\xb8\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+		v6Global2    = tcpip.Address("\x20\x01\x0d\xb8\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02")
+		v6Global3    = tcpip.Address("\x20\x01\x0d\xb8\x00\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+		v6Teredo     = tcpip.Address("\x20\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+		v6Label1     = tcpip.Address("\x30\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+	)
+
+	tests := []struct {
+		name       string
+		candidates []tcpip.Address
+		dst        tcpip.Address
+		want       tcpip.Address
+		wantOK     bool
+	}{
+		{
+			name:       "IPv6 same address",
+			candidates: []tcpip.Address{v6Global3, v6Global1},
+			dst:        v6Global1,
+			want:       v6Global1,
+			wantOK:     true,
+		},
+		{
+			name:       "IPv6 global scope for global destination",
+			candidates: []tcpip.Address{v6LinkLocal1, v6Global3},
+			dst:        v6Global1,
+			want:       v6Global3,
+			wantOK:     true,
+		},
+		{
+			name:       "IPv6 link-local scope for link-local destination",
+			candidates: []tcpip.Address{v6Global1, v6LinkLocal1},
+			dst:        v6LinkLocal2,
+			want:       v6LinkLocal1,
+			wantOK:     true,
+		},
+		{
+			name:       "IPv6 matching label over longer prefix",
+			candidates: []tcpip.Address{v6Teredo, v6Label1},
+			dst:        v6Global1,
+			want:       v6Label1,
+			wantOK:     true,
+		},
+		{
+			name:       "IPv6 longest matching prefix",
+			candidates: []tcpip.Address{v6Global3, v6Global2},
+			dst:        v6Global1,
+			want:       v6Global2,
+			wantOK:     true,
+		},
+		{
+			name:       "IPv4 same address",
+			candidates: []tcpip.Address{v4Private1, v4Loopback},
+			dst:        v4Loopback,
+			want:       v4Loopback,
+			wantOK:     true,
+		},
+		{
+			name:       "IPv4 global scope for global destination",
+			candidates: []tcpip.Address{v4LinkLocal1, v4Private1},
+			dst:        v4Global,
+			want:       v4Private1,
+			wantOK:     true,
+		},
+		{
+			name:       "IPv4 link-local scope for link-local destination",
+			candidates: []tcpip.Address{v4Private1, v4LinkLocal1},
+			dst:        v4LinkLocal2,
+			want:       v4LinkLocal1,
+			wantOK:     true,
+		},
+		{
+			name:       "IPv4 longest matching prefix",
+			candidates: []tcpip.Address{v4Private2, v4Private1},
+			dst:        "\x0a\x00\x00\x02",
+			want:       v4Private1,
+			wantOK:     true,
+		},
+		{
+			name:       "tie keeps earliest candidate",
+			candidates: []tcpip.Address{v4Private1, v4Private2},
+			dst:        v4Global,
+			want:       v4Private1,
+			wantOK:     true,
+		},
+		{
+			name:       "other family ignored",
+			candidates: []tcpip.Address{v6Global1, v4Private1},
+			dst:        v4Global,
+			want:       v4Private1,
+			wantOK:     true,
+		},
+		{
+			name:       "no candidate of the same family",
+			candidates: []tcpip.Address{v6Global1, v6LinkLocal1},
+			dst:        v4Global,
+			wantOK:     false,
+		},
+		{
+			name:   "no candidates",
+			dst:    v6Global1,
+			wantOK: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := header.SelectSourceAddress(test.candidates, test.dst)
+			if got != test.want || ok != test.wantOK {
+				t.Errorf("got header.SelectSourceAddress(%s, %s) = (%s, %t), want = (%s, %t)", test.candidates, test.dst, got, ok, test.want, test.wantOK)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ package header
 
 import (
 	"encoding/binary"
+	"fmt"
 
 	"gvisor.dev/gvisor/pkg/tcpip"
 )
@@ -125,3 +126,26 @@ func (a ARP) IsValid() bool {
 		a.hardwareAddressSize() == EthernetAddressSize &&
 		a.protocolAddressSize() == IPv4AddressSize
 }
+
+// BuildARPRequest returns an IPv4-over-Ethernet ARP request sent by senderMAC
+// and senderIP, asking for the link address of targetIP.
+//
+// The target hardware address is left zeroed as it is the address being
+// resolved; the request itself is expected to be sent to the Ethernet
+// broadcast address.
+func BuildARPRequest(senderMAC tcpip.LinkAddress, senderIP, targetIP tcpip.Address) []byte {
+	if len(senderMAC) != EthernetAddressSize {
+		panic(fmt.Sprintf("got len(senderMAC) = %d, want = %d", len(senderMAC), EthernetAddressSize))
+	}
+	if len(senderIP) != IPv4AddressSize || len(targetIP) != IPv4AddressSize {
+		panic(fmt.Sprintf("got len(senderIP) = %d, len(targetIP) = %d, want = %d", len(senderIP), len(targetIP), IPv4AddressSize))
+	}
+
+	a := ARP(make([]byte, ARPSize))
+	a.SetIPv4OverEthernet()
+	a.SetOp(ARPRequest)
+	copy(a.HardwareAddressSender(), senderMAC)
+	copy(a.ProtocolAddressSender(), senderIP)
+	copy(a.ProtocolAddressTarget(), targetIP)
+	return a
+}
@@ -0,0 +1,47 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestBuildARPRequest(t *testing.T) {
+	const senderMAC = tcpip.LinkAddress("\x02\x03\x04\x05\x06\x07")
+
+	a := header.ARP(header.BuildARPRequest(senderMAC, testIPv4SrcAddr, testIPv4DstAddr))
+	if !a.IsValid() {
+		t.Fatal("got a.IsValid() = false, want = true")
+	}
+	if got := a.Op(); got != header.ARPRequest {
+		t.Errorf("got a.Op() = %d, want = %d", got, header.ARPRequest)
+	}
+	if got := tcpip.LinkAddress(a.HardwareAddressSender()); got != senderMAC {
+		t.Errorf("got a.HardwareAddressSender() = %s, want = %s", got, senderMAC)
+	}
+	if got := tcpip.Address(a.ProtocolAddressSender()); got != testIPv4SrcAddr {
+		t.Errorf("got a.ProtocolAddressSender() = %s, want = %s", got, testIPv4SrcAddr)
+	}
+	if got, want := a.HardwareAddressTarget(), make([]byte, header.EthernetAddressSize); !bytes.Equal(got, want) {
+		t.Errorf("got a.HardwareAddressTarget() = %x, want = %x", got, want)
+	}
+	if got := tcpip.Address(a.ProtocolAddressTarget()); got != testIPv4DstAddr {
+		t.Errorf("got a.ProtocolAddressTarget() = %s, want = %s", got, testIPv4DstAddr)
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import "encoding/binary"
+
+// RFC 5880 section 4.1 defines the mandatory section of a BFD Control packet
+// as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|Vers |  Diag   |Sta|P|F|C|A|D|M|  Detect Mult  |    Length     |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                       My Discriminator                        |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                      Your Discriminator                       |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                    Desired Min TX Interval                    |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                   Required Min RX Interval                    |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                 Required Min Echo RX Interval                 |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+const (
+	bfdVersDiag          = 0
+	bfdStateFlags        = 1
+	bfdDetectMult        = 2
+	bfdLength            = 3
+	bfdMyDiscriminator   = 4
+	bfdYourDiscriminator = 8
+
+	bfdVersionShift = 5
+	bfdStateShift   = 6
+
+	// bfdAuthPresentFlag is the Authentication Present (A) flag within the
+	// state and flags byte.
+	bfdAuthPresentFlag = 1 << 2
+)
+
+const (
+	// BFDControlPort is the UDP destination port for single-hop BFD Control
+	// packets, as per RFC 5881 section 4.
+	BFDControlPort = 3784
+
+	// BFDVersion is the version of the BFD protocol defined by RFC 5880.
+	BFDVersion = 1
+
+	// BFDControlMinimumSize is the size of the mandatory section of a BFD
+	// Control packet.
+	BFDControlMinimumSize = 24
+
+	// bfdControlMinimumSizeWithAuth is the minimum size of a BFD Control
+	// packet carrying an Authentication Section, as per RFC 5880 section
+	// 6.8.6.
+	bfdControlMinimumSizeWithAuth = BFDControlMinimumSize + 2
+)
+
+// The session states carried in the Sta field of a BFD Control packet, as per
+// RFC 5880 section 4.1.
+const (
+	BFDStateAdminDown uint8 = 0
+	BFDStateDown      uint8 = 1
+	BFDStateInit      uint8 = 2
+	BFDStateUp        uint8 = 3
+)
+
+// BFDControl parses the BFD Control packet held in udpPayload.
+//
+// ok is false if udpPayload does not hold a well-formed BFD version 1 Control
+// packet. The checks performed are the stateless reception checks from RFC
+// 5880 section 6.8.6 that only depend on the packet itself.
+func BFDControl(udpPayload []byte) (myDiscriminator, yourDiscriminator uint32, state uint8, ok bool) {
+	if len(udpPayload) < BFDControlMinimumSize {
+		return 0, 0, 0, false
+	}
+	if udpPayload[bfdVersDiag]>>bfdVersionShift != BFDVersion {
+		return 0, 0, 0, false
+	}
+
+	flags := udpPayload[bfdStateFlags]
+	minLength := BFDControlMinimumSize
+	if flags&bfdAuthPresentFlag != 0 {
+		minLength = bfdControlMinimumSizeWithAuth
+	}
+	if length := int(udpPayload[bfdLength]); length < minLength || length > len(udpPayload) {
+		return 0, 0, 0, false
+	}
+
+	// As per RFC 5880 section 6.8.6, packets with a zero Detect Mult or a zero
+	// My Discriminator MUST be discarded.
+	if udpPayload[bfdDetectMult] == 0 {
+		return 0, 0, 0, false
+	}
+	myDiscriminator = binary.BigEndian.Uint32(udpPayload[bfdMyDiscriminator:])
+	if myDiscriminator == 0 {
+		return 0, 0, 0, false
+	}
+
+	yourDiscriminator = binary.BigEndian.Uint32(udpPayload[bfdYourDiscriminator:])
+	return myDiscriminator, yourDiscriminator, flags >> bfdStateShift, true
+}
@@ -0,0 +1,124 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestBFDControl(t *testing.T) {
+	upPacket := func() []byte {
+		return []byte{
+			// Vers = 1, Diag = 0.
+			0x20,
+			// Sta = Up, no flags.
+			0xc0,
+			// Detect Mult.
+			3,
+			// Length.
+			header.BFDControlMinimumSize,
+			// My Discriminator.
+			0x00, 0x00, 0x00, 0x01,
+			// Your Discriminator.
+			0x00, 0x00, 0x00, 0x02,
+			// Desired Min TX Interval.
+			0x00, 0x0f, 0x42, 0x40,
+			// Required Min RX Interval.
+			0x00, 0x0f, 0x42, 0x40,
+			// Required Min Echo RX Interval.
+			0x00, 0x00, 0x00, 0x00,
+		}
+	}
+
+	tests := []struct {
+		name      string
+		mutate    func([]byte) []byte
+		wantMy    uint32
+		wantYour  uint32
+		wantState uint8
+		wantOK    bool
+	}{
+		{
+			name:      "Up",
+			mutate:    func(b []byte) []byte { return b },
+			wantMy:    1,
+			wantYour:  2,
+			wantState: header.BFDStateUp,
+			wantOK:    true,
+		},
+		{
+			name:   "Truncated",
+			mutate: func(b []byte) []byte { return b[:header.BFDControlMinimumSize-1] },
+		},
+		{
+			name: "BadVersion",
+			mutate: func(b []byte) []byte {
+				b[0] = 0x40
+				return b
+			},
+		},
+		{
+			name: "LengthTooLarge",
+			mutate: func(b []byte) []byte {
+				b[3] = header.BFDControlMinimumSize + 1
+				return b
+			},
+		},
+		{
+			name: "AuthWithoutSection",
+			mutate: func(b []byte) []byte {
+				b[1] |= 0x04
+				return b
+			},
+		},
+		{
+			name: "ZeroDetectMult",
+			mutate: func(b []byte) []byte {
+				b[2] = 0
+				return b
+			},
+		},
+		{
+			name: "ZeroMyDiscriminator",
+			mutate: func(b []byte) []byte {
+				b[7] = 0
+				return b
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			my, your, state, ok := header.BFDControl(test.mutate(upPacket()))
+			if ok != test.wantOK {
+				t.Fatalf("got header.BFDControl(_) ok = %t, want = %t", ok, test.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if my != test.wantMy {
+				t.Errorf("got myDiscriminator = %d, want = %d", my, test.wantMy)
+			}
+			if your != test.wantYour {
+				t.Errorf("got yourDiscriminator = %d, want = %d", your, test.wantYour)
+			}
+			if state != test.wantState {
+				t.Errorf("got state = %d, want = %d", state, test.wantState)
+			}
+		})
+	}
+}
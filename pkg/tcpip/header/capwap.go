@@ -0,0 +1,255 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import "encoding/binary"
+
+// RFC 5415 section 4.3 defines the CAPWAP header, starting with the preamble
+// of section 4.1, as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|CAPWAP Preamble|  HLEN   |   RID   | WBID    |T|F|L|W|M|K|Flags|
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|          Fragment ID          |     Frag Offset         |Rsvd |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                 (optional) Radio MAC Address                  |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|            (optional) Wireless Specific Information           |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// The preamble holds a 4-bit version and a 4-bit type. HLEN is the length of
+// the whole header, including the optional fields, in 4-byte words. Each
+// optional field starts with a 1-byte length and is padded to a 4-byte
+// boundary.
+const (
+	capwapPreamble       = 0
+	capwapFirstWord      = 0
+	capwapFragmentID     = 4
+	capwapFragmentOffset = 6
+	capwapOptionalFields = 8
+
+	capwapVersionShift        = 4
+	capwapTypeMask            = 0x0f
+	capwapHLENShift           = 19
+	capwapHLENMask            = 0x1f
+	capwapRIDShift            = 14
+	capwapRIDMask             = 0x1f
+	capwapWBIDShift           = 9
+	capwapWBIDMask            = 0x1f
+	capwapFlagsMask           = 0x1ff
+	capwapFragmentOffsetShift = 3
+	capwapFragmentOffsetUnit  = 8
+	capwapHLENUnit            = 4
+)
+
+const (
+	// CAPWAPControlPort is the UDP port of the CAPWAP control channel, as
+	// per RFC 5415 section 3.
+	CAPWAPControlPort = 5246
+
+	// CAPWAPDataPort is the UDP port of the CAPWAP data channel, as per RFC
+	// 5415 section 3.
+	CAPWAPDataPort = 5247
+
+	// CAPWAPMinimumSize is the size of the CAPWAP header without optional
+	// fields.
+	CAPWAPMinimumSize = 8
+
+	// CAPWAPVersion is the version of CAPWAP described by RFC 5415.
+	CAPWAPVersion = 0
+)
+
+// The values of the type field of the CAPWAP preamble.
+const (
+	// CAPWAPTypeHeader indicates that the CAPWAP header follows the
+	// preamble.
+	CAPWAPTypeHeader uint8 = 0
+
+	// CAPWAPTypeDTLS indicates that a DTLS header follows the preamble.
+	CAPWAPTypeDTLS uint8 = 1
+)
+
+// CAPWAPWBIDIEEE80211 is the Wireless Binding Identifier of IEEE 802.11, as
+// per RFC 5415 section 4.3.
+const CAPWAPWBIDIEEE80211 uint8 = 1
+
+// The flags of the CAPWAP header, as returned by CAPWAP.Flags.
+const (
+	// CAPWAPFlagNativeFrame is the T flag, indicating that the payload is a
+	// frame in the native format of the wireless binding.
+	CAPWAPFlagNativeFrame uint16 = 1 << 8
+
+	// CAPWAPFlagFragment is the F flag, indicating a fragment.
+	CAPWAPFlagFragment uint16 = 1 << 7
+
+	// CAPWAPFlagLastFragment is the L flag, indicating the last fragment of
+	// a packet. It is only meaningful when CAPWAPFlagFragment is set.
+	CAPWAPFlagLastFragment uint16 = 1 << 6
+
+	// CAPWAPFlagWirelessSpecificInfo is the W flag, indicating that the
+	// Wireless Specific Information field is present.
+	CAPWAPFlagWirelessSpecificInfo uint16 = 1 << 5
+
+	// CAPWAPFlagRadioMAC is the M flag, indicating that the Radio MAC
+	// Address field is present.
+	CAPWAPFlagRadioMAC uint16 = 1 << 4
+
+	// CAPWAPFlagKeepAlive is the K flag, indicating a Data Channel
+	// Keep-Alive packet.
+	CAPWAPFlagKeepAlive uint16 = 1 << 3
+)
+
+// CAPWAP represents a CAPWAP header, starting with its preamble, stored in a
+// byte array.
+type CAPWAP []byte
+
+// IsValid performs basic validation on the CAPWAP header.
+//
+// The version must be CAPWAPVersion and the type CAPWAPTypeHeader, and the
+// header, including the optional fields indicated by its flags, must fit
+// within its HLEN and within b.
+func (b CAPWAP) IsValid() bool {
+	if len(b) < CAPWAPMinimumSize || b.Version() != CAPWAPVersion || b.Type() != CAPWAPTypeHeader {
+		return false
+	}
+	hdrLen := b.HeaderLength()
+	if hdrLen < CAPWAPMinimumSize || hdrLen > len(b) {
+		return false
+	}
+	_, _, ok := b.optionalFields()
+	return ok
+}
+
+// Version returns the version of the CAPWAP preamble.
+func (b CAPWAP) Version() uint8 {
+	return b[capwapPreamble] >> capwapVersionShift
+}
+
+// Type returns the type of the CAPWAP preamble, one of the CAPWAPType*
+// values.
+func (b CAPWAP) Type() uint8 {
+	return b[capwapPreamble] & capwapTypeMask
+}
+
+func (b CAPWAP) word() uint32 {
+	return binary.BigEndian.Uint32(b[capwapFirstWord:])
+}
+
+// HeaderLength returns the length of the CAPWAP header in bytes, including
+// the preamble and the optional fields.
+func (b CAPWAP) HeaderLength() int {
+	return int((b.word()>>capwapHLENShift)&capwapHLENMask) * capwapHLENUnit
+}
+
+// RadioID returns the Radio ID, the radio of the WTP the packet belongs to.
+func (b CAPWAP) RadioID() uint8 {
+	return uint8((b.word() >> capwapRIDShift) & capwapRIDMask)
+}
+
+// WBID returns the Wireless Binding Identifier, e.g. CAPWAPWBIDIEEE80211.
+func (b CAPWAP) WBID() uint8 {
+	return uint8((b.word() >> capwapWBIDShift) & capwapWBIDMask)
+}
+
+// Flags returns the T, F, L, W, M and K flags and the reserved flags of the
+// CAPWAP header; see the CAPWAPFlag* values.
+func (b CAPWAP) Flags() uint16 {
+	return uint16(b.word() & capwapFlagsMask)
+}
+
+// IsFragment returns true iff the packet is a fragment.
+func (b CAPWAP) IsFragment() bool {
+	return b.Flags()&CAPWAPFlagFragment != 0
+}
+
+// IsLastFragment returns true iff the packet is the last fragment of a
+// fragmented packet.
+func (b CAPWAP) IsLastFragment() bool {
+	return b.IsFragment() && b.Flags()&CAPWAPFlagLastFragment != 0
+}
+
+// FragmentID returns the identifier shared by the fragments of a packet.
+func (b CAPWAP) FragmentID() uint16 {
+	return binary.BigEndian.Uint16(b[capwapFragmentID:])
+}
+
+// FragmentOffset returns the offset of the fragment within the original
+// packet, in bytes.
+func (b CAPWAP) FragmentOffset() int {
+	return int(binary.BigEndian.Uint16(b[capwapFragmentOffset:])>>capwapFragmentOffsetShift) * capwapFragmentOffsetUnit
+}
+
+// optionalFields returns the Radio MAC Address and the Wireless Specific
+// Information fields, without their length and padding, or nil if they are
+// not present.
+//
+// The returned bool is false if a field does not fit within the header.
+func (b CAPWAP) optionalFields() (radioMAC, wirelessInfo []byte, ok bool) {
+	fields := b[capwapOptionalFields:b.HeaderLength()]
+	next := func() ([]byte, bool) {
+		if len(fields) == 0 {
+			return nil, false
+		}
+		fieldLen := 1 + int(fields[0])
+		paddedLen := (fieldLen + capwapHLENUnit - 1) &^ (capwapHLENUnit - 1)
+		if paddedLen > len(fields) {
+			return nil, false
+		}
+		field := fields[1:fieldLen]
+		fields = fields[paddedLen:]
+		return field, true
+	}
+	flags := b.Flags()
+	if flags&CAPWAPFlagRadioMAC != 0 {
+		if radioMAC, ok = next(); !ok {
+			return nil, nil, false
+		}
+	}
+	if flags&CAPWAPFlagWirelessSpecificInfo != 0 {
+		if wirelessInfo, ok = next(); !ok {
+			return nil, nil, false
+		}
+	}
+	return radioMAC, wirelessInfo, true
+}
+
+// RadioMAC returns the MAC address of the radio the packet was received on,
+// held in the optional Radio MAC Address field.
+//
+// The returned bool is false if the field is not present. b must be valid;
+// see IsValid.
+func (b CAPWAP) RadioMAC() ([]byte, bool) {
+	radioMAC, _, _ := b.optionalFields()
+	return radioMAC, b.Flags()&CAPWAPFlagRadioMAC != 0
+}
+
+// WirelessSpecificInfo returns the binding-specific data held in the optional
+// Wireless Specific Information field.
+//
+// The returned bool is false if the field is not present. b must be valid;
+// see IsValid.
+func (b CAPWAP) WirelessSpecificInfo() ([]byte, bool) {
+	_, wirelessInfo, _ := b.optionalFields()
+	return wirelessInfo, b.Flags()&CAPWAPFlagWirelessSpecificInfo != 0
+}
+
+// Payload returns the packet following the CAPWAP header.
+//
+// b must be valid; see IsValid.
+func (b CAPWAP) Payload() []byte {
+	return b[b.HeaderLength():]
+}
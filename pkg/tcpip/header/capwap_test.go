@@ -0,0 +1,190 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestCAPWAP(t *testing.T) {
+	radioMAC := []byte{0x02, 0x03, 0x04, 0x05, 0x06, 0x07}
+	wirelessInfo := []byte{0xa, 0xb, 0xc, 0xd}
+
+	tests := []struct {
+		name               string
+		buf                []byte
+		wantValid          bool
+		wantHeaderLength   int
+		wantRadioID        uint8
+		wantFlags          uint16
+		wantFragment       bool
+		wantLastFragment   bool
+		wantFragmentID     uint16
+		wantFragmentOffset int
+		wantRadioMAC       []byte
+		wantWirelessInfo   []byte
+		wantPayloadLen     int
+	}{
+		{
+			name: "data channel with radio MAC",
+			buf: append([]byte{
+				0x00, 0x20, 0x43, 0x10,
+				0x00, 0x00, 0x00, 0x00,
+				0x06, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x00,
+			}, 0x08, 0x02, 0x00, 0x00),
+			wantValid:        true,
+			wantHeaderLength: 16,
+			wantRadioID:      1,
+			wantFlags:        header.CAPWAPFlagNativeFrame | header.CAPWAPFlagRadioMAC,
+			wantRadioMAC:     radioMAC,
+			wantPayloadLen:   4,
+		},
+		{
+			name: "data channel with wireless specific information",
+			buf: []byte{
+				0x00, 0x20, 0x03, 0x20,
+				0x00, 0x00, 0x00, 0x00,
+				0x04, 0x0a, 0x0b, 0x0c, 0x0d, 0x00, 0x00, 0x00,
+			},
+			wantValid:        true,
+			wantHeaderLength: 16,
+			wantFlags:        header.CAPWAPFlagNativeFrame | header.CAPWAPFlagWirelessSpecificInfo,
+			wantWirelessInfo: wirelessInfo,
+		},
+		{
+			name: "last fragment",
+			buf: []byte{
+				0x00, 0x10, 0x03, 0xc0,
+				0x12, 0x34, 0x00, 0x10,
+				0x01, 0x02,
+			},
+			wantValid:          true,
+			wantHeaderLength:   8,
+			wantFlags:          header.CAPWAPFlagNativeFrame | header.CAPWAPFlagFragment | header.CAPWAPFlagLastFragment,
+			wantFragment:       true,
+			wantLastFragment:   true,
+			wantFragmentID:     0x1234,
+			wantFragmentOffset: 16,
+			wantPayloadLen:     2,
+		},
+		{
+			name: "last fragment flag without fragment flag",
+			buf: []byte{
+				0x00, 0x10, 0x03, 0x40,
+				0x00, 0x00, 0x00, 0x00,
+			},
+			wantValid:        true,
+			wantHeaderLength: 8,
+			wantFlags:        header.CAPWAPFlagNativeFrame | header.CAPWAPFlagLastFragment,
+		},
+		{
+			name: "radio MAC flag without room for the field",
+			buf: []byte{
+				0x00, 0x10, 0x03, 0x10,
+				0x00, 0x00, 0x00, 0x00,
+				0x06, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x00,
+			},
+		},
+		{
+			name: "radio MAC overruns header length",
+			buf: []byte{
+				0x00, 0x18, 0x03, 0x10,
+				0x00, 0x00, 0x00, 0x00,
+				0x06, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x00,
+			},
+		},
+		{
+			name: "header length exceeds buffer",
+			buf: []byte{
+				0x00, 0x20, 0x03, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			name: "header length too small",
+			buf: []byte{
+				0x00, 0x08, 0x03, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			name: "DTLS",
+			buf: []byte{
+				0x01, 0x10, 0x03, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			name: "non-zero version",
+			buf: []byte{
+				0x10, 0x10, 0x03, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+			},
+		},
+		{
+			name: "too small",
+			buf:  []byte{0x00, 0x10, 0x03, 0x00, 0x00, 0x00, 0x00},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			capwap := header.CAPWAP(test.buf)
+			if got := capwap.IsValid(); got != test.wantValid {
+				t.Fatalf("got IsValid() = %t, want = %t", got, test.wantValid)
+			}
+			if !test.wantValid {
+				return
+			}
+			if got := capwap.HeaderLength(); got != test.wantHeaderLength {
+				t.Errorf("got HeaderLength() = %d, want = %d", got, test.wantHeaderLength)
+			}
+			if got := capwap.RadioID(); got != test.wantRadioID {
+				t.Errorf("got RadioID() = %d, want = %d", got, test.wantRadioID)
+			}
+			if got := capwap.WBID(); got != header.CAPWAPWBIDIEEE80211 {
+				t.Errorf("got WBID() = %d, want = %d", got, header.CAPWAPWBIDIEEE80211)
+			}
+			if got := capwap.Flags(); got != test.wantFlags {
+				t.Errorf("got Flags() = %#x, want = %#x", got, test.wantFlags)
+			}
+			if got := capwap.IsFragment(); got != test.wantFragment {
+				t.Errorf("got IsFragment() = %t, want = %t", got, test.wantFragment)
+			}
+			if got := capwap.IsLastFragment(); got != test.wantLastFragment {
+				t.Errorf("got IsLastFragment() = %t, want = %t", got, test.wantLastFragment)
+			}
+			if got := capwap.FragmentID(); got != test.wantFragmentID {
+				t.Errorf("got FragmentID() = %#x, want = %#x", got, test.wantFragmentID)
+			}
+			if got := capwap.FragmentOffset(); got != test.wantFragmentOffset {
+				t.Errorf("got FragmentOffset() = %d, want = %d", got, test.wantFragmentOffset)
+			}
+			if got, ok := capwap.RadioMAC(); ok != (test.wantRadioMAC != nil) || !bytes.Equal(got, test.wantRadioMAC) {
+				t.Errorf("got RadioMAC() = (%x, %t), want = (%x, %t)", got, ok, test.wantRadioMAC, test.wantRadioMAC != nil)
+			}
+			if got, ok := capwap.WirelessSpecificInfo(); ok != (test.wantWirelessInfo != nil) || !bytes.Equal(got, test.wantWirelessInfo) {
+				t.Errorf("got WirelessSpecificInfo() = (%x, %t), want = (%x, %t)", got, ok, test.wantWirelessInfo, test.wantWirelessInfo != nil)
+			}
+			if got := len(capwap.Payload()); got != test.wantPayloadLen {
+				t.Errorf("got len(Payload()) = %d, want = %d", got, test.wantPayloadLen)
+			}
+		})
+	}
+}
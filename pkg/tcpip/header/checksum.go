@@ -18,6 +18,7 @@ package header
 
 import (
 	"encoding/binary"
+	"fmt"
 
 	"gvisor.dev/gvisor/pkg/tcpip"
 	"gvisor.dev/gvisor/pkg/tcpip/buffer"
@@ -211,6 +212,26 @@ func (c *Checksumer) Checksum() uint16 {
 	return c.sum
 }
 
+// ChecksumExcludingField calculates the checksum (as defined in RFC 1071) of
+// the bytes in data as if the fieldLen bytes at fieldOffset were zero, without
+// modifying or copying data. It allows a checksum field to be verified in a
+// buffer that may be shared.
+//
+// The initial checksum must have been computed on an even number of bytes.
+func ChecksumExcludingField(data []byte, fieldOffset, fieldLen int, initial uint16) uint16 {
+	end := fieldOffset + fieldLen
+	if fieldOffset < 0 || fieldLen < 0 || end > len(data) {
+		panic(fmt.Sprintf("field at offset %d of length %d is out of bounds of %d bytes", fieldOffset, fieldLen, len(data)))
+	}
+	c := Checksumer{sum: initial}
+	c.Add(data[:fieldOffset])
+	// The field adds nothing to the sum but shifts the bytes following it
+	// when its length is odd.
+	c.odd = c.odd != (fieldLen%2 != 0)
+	c.Add(data[end:])
+	return c.Checksum()
+}
+
 // ChecksumCombine combines the two uint16 to form their checksum. This is done
 // by adding them and the carry.
 //
@@ -220,12 +241,77 @@ func ChecksumCombine(a, b uint16) uint16 {
 	return uint16(v + v>>16)
 }
 
+// checksumUpdate2ByteAlignedAddress updates the checksum xsum, calculated over
+// a buffer holding the address old, to account for old being replaced with
+// new.
+//
+// The addresses must have the same, even, length and must begin at a 2-byte
+// boundary in the original buffer.
+func checksumUpdate2ByteAlignedAddress(xsum uint16, old, new tcpip.Address) uint16 {
+	if len(old) != len(new) {
+		panic(fmt.Sprintf("got len(old) = %d, len(new) = %d, want equal lengths", len(old), len(new)))
+	}
+	if len(old)%2 != 0 {
+		panic(fmt.Sprintf("got odd address length = %d", len(old)))
+	}
+
+	// As per RFC 1071 section 2 (4), given the original value m, the new value
+	// m' and the old checksum C, the new checksum C' is:
+	//
+	//   C' = C + (-m) + m' = C + (m' - m)
+	for i := 0; i < len(old); i += 2 {
+		m := uint16(old[i])<<8 | uint16(old[i+1])
+		mPrime := uint16(new[i])<<8 | uint16(new[i+1])
+		xsum = ChecksumCombine(xsum, ChecksumCombine(mPrime, ^m))
+	}
+	return xsum
+}
+
+// checkPseudoHeaderAddresses panics if src and dst are not addresses of the
+// network protocol netProto.
+func checkPseudoHeaderAddresses(src, dst tcpip.Address, netProto tcpip.NetworkProtocolNumber) {
+	var want int
+	switch netProto {
+	case IPv4ProtocolNumber:
+		want = IPv4AddressSize
+	case IPv6ProtocolNumber:
+		want = IPv6AddressSize
+	default:
+		panic(fmt.Sprintf("unsupported network protocol number = %d", netProto))
+	}
+	if len(src) != want || len(dst) != want {
+		panic(fmt.Sprintf("got len(src) = %d, len(dst) = %d, want = %d for network protocol number %d", len(src), len(dst), want, netProto))
+	}
+}
+
+// ChecksumToField returns the value to store in a checksum field given sum,
+// the folded one's complement sum computed over the covered data with the
+// checksum field set to zero.
+//
+// The field holds the one's complement of the sum, so a sum of 0 is stored as
+// 0xffff and a sum of 0xffff as 0. UDP reserves a zero field to mean that no
+// checksum was computed, so UDP senders must additionally transmit a zero
+// result as 0xffff, as per RFC 768.
+func ChecksumToField(sum uint16) uint16 {
+	return ^sum
+}
+
+// AddressPairChecksum returns the folded one's complement sum of the source and
+// destination addresses src and dst.
+//
+// The addresses are covered by both the IPv4 header checksum and the
+// transport pseudo-header checksum, so senders of bursts of packets to the
+// same peer may compute this once and use it as the initial value of either
+// checksum.
+func AddressPairChecksum(src, dst tcpip.Address) uint16 {
+	return Checksum([]byte(dst), Checksum([]byte(src), 0))
+}
+
 // PseudoHeaderChecksum calculates the pseudo-header checksum for the given
 // destination protocol and network address. Pseudo-headers are needed by
 // transport layers when calculating their own checksum.
 func PseudoHeaderChecksum(protocol tcpip.TransportProtocolNumber, srcAddr tcpip.Address, dstAddr tcpip.Address, totalLen uint16) uint16 {
-	xsum := Checksum([]byte(srcAddr), 0)
-	xsum = Checksum([]byte(dstAddr), xsum)
+	xsum := AddressPairChecksum(srcAddr, dstAddr)
 
 	// Add the length portion of the checksum to the pseudo-checksum.
 	tmp := make([]byte, 2)
@@ -234,3 +320,45 @@ func PseudoHeaderChecksum(protocol tcpip.TransportProtocolNumber, srcAddr tcpip.
 
 	return Checksum([]byte{0, uint8(protocol)}, xsum)
 }
+
+// PseudoHeaderBytes returns the pseudo-header covered by the checksum of a
+// transport protocol segment of length bytes sent from src to dst, for
+// inspecting the bytes PseudoHeaderChecksum sums.
+//
+// The network protocol is inferred from the size of the addresses: the IPv4
+// pseudo-header is 12 bytes long, as per RFC 793 section 3.1, and the IPv6
+// pseudo-header is 40 bytes long, as per RFC 8200 section 8.1.
+func PseudoHeaderBytes(protocol tcpip.TransportProtocolNumber, src, dst tcpip.Address, length uint16) []byte {
+	switch len(src) {
+	case IPv4AddressSize:
+		checkPseudoHeaderAddresses(src, dst, IPv4ProtocolNumber)
+		b := make([]byte, 2*IPv4AddressSize+4)
+		n := copy(b, src)
+		n += copy(b[n:], dst)
+		b[n+1] = uint8(protocol)
+		binary.BigEndian.PutUint16(b[n+2:], length)
+		return b
+	case IPv6AddressSize:
+		checkPseudoHeaderAddresses(src, dst, IPv6ProtocolNumber)
+		b := make([]byte, 2*IPv6AddressSize+8)
+		n := copy(b, src)
+		n += copy(b[n:], dst)
+		binary.BigEndian.PutUint32(b[n:], uint32(length))
+		b[n+7] = uint8(protocol)
+		return b
+	default:
+		panic(fmt.Sprintf("got len(src) = %d, want = %d or %d", len(src), IPv4AddressSize, IPv6AddressSize))
+	}
+}
+
+// PseudoHeaderChecksumV4Cached calculates the same pseudo-header checksum as
+// PseudoHeaderChecksum from precomputed parts, for callers that send bursts of
+// packets sharing addresses, protocol and length.
+//
+// addrSum is the checksum of the source and destination addresses, as returned
+// by AddressPairChecksum, and protoLenWord is the checksum of
+// the protocol and length words, i.e. ChecksumCombine(uint16(protocol),
+// totalLen).
+func PseudoHeaderChecksumV4Cached(addrSum uint16, protoLenWord uint16) uint16 {
+	return ChecksumCombine(addrSum, protoLenWord)
+}
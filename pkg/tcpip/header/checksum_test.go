@@ -23,6 +23,7 @@ import (
 	"sync"
 	"testing"
 
+	"gvisor.dev/gvisor/pkg/tcpip"
 	"gvisor.dev/gvisor/pkg/tcpip/buffer"
 	"gvisor.dev/gvisor/pkg/tcpip/header"
 )
@@ -256,3 +257,297 @@ func TestICMPv6Checksum(t *testing.T) {
 		})
 	}, want, fmt.Sprintf("header: {% x} data {% x}", h, vv.ToView()))
 }
+
+func TestFillUDPChecksum(t *testing.T) {
+	tests := []struct {
+		name     string
+		netProto tcpip.NetworkProtocolNumber
+		src      tcpip.Address
+		dst      tcpip.Address
+	}{
+		{
+			name:     "IPv4",
+			netProto: header.IPv4ProtocolNumber,
+			src:      tcpip.Address("\x0a\x00\x00\x01"),
+			dst:      tcpip.Address("\x0a\x00\x00\x02"),
+		},
+		{
+			name:     "IPv6",
+			netProto: header.IPv6ProtocolNumber,
+			src:      header.IPv6Loopback,
+			dst:      header.IPv6AllNodesMulticastAddress,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload := buffer.View{1, 2, 3, 4, 5, 6, 0, 0, 9}
+			u := header.UDP(make([]byte, header.UDPMinimumSize))
+			u.Encode(&header.UDPFields{
+				SrcPort: 1234,
+				DstPort: 5678,
+				Length:  uint16(header.UDPMinimumSize + len(payload)),
+			})
+
+			header.FillUDPChecksum(u, test.src, test.dst, test.netProto, payload.ToVectorisedView())
+			if !u.IsChecksumValid(test.src, test.dst, header.Checksum(payload, 0)) {
+				t.Errorf("got u.IsChecksumValid(...) = false after FillUDPChecksum, checksum = %#04x", u.Checksum())
+			}
+
+			// Make the datagram's one's complement sum 0xffff so that the
+			// calculated checksum is zero. It must be written as all ones.
+			payload[6], payload[7] = uint8(u.Checksum()>>8), uint8(u.Checksum())
+			header.FillUDPChecksum(u, test.src, test.dst, test.netProto, payload.ToVectorisedView())
+			if got, want := u.Checksum(), uint16(0xffff); got != want {
+				t.Errorf("got u.Checksum() = %#04x, want = %#04x", got, want)
+			}
+			if !u.IsChecksumValid(test.src, test.dst, header.Checksum(payload, 0)) {
+				t.Error("got u.IsChecksumValid(...) = false for all ones checksum")
+			}
+		})
+	}
+}
+
+func TestFillTCPChecksum(t *testing.T) {
+	src := tcpip.Address("\x0a\x00\x00\x01")
+	dst := tcpip.Address("\x0a\x00\x00\x02")
+	payload := buffer.View("hello, world!")
+
+	tcp := header.TCP(make([]byte, header.TCPMinimumSize))
+	tcp.Encode(&header.TCPFields{
+		SrcPort:    1234,
+		DstPort:    80,
+		SeqNum:     100,
+		AckNum:     200,
+		DataOffset: header.TCPMinimumSize,
+		Flags:      header.TCPFlagAck | header.TCPFlagPsh,
+		WindowSize: 65535,
+		Checksum:   0xdead,
+	})
+
+	header.FillTCPChecksum(tcp, src, dst, header.IPv4ProtocolNumber, payload.ToVectorisedView())
+	if !tcp.IsChecksumValid(src, dst, header.Checksum(payload, 0), uint16(len(payload))) {
+		t.Errorf("got tcp.IsChecksumValid(...) = false after FillTCPChecksum, checksum = %#04x", tcp.Checksum())
+	}
+
+	tcp.SetChecksum(tcp.Checksum() + 1)
+	if tcp.IsChecksumValid(src, dst, header.Checksum(payload, 0), uint16(len(payload))) {
+		t.Error("got tcp.IsChecksumValid(...) = true for corrupted checksum")
+	}
+}
+
+func TestFillICMPv6Checksum(t *testing.T) {
+	src := header.IPv6Loopback
+	dst := header.IPv6AllNodesMulticastAddress
+	payload := buffer.View{1, 2, 3, 4, 5}
+
+	icmp := header.ICMPv6(make([]byte, header.ICMPv6EchoMinimumSize))
+	icmp.SetType(header.ICMPv6EchoRequest)
+	icmp.SetIdent(1)
+	icmp.SetSequence(2)
+
+	header.FillICMPv6Checksum(icmp, src, dst, payload.ToVectorisedView())
+	xsum := header.PseudoHeaderChecksum(header.ICMPv6ProtocolNumber, src, dst, uint16(len(icmp)+len(payload)))
+	xsum = header.Checksum(payload, xsum)
+	if got := header.Checksum(icmp, xsum); got != 0xffff {
+		t.Errorf("got checksum over message = %#04x, want = 0xffff", got)
+	}
+}
+
+func TestPseudoHeaderChecksumV4Cached(t *testing.T) {
+	// Ensure same buffer generation for test consistency.
+	rnd := rand.New(rand.NewSource(42))
+	for i := 0; i < 1000; i++ {
+		addrs := make([]byte, 2*header.IPv4AddressSize)
+		rnd.Read(addrs)
+		src := tcpip.Address(addrs[:header.IPv4AddressSize])
+		dst := tcpip.Address(addrs[header.IPv4AddressSize:])
+		protocol := tcpip.TransportProtocolNumber(rnd.Intn(256))
+		totalLen := uint16(rnd.Intn(65536))
+
+		addrSum := header.Checksum(addrs, 0)
+		protoLenWord := header.ChecksumCombine(uint16(protocol), totalLen)
+		got := header.PseudoHeaderChecksumV4Cached(addrSum, protoLenWord)
+		if want := header.PseudoHeaderChecksum(protocol, src, dst, totalLen); got != want {
+			t.Fatalf("got PseudoHeaderChecksumV4Cached(%#x, %#x) = %#x, want = %#x (src = %s, dst = %s, protocol = %d, totalLen = %d)", addrSum, protoLenWord, got, want, src, dst, protocol, totalLen)
+		}
+	}
+}
+
+func BenchmarkPseudoHeaderChecksumV4(b *testing.B) {
+	const (
+		src      = tcpip.Address("\x0a\x00\x00\x01")
+		dst      = tcpip.Address("\x0a\x00\x00\x02")
+		totalLen = 1480
+	)
+
+	b.Run("full", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = header.PseudoHeaderChecksum(header.UDPProtocolNumber, src, dst, totalLen)
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		addrSum := header.AddressPairChecksum(src, dst)
+		protoLenWord := header.ChecksumCombine(uint16(header.UDPProtocolNumber), totalLen)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = header.PseudoHeaderChecksumV4Cached(addrSum, protoLenWord)
+		}
+	})
+}
+
+func TestAddressPairChecksum(t *testing.T) {
+	// Ensure same buffer generation for test consistency.
+	rnd := rand.New(rand.NewSource(42))
+	for _, addrSize := range []int{header.IPv4AddressSize, header.IPv6AddressSize} {
+		for i := 0; i < 1000; i++ {
+			addrs := make([]byte, 2*addrSize)
+			rnd.Read(addrs)
+			src := tcpip.Address(addrs[:addrSize])
+			dst := tcpip.Address(addrs[addrSize:])
+
+			got := header.AddressPairChecksum(src, dst)
+			if want := header.Checksum(addrs, 0); got != want {
+				t.Fatalf("got AddressPairChecksum(%s, %s) = %#x, want = %#x", src, dst, got, want)
+			}
+
+			protocol := tcpip.TransportProtocolNumber(rnd.Intn(256))
+			totalLen := uint16(rnd.Intn(65536))
+			protoLenWord := header.ChecksumCombine(uint16(protocol), totalLen)
+			if got, want := header.ChecksumCombine(got, protoLenWord), header.PseudoHeaderChecksum(protocol, src, dst, totalLen); got != want {
+				t.Fatalf("got pseudo-header checksum from AddressPairChecksum(%s, %s) = %#x, want = %#x", src, dst, got, want)
+			}
+		}
+	}
+
+	t.Run("IPv4 header", func(t *testing.T) {
+		pkt := header.BuildUDPv4Packet(tcpip.Address("\x0a\x00\x00\x01"), tcpip.Address("\x0a\x00\x00\x02"), 1234, 53, nil, 64)
+		ip := header.IPv4(pkt)
+		// The addresses are the last 8 bytes of an IPv4 header without
+		// options.
+		xsum := header.Checksum(pkt[:header.IPv4MinimumSize-2*header.IPv4AddressSize], header.AddressPairChecksum(ip.SourceAddress(), ip.DestinationAddress()))
+		if xsum != 0xffff {
+			t.Errorf("got IPv4 header checksum from AddressPairChecksum = %#x, want = 0xffff", xsum)
+		}
+	})
+}
+
+func BenchmarkAddressPairChecksum(b *testing.B) {
+	const (
+		src = tcpip.Address("\xfd\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+		dst = tcpip.Address("\xfd\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02")
+	)
+
+	// Simulate a burst of segments of varying lengths to the same peer.
+	b.Run("per packet", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = header.PseudoHeaderChecksum(header.TCPProtocolNumber, src, dst, uint16(i))
+		}
+	})
+
+	b.Run("cached address pair", func(b *testing.B) {
+		addrSum := header.AddressPairChecksum(src, dst)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_ = header.ChecksumCombine(addrSum, header.ChecksumCombine(uint16(header.TCPProtocolNumber), uint16(i)))
+		}
+	})
+}
+
+func TestChecksumToField(t *testing.T) {
+	tests := []struct {
+		sum  uint16
+		want uint16
+	}{
+		{sum: 0, want: 0xffff},
+		{sum: 0xffff, want: 0},
+		{sum: 0x1234, want: 0xedcb},
+	}
+
+	for _, test := range tests {
+		if got := header.ChecksumToField(test.sum); got != test.want {
+			t.Errorf("got header.ChecksumToField(%#04x) = %#04x, want = %#04x", test.sum, got, test.want)
+		}
+	}
+}
+
+func TestPseudoHeaderBytes(t *testing.T) {
+	tests := []struct {
+		name     string
+		src, dst tcpip.Address
+		want     []byte
+	}{
+		{
+			name: "IPv4",
+			src:  tcpip.Address("\x0a\x00\x00\x01"),
+			dst:  tcpip.Address("\x0a\x00\x00\x02"),
+			want: []byte{
+				// Source Address.
+				10, 0, 0, 1,
+				// Destination Address.
+				10, 0, 0, 2,
+				// Zero, Protocol and TCP Length.
+				0, 6, 0x05, 0xdc,
+			},
+		},
+		{
+			name: "IPv6",
+			src:  tcpip.Address("\xfd\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01"),
+			dst:  tcpip.Address("\xfd\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02"),
+			want: []byte{
+				// Source Address.
+				0xfd, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1,
+				// Destination Address.
+				0xfd, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 2,
+				// Upper-Layer Packet Length.
+				0, 0, 0x05, 0xdc,
+				// Zero and Next Header.
+				0, 0, 0, 6,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			const length = 1500
+			got := header.PseudoHeaderBytes(header.TCPProtocolNumber, test.src, test.dst, length)
+			if !bytes.Equal(got, test.want) {
+				t.Errorf("got header.PseudoHeaderBytes(...) = %x, want = %x", got, test.want)
+			}
+			if got, want := header.Checksum(got, 0), header.PseudoHeaderChecksum(header.TCPProtocolNumber, test.src, test.dst, length); got != want {
+				t.Errorf("got checksum of pseudo-header bytes = %#x, want = %#x", got, want)
+			}
+		})
+	}
+}
+
+func TestChecksumExcludingField(t *testing.T) {
+	rnd := rand.New(rand.NewSource(42))
+	for _, size := range []int{2, 3, 20, 21, 60, 64, 65, 127, 1024} {
+		buf := make([]byte, size)
+		rnd.Read(buf)
+		initial := uint16(rnd.Intn(65536))
+		for _, fieldLen := range []int{1, 2, 3} {
+			// Every offset is covered, including odd ones and the
+			// checksum fields of the IPv4 (10), UDP (6) and TCP (16)
+			// headers.
+			for fieldOffset := 0; fieldOffset+fieldLen <= size; fieldOffset++ {
+				t.Run(fmt.Sprintf("size=%d/fieldLen=%d/fieldOffset=%d", size, fieldLen, fieldOffset), func(t *testing.T) {
+					zeroed := append([]byte(nil), buf...)
+					for i := 0; i < fieldLen; i++ {
+						zeroed[fieldOffset+i] = 0
+					}
+					want := header.Checksum(zeroed, initial)
+					orig := append([]byte(nil), buf...)
+					if got := header.ChecksumExcludingField(buf, fieldOffset, fieldLen, initial); got != want {
+						t.Errorf("got ChecksumExcludingField(_, %d, %d, %d) = %#04x, want = %#04x", fieldOffset, fieldLen, initial, got, want)
+					}
+					if !bytes.Equal(buf, orig) {
+						t.Errorf("ChecksumExcludingField modified the buffer: got %x, want %x", buf, orig)
+					}
+				})
+			}
+		}
+	}
+}
@@ -0,0 +1,57 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// ConntrackKey is a direction-independent key identifying a connection. Both
+// directions of a connection map to the same key.
+type ConntrackKey struct {
+	// LowAddr and LowPort are the address and port of the endpoint that
+	// orders first, comparing addresses then ports.
+	LowAddr tcpip.Address
+	LowPort uint16
+
+	// HighAddr and HighPort are the address and port of the other endpoint.
+	HighAddr tcpip.Address
+	HighPort uint16
+
+	// Proto is the transport protocol number.
+	Proto uint8
+}
+
+// MakeConntrackKey returns the connection tracking key for a packet with the
+// given 5-tuple.
+//
+// The endpoints are ordered by address then port so that a packet and its
+// reply produce the same key. reversed is true iff the packet's source is the
+// key's high endpoint; a packet and its reply always have opposite values of
+// reversed.
+func MakeConntrackKey(src, dst tcpip.Address, srcPort, dstPort uint16, proto uint8) (key ConntrackKey, reversed bool) {
+	reversed = src > dst || (src == dst && srcPort > dstPort)
+	if reversed {
+		src, dst = dst, src
+		srcPort, dstPort = dstPort, srcPort
+	}
+	return ConntrackKey{
+		LowAddr:  src,
+		LowPort:  srcPort,
+		HighAddr: dst,
+		HighPort: dstPort,
+		Proto:    proto,
+	}, reversed
+}
@@ -0,0 +1,76 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestMakeConntrackKey(t *testing.T) {
+	tests := []struct {
+		name    string
+		src     tcpip.Address
+		dst     tcpip.Address
+		srcPort uint16
+		dstPort uint16
+	}{
+		{
+			name:    "IPv4",
+			src:     testIPv4SrcAddr,
+			dst:     testIPv4DstAddr,
+			srcPort: 40000,
+			dstPort: 80,
+		},
+		{
+			name:    "IPv6",
+			src:     header.IPv6Loopback,
+			dst:     header.IPv6AllNodesMulticastAddress,
+			srcPort: 5353,
+			dstPort: 5353,
+		},
+		{
+			name:    "SameAddress",
+			src:     testIPv4SrcAddr,
+			dst:     testIPv4SrcAddr,
+			srcPort: 40000,
+			dstPort: 80,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proto := uint8(header.TCPProtocolNumber)
+			key, reversed := header.MakeConntrackKey(test.src, test.dst, test.srcPort, test.dstPort, proto)
+			replyKey, replyReversed := header.MakeConntrackKey(test.dst, test.src, test.dstPort, test.srcPort, proto)
+			if key != replyKey {
+				t.Errorf("got reply key = %+v, want = %+v", replyKey, key)
+			}
+			if reversed == replyReversed {
+				t.Errorf("got reversed = %t for both directions", reversed)
+			}
+			if key.Proto != proto {
+				t.Errorf("got key.Proto = %d, want = %d", key.Proto, proto)
+			}
+
+			otherKey, _ := header.MakeConntrackKey(test.src, test.dst, test.srcPort+1, test.dstPort, proto)
+			if otherKey == key {
+				t.Errorf("got the same key %+v for a different source port", key)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// RFC 2131 section 2 defines the format of a DHCP message as the fixed BOOTP
+// fields, followed by the options field whose first four bytes are the magic
+// cookie:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+---------------+---------------+---------------+---------------+
+//	|     op (1)    |   htype (1)   |   hlen (1)    |   hops (1)    |
+//	+---------------+---------------+---------------+---------------+
+//	|                            xid (4)                            |
+//	+-------------------------------+-------------------------------+
+//	|           secs (2)            |           flags (2)           |
+//	+-------------------------------+-------------------------------+
+//	|                          ciaddr  (4)                          |
+//	+---------------------------------------------------------------+
+//	|                          yiaddr  (4)                          |
+//	+---------------------------------------------------------------+
+//	|                          siaddr  (4)                          |
+//	+---------------------------------------------------------------+
+//	|                          giaddr  (4)                          |
+//	+---------------------------------------------------------------+
+//	|                          chaddr  (16)                         |
+//	+---------------------------------------------------------------+
+//	|                          sname   (64)                         |
+//	+---------------------------------------------------------------+
+//	|                          file    (128)                        |
+//	+---------------------------------------------------------------+
+//	|                          options (variable)                   |
+//	+---------------------------------------------------------------+
+const (
+	dhcpv4Op           = 0
+	dhcpv4MagicCookie  = DHCPv4BOOTPSize
+	dhcpv4OptionsStart = DHCPv4BOOTPSize + dhcpv4MagicCookieSize
+
+	dhcpv4MagicCookieSize = 4
+)
+
+// dhcpv4MagicCookieValue is the magic cookie that starts the options field,
+// as per RFC 2131 section 3.
+var dhcpv4MagicCookieValue = [dhcpv4MagicCookieSize]byte{99, 130, 83, 99}
+
+const (
+	// DHCPv4ServerPort is the UDP port DHCP servers listen on.
+	DHCPv4ServerPort = 67
+
+	// DHCPv4ClientPort is the UDP port DHCP clients listen on.
+	DHCPv4ClientPort = 68
+
+	// DHCPv4BOOTPSize is the size of the fixed BOOTP fields of a DHCP
+	// message.
+	DHCPv4BOOTPSize = 236
+
+	// DHCPv4MinimumSize is the minimum size of a DHCP message, the fixed
+	// BOOTP fields followed by the magic cookie.
+	DHCPv4MinimumSize = dhcpv4OptionsStart
+)
+
+// The values of the op field of a DHCP message.
+const (
+	DHCPv4OpRequest uint8 = 1
+	DHCPv4OpReply   uint8 = 2
+)
+
+// ErrDHCPv4Malformed is returned by ValidateDHCPv4 when a DHCP message holds
+// an unknown op or does not start its options with the magic cookie.
+var ErrDHCPv4Malformed = errors.New("malformed DHCPv4 message")
+
+// ValidateDHCPv4 checks that the DHCP message held in payload, a UDP payload,
+// holds the fixed BOOTP fields and the magic cookie, and that its op is
+// DHCPv4OpRequest or DHCPv4OpReply. The options following the magic cookie
+// may be parsed after it returns nil.
+func ValidateDHCPv4(payload []byte) error {
+	if len(payload) < DHCPv4MinimumSize {
+		return fmt.Errorf("got %d bytes, want at least %d: %w", len(payload), DHCPv4MinimumSize, io.ErrUnexpectedEOF)
+	}
+	if op := payload[dhcpv4Op]; op != DHCPv4OpRequest && op != DHCPv4OpReply {
+		return fmt.Errorf("got op = %d, want = %d or %d: %w", op, DHCPv4OpRequest, DHCPv4OpReply, ErrDHCPv4Malformed)
+	}
+	if cookie := payload[dhcpv4MagicCookie:dhcpv4OptionsStart]; !bytes.Equal(cookie, dhcpv4MagicCookieValue[:]) {
+		return fmt.Errorf("got magic cookie = %v, want = %v: %w", cookie, dhcpv4MagicCookieValue, ErrDHCPv4Malformed)
+	}
+	return nil
+}
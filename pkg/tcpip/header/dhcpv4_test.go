@@ -0,0 +1,96 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestValidateDHCPv4(t *testing.T) {
+	// makeMessage returns a DHCP message with the given op, followed by the
+	// magic cookie and options.
+	makeMessage := func(op uint8, options ...byte) []byte {
+		b := make([]byte, header.DHCPv4BOOTPSize)
+		b[0] = op
+		// htype = Ethernet, hlen = 6.
+		b[1] = 1
+		b[2] = 6
+		// xid.
+		copy(b[4:], []byte{0xde, 0xad, 0xbe, 0xef})
+		// yiaddr.
+		copy(b[16:], []byte{192, 168, 1, 100})
+		return append(append(b, 99, 130, 83, 99), options...)
+	}
+	offer := makeMessage(header.DHCPv4OpReply,
+		// DHCP Message Type = DHCPOFFER.
+		53, 1, 2,
+		// End.
+		255,
+	)
+	badCookie := makeMessage(header.DHCPv4OpReply)
+	badCookie[header.DHCPv4BOOTPSize] = 0
+
+	tests := []struct {
+		name    string
+		payload []byte
+		wantErr error
+	}{
+		{
+			name:    "OFFER",
+			payload: offer,
+		},
+		{
+			name:    "request without options",
+			payload: makeMessage(header.DHCPv4OpRequest),
+		},
+		{
+			name:    "empty",
+			payload: nil,
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "missing magic cookie",
+			payload: offer[:header.DHCPv4BOOTPSize],
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "truncated magic cookie",
+			payload: offer[:header.DHCPv4MinimumSize-1],
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "unknown op",
+			payload: makeMessage(3),
+			wantErr: header.ErrDHCPv4Malformed,
+		},
+		{
+			name:    "bad magic cookie",
+			payload: badCookie,
+			wantErr: header.ErrDHCPv4Malformed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if err := header.ValidateDHCPv4(test.payload); !errors.Is(err, test.wantErr) {
+				t.Errorf("got ValidateDHCPv4(_) = %v, want = %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"encoding/binary"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// RFC 1035 section 4.1.1 defines the header of a DNS message as:
+//
+//	                                1  1  1  1  1  1
+//	  0  1  2  3  4  5  6  7  8  9  0  1  2  3  4  5
+//	+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+//	|                      ID                       |
+//	+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+//	|QR|   Opcode  |AA|TC|RD|RA|   Z    |   RCODE   |
+//	+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+//	|                    QDCOUNT                    |
+//	+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+//	|                    ANCOUNT                    |
+//	+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+//	|                    NSCOUNT                    |
+//	+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+//	|                    ARCOUNT                    |
+//	+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+--+
+const (
+	dnsID    = 0
+	dnsFlags = 2
+
+	// dnsFlagResponse is the QR bit of the first flags byte, set in
+	// responses and cleared in queries.
+	dnsFlagResponse = 0x80
+)
+
+const (
+	// DNSPort is the well-known UDP port for DNS, as per RFC 1035 section
+	// 4.2.1.
+	DNSPort = 53
+
+	// DNSHeaderSize is the size of the DNS message header.
+	DNSHeaderSize = 12
+
+	// MDNSPort is the UDP port for multicast DNS, as per RFC 6762 section 3.
+	MDNSPort = 5353
+
+	// MDNSIPv4Address is the IPv4 multicast address for multicast DNS, as
+	// per RFC 6762 section 3.
+	MDNSIPv4Address tcpip.Address = "\xe0\x00\x00\xfb"
+
+	// MDNSIPv6Address is the link-local scoped IPv6 multicast address for
+	// multicast DNS, as per RFC 6762 section 3.
+	MDNSIPv6Address tcpip.Address = "\xff\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xfb"
+)
+
+// IsDNSResponse parses the header of the DNS message held in udpPayload and
+// returns its ID and whether it is a response, as indicated by the QR bit.
+//
+// ok is false if udpPayload is too short to hold a DNS header.
+func IsDNSResponse(udpPayload []byte) (id uint16, isResponse bool, ok bool) {
+	if len(udpPayload) < DNSHeaderSize {
+		return 0, false, false
+	}
+	id = binary.BigEndian.Uint16(udpPayload[dnsID:])
+	isResponse = udpPayload[dnsFlags]&dnsFlagResponse != 0
+	return id, isResponse, true
+}
+
+// IsMDNS returns true iff a UDP datagram destined to dst and dstPort is a
+// multicast DNS message, i.e. it is sent to MDNSPort on MDNSIPv4Address or
+// MDNSIPv6Address.
+func IsMDNS(dst tcpip.Address, dstPort uint16) bool {
+	return dstPort == MDNSPort && (dst == MDNSIPv4Address || dst == MDNSIPv6Address)
+}
@@ -0,0 +1,118 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestIsDNSResponse(t *testing.T) {
+	tests := []struct {
+		name           string
+		payload        []byte
+		wantID         uint16
+		wantIsResponse bool
+		wantOK         bool
+	}{
+		{
+			name: "Query",
+			payload: []byte{
+				// ID.
+				0x12, 0x34,
+				// Flags = RD.
+				0x01, 0x00,
+				// QDCOUNT, ANCOUNT, NSCOUNT and ARCOUNT.
+				0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+			},
+			wantID: 0x1234,
+			wantOK: true,
+		},
+		{
+			name: "Response",
+			payload: []byte{
+				// ID.
+				0x12, 0x34,
+				// Flags = QR | RD | RA.
+				0x81, 0x80,
+				// QDCOUNT, ANCOUNT, NSCOUNT and ARCOUNT.
+				0x00, 0x01, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00,
+			},
+			wantID:         0x1234,
+			wantIsResponse: true,
+			wantOK:         true,
+		},
+		{
+			name:    "Truncated",
+			payload: []byte{0x12, 0x34, 0x81, 0x80},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			id, isResponse, ok := header.IsDNSResponse(test.payload)
+			if id != test.wantID || isResponse != test.wantIsResponse || ok != test.wantOK {
+				t.Errorf("got header.IsDNSResponse(_) = (%#x, %t, %t), want = (%#x, %t, %t)", id, isResponse, ok, test.wantID, test.wantIsResponse, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestIsMDNS(t *testing.T) {
+	tests := []struct {
+		name    string
+		dst     tcpip.Address
+		dstPort uint16
+		want    bool
+	}{
+		{
+			name:    "IPv4 mDNS",
+			dst:     tcpip.Address("\xe0\x00\x00\xfb"),
+			dstPort: 5353,
+			want:    true,
+		},
+		{
+			name:    "IPv6 mDNS",
+			dst:     tcpip.Address("\xff\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xfb"),
+			dstPort: 5353,
+			want:    true,
+		},
+		{
+			name:    "unicast DNS",
+			dst:     testIPv4DstAddr,
+			dstPort: header.DNSPort,
+		},
+		{
+			name:    "DNS port on mDNS address",
+			dst:     header.MDNSIPv4Address,
+			dstPort: header.DNSPort,
+		},
+		{
+			name:    "mDNS port on unicast address",
+			dst:     uniqueLocalAddr1,
+			dstPort: header.MDNSPort,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.IsMDNS(test.dst, test.dstPort); got != test.want {
+				t.Errorf("got header.IsMDNS(%s, %d) = %t, want = %t", test.dst, test.dstPort, got, test.want)
+			}
+		})
+	}
+}
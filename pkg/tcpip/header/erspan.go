@@ -0,0 +1,194 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"encoding/binary"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// ERSPAN mirrored frames are carried over GRE, whose header is defined by RFC
+// 2784 and RFC 2890 as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|C| |K|S| Reserved0       | Ver |         Protocol Type         |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|      Checksum (optional)      |       Reserved1 (Optional)    |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                         Key (optional)                        |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                 Sequence Number (Optional)                    |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// draft-foschiano-erspan section 4.1 defines the ERSPAN Type II header that
+// follows it as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|  Ver  |          VLAN         | COS | En|T|    Session ID     |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|      Reserved         |                  Index                |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// and section 4.2 defines the ERSPAN Type III header as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|  Ver  |          VLAN         | COS |BSO|T|     Session ID    |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                          Timestamp                            |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|             SGT               |P|    FT   |   Hw ID   |D|Gra|O|
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// where the O flag indicates that an 8-byte platform specific subheader
+// follows. The mirrored Ethernet frame follows the ERSPAN header.
+const (
+	greFlags        = 0
+	greProtocolType = 2
+
+	greFlagChecksum = 0x8000
+	greFlagKey      = 0x2000
+	greFlagSequence = 0x1000
+	greVersionMask  = 0x7
+	greOptionSize   = 4
+
+	erspanVersion       = 0
+	erspanVLAN          = 0
+	erspanSessionID     = 2
+	erspanTypeIIIFlags  = 11
+	erspanVersionShift  = 4
+	erspanVLANMask      = 0xfff
+	erspanSessionIDMask = 0x3ff
+
+	// erspanTypeIIIFlagOptional is the O flag of the ERSPAN Type III header.
+	erspanTypeIIIFlagOptional = 1 << 0
+)
+
+const (
+	// GREProtocolNumber is GRE's transport protocol number.
+	GREProtocolNumber tcpip.TransportProtocolNumber = 47
+
+	// GREMinimumSize is the size of a GRE header without optional fields.
+	GREMinimumSize = 4
+
+	// ERSPANTypeIIProtocolType is the GRE protocol type of ERSPAN Type II.
+	ERSPANTypeIIProtocolType = 0x88be
+
+	// ERSPANTypeIIIProtocolType is the GRE protocol type of ERSPAN Type III.
+	ERSPANTypeIIIProtocolType = 0x22eb
+
+	// ERSPANTypeIIHeaderSize is the size of the ERSPAN Type II header.
+	ERSPANTypeIIHeaderSize = 8
+
+	// ERSPANTypeIIIHeaderSize is the size of the ERSPAN Type III header,
+	// without the platform specific subheader.
+	ERSPANTypeIIIHeaderSize = 12
+
+	// ERSPANPlatformSubheaderSize is the size of the optional platform
+	// specific subheader of ERSPAN Type III.
+	ERSPANPlatformSubheaderSize = 8
+)
+
+// The values of the Ver field of the ERSPAN header.
+const (
+	ERSPANVersionTypeII  uint8 = 1
+	ERSPANVersionTypeIII uint8 = 2
+)
+
+// ERSPAN represents an ERSPAN Type II or Type III header, followed by the
+// mirrored frame, stored in a byte array. It is returned by ParseERSPAN.
+type ERSPAN []byte
+
+// ParseERSPAN returns the ERSPAN header carried by the GRE packet gre, the
+// payload of an IP packet carrying GREProtocolNumber. The returned header
+// aliases gre.
+//
+// The type of ERSPAN is given by the GRE protocol type and must match the
+// version of the ERSPAN header. ok is false if gre does not carry ERSPAN or if
+// it is too short to hold the GRE header, its optional fields and the whole
+// ERSPAN header.
+func ParseERSPAN(gre []byte) (hdr ERSPAN, ok bool) {
+	if len(gre) < GREMinimumSize {
+		return nil, false
+	}
+	flags := binary.BigEndian.Uint16(gre[greFlags:])
+	if flags&greVersionMask != 0 {
+		return nil, false
+	}
+	greLen := GREMinimumSize
+	for _, f := range []uint16{greFlagChecksum, greFlagKey, greFlagSequence} {
+		if flags&f != 0 {
+			greLen += greOptionSize
+		}
+	}
+	if len(gre) < greLen {
+		return nil, false
+	}
+	hdr = ERSPAN(gre[greLen:])
+
+	var wantVersion uint8
+	switch binary.BigEndian.Uint16(gre[greProtocolType:]) {
+	case ERSPANTypeIIProtocolType:
+		wantVersion = ERSPANVersionTypeII
+	case ERSPANTypeIIIProtocolType:
+		wantVersion = ERSPANVersionTypeIII
+	default:
+		return nil, false
+	}
+	if len(hdr) < ERSPANTypeIIHeaderSize || hdr.Version() != wantVersion || len(hdr) < hdr.HeaderLength() {
+		return nil, false
+	}
+	return hdr, true
+}
+
+// Version returns the version of the ERSPAN header, one of the ERSPANVersion*
+// values.
+func (b ERSPAN) Version() uint8 {
+	return b[erspanVersion] >> erspanVersionShift
+}
+
+// VLAN returns the VLAN of the mirrored frame.
+func (b ERSPAN) VLAN() uint16 {
+	return binary.BigEndian.Uint16(b[erspanVLAN:]) & erspanVLANMask
+}
+
+// SessionID returns the ERSPAN session ID, identifying the SPAN session the
+// frame was mirrored by.
+func (b ERSPAN) SessionID() uint16 {
+	return binary.BigEndian.Uint16(b[erspanSessionID:]) & erspanSessionIDMask
+}
+
+// HeaderLength returns the length of the ERSPAN header in bytes, including the
+// platform specific subheader of Type III headers carrying it.
+func (b ERSPAN) HeaderLength() int {
+	if b.Version() != ERSPANVersionTypeIII {
+		return ERSPANTypeIIHeaderSize
+	}
+	if len(b) >= ERSPANTypeIIIHeaderSize && b[erspanTypeIIIFlags]&erspanTypeIIIFlagOptional != 0 {
+		return ERSPANTypeIIIHeaderSize + ERSPANPlatformSubheaderSize
+	}
+	return ERSPANTypeIIIHeaderSize
+}
+
+// Payload returns the mirrored Ethernet frame.
+func (b ERSPAN) Payload() []byte {
+	return b[b.HeaderLength():]
+}
@@ -0,0 +1,147 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestParseERSPAN(t *testing.T) {
+	frame := []byte{
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x01,
+		0x02, 0x00, 0x00, 0x00, 0x00, 0x02,
+		0x08, 0x00,
+	}
+	concat := func(bs ...[]byte) []byte {
+		var b []byte
+		for _, p := range bs {
+			b = append(b, p...)
+		}
+		return b
+	}
+	// A GRE header with the S flag and a sequence number, as sent by ERSPAN
+	// sources.
+	greTypeII := []byte{0x10, 0x00, 0x88, 0xbe, 0x00, 0x00, 0x00, 0x01}
+	greTypeIII := []byte{0x10, 0x00, 0x22, 0xeb, 0x00, 0x00, 0x00, 0x01}
+	typeII := []byte{0x10, 0x64, 0x19, 0x23, 0x00, 0x00, 0x00, 0x05}
+
+	tests := []struct {
+		name          string
+		gre           []byte
+		wantOK        bool
+		wantVersion   uint8
+		wantVLAN      uint16
+		wantSessionID uint16
+	}{
+		{
+			name:          "Type II",
+			gre:           concat(greTypeII, typeII, frame),
+			wantOK:        true,
+			wantVersion:   header.ERSPANVersionTypeII,
+			wantVLAN:      100,
+			wantSessionID: 0x123,
+		},
+		{
+			name: "Type II with GRE key",
+			gre: concat(
+				[]byte{0x30, 0x00, 0x88, 0xbe, 0x00, 0x00, 0x00, 0x2a, 0x00, 0x00, 0x00, 0x01},
+				typeII,
+				frame,
+			),
+			wantOK:        true,
+			wantVersion:   header.ERSPANVersionTypeII,
+			wantVLAN:      100,
+			wantSessionID: 0x123,
+		},
+		{
+			name: "Type III",
+			gre: concat(
+				greTypeIII,
+				[]byte{0x20, 0x00, 0x00, 0x07, 0x01, 0x02, 0x03, 0x04, 0x00, 0x00, 0x00, 0x00},
+				frame,
+			),
+			wantOK:        true,
+			wantVersion:   header.ERSPANVersionTypeIII,
+			wantSessionID: 7,
+		},
+		{
+			name: "Type III with platform specific subheader",
+			gre: concat(
+				greTypeIII,
+				[]byte{0x20, 0x00, 0x00, 0x07, 0x01, 0x02, 0x03, 0x04, 0x00, 0x00, 0x00, 0x01},
+				[]byte{0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00},
+				frame,
+			),
+			wantOK:        true,
+			wantVersion:   header.ERSPANVersionTypeIII,
+			wantSessionID: 7,
+		},
+		{
+			name: "Type III truncated platform specific subheader",
+			gre: concat(
+				greTypeIII,
+				[]byte{0x20, 0x00, 0x00, 0x07, 0x01, 0x02, 0x03, 0x04, 0x00, 0x00, 0x00, 0x01},
+				[]byte{0x08, 0x00, 0x00, 0x00},
+			),
+		},
+		{
+			name: "Type II protocol type with Type III version",
+			gre:  concat(greTypeII, []byte{0x20, 0x64, 0x19, 0x23, 0x00, 0x00, 0x00, 0x05}, frame),
+		},
+		{
+			name: "truncated Type II header",
+			gre:  concat(greTypeII, typeII[:header.ERSPANTypeIIHeaderSize-1]),
+		},
+		{
+			name: "truncated GRE sequence number",
+			gre:  greTypeII[:6],
+		},
+		{
+			name: "not ERSPAN",
+			gre:  concat([]byte{0x00, 0x00, 0x08, 0x00}, frame),
+		},
+		{
+			name: "non-zero GRE version",
+			gre:  concat([]byte{0x10, 0x01, 0x88, 0xbe, 0x00, 0x00, 0x00, 0x01}, typeII, frame),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			erspan, ok := header.ParseERSPAN(test.gre)
+			if ok != test.wantOK {
+				t.Fatalf("got header.ParseERSPAN(_) = (_, %t), want = (_, %t)", ok, test.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got := erspan.Version(); got != test.wantVersion {
+				t.Errorf("got Version() = %d, want = %d", got, test.wantVersion)
+			}
+			if got := erspan.VLAN(); got != test.wantVLAN {
+				t.Errorf("got VLAN() = %d, want = %d", got, test.wantVLAN)
+			}
+			if got := erspan.SessionID(); got != test.wantSessionID {
+				t.Errorf("got SessionID() = %#x, want = %#x", got, test.wantSessionID)
+			}
+			if got := erspan.Payload(); !bytes.Equal(got, frame) {
+				t.Errorf("got Payload() = %x, want = %x", got, frame)
+			}
+		})
+	}
+}
@@ -16,6 +16,8 @@ package header
 
 import (
 	"encoding/binary"
+	"fmt"
+	"strconv"
 
 	"gvisor.dev/gvisor/pkg/tcpip"
 )
@@ -46,6 +48,11 @@ const (
 	// EthernetMinimumSize is the minimum size of a valid ethernet frame.
 	EthernetMinimumSize = 14
 
+	// EthernetMinimumFrameSize is the minimum size of an ethernet frame on the
+	// wire, excluding the 4 byte frame check sequence, as per IEEE 802.3
+	// clause 4.4.2.
+	EthernetMinimumFrameSize = 60
+
 	// EthernetAddressSize is the size, in bytes, of an ethernet address.
 	EthernetAddressSize = 6
 
@@ -185,3 +192,69 @@ func EthernetAddressFromMulticastIPv6Address(addr tcpip.Address) tcpip.LinkAddre
 	linkAddrBytes[1] = 0x33
 	return tcpip.LinkAddress(linkAddrBytes[:])
 }
+
+// ParseLinkAddress parses an ethernet address in one of the common formats:
+// colon separated (aa:bb:cc:dd:ee:ff), hyphen separated (aa-bb-cc-dd-ee-ff) or
+// dot separated groups of 4 hex digits (aabb.ccdd.eeff).
+func ParseLinkAddress(s string) (tcpip.LinkAddress, error) {
+	var (
+		groupLen int
+		sep      byte
+	)
+	switch {
+	case len(s) == 3*EthernetAddressSize-1 && (s[2] == ':' || s[2] == '-'):
+		groupLen, sep = 2, s[2]
+	case len(s) == 5*EthernetAddressSize/2-1 && s[4] == '.':
+		groupLen, sep = 4, '.'
+	default:
+		return "", fmt.Errorf("unrecognized link address format: %q", s)
+	}
+
+	addr := make([]byte, 0, EthernetAddressSize)
+	for i := 0; i < len(s); i += groupLen + 1 {
+		if i != 0 && s[i-1] != sep {
+			return "", fmt.Errorf("inconsistent separators in link address: %q", s)
+		}
+		v, err := strconv.ParseUint(s[i:i+groupLen], 16, 4*groupLen)
+		if err != nil {
+			return "", fmt.Errorf("invalid hex digits in link address %q: %w", s, err)
+		}
+		for j := groupLen/2 - 1; j >= 0; j-- {
+			addr = append(addr, byte(v>>(8*j)))
+		}
+	}
+	return tcpip.LinkAddress(addr), nil
+}
+
+// LinkAddressString returns the canonical representation of addr: its bytes
+// as pairs of lowercase hex digits separated by colons.
+func LinkAddressString(addr tcpip.LinkAddress) string {
+	const hexDigits = "0123456789abcdef"
+
+	if len(addr) == 0 {
+		return ""
+	}
+	b := make([]byte, 0, 3*len(addr)-1)
+	for i := 0; i < len(addr); i++ {
+		if i != 0 {
+			b = append(b, ':')
+		}
+		b = append(b, hexDigits[addr[i]>>4], hexDigits[addr[i]&0xf])
+	}
+	return string(b)
+}
+
+// EthernetPadding returns the number of zero bytes that must be appended to a
+// frame of frameLen bytes to reach EthernetMinimumFrameSize.
+func EthernetPadding(frameLen int) int {
+	if frameLen >= EthernetMinimumFrameSize {
+		return 0
+	}
+	return EthernetMinimumFrameSize - frameLen
+}
+
+// PadEthernetFrame returns frame zero-padded to EthernetMinimumFrameSize. The
+// frame is returned as is if it is already long enough.
+func PadEthernetFrame(frame []byte) []byte {
+	return append(frame, make([]byte, EthernetPadding(len(frame)))...)
+}
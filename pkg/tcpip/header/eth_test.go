@@ -15,6 +15,7 @@
 package header
 
 import (
+	"bytes"
 	"testing"
 
 	"gvisor.dev/gvisor/pkg/tcpip"
@@ -147,3 +148,101 @@ func TestEthernetAddressFromMulticastIPv6Address(t *testing.T) {
 		t.Fatalf("got EthernetAddressFromMulticastIPv6Address(%s) = %s, want = %s", addr, got, want)
 	}
 }
+
+func TestParseLinkAddress(t *testing.T) {
+	const want = tcpip.LinkAddress("\x02\x1a\xb3\x4c\xd5\x6e")
+
+	tests := []struct {
+		name    string
+		s       string
+		wantErr bool
+	}{
+		{name: "colon", s: "02:1a:b3:4c:d5:6e"},
+		{name: "colon uppercase", s: "02:1A:B3:4C:D5:6E"},
+		{name: "hyphen", s: "02-1a-b3-4c-d5-6e"},
+		{name: "dotted", s: "021a.b34c.d56e"},
+		{name: "mixed separators", s: "02:1a-b3:4c:d5:6e", wantErr: true},
+		{name: "too short", s: "02:1a:b3:4c:d5", wantErr: true},
+		{name: "empty group", s: "02::1a:b3:4c:d5:6e", wantErr: true},
+		{name: "invalid hex", s: "02:1a:b3:4c:d5:6g", wantErr: true},
+		{name: "sign", s: "+2:1a:b3:4c:d5:6e", wantErr: true},
+		{name: "dotted short group", s: "21a.b34c.d56e0", wantErr: true},
+		{name: "empty", s: "", wantErr: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ParseLinkAddress(test.s)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("got ParseLinkAddress(%q) = (%s, nil), want non-nil error", test.s, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseLinkAddress(%q): %s", test.s, err)
+			}
+			if got != want {
+				t.Fatalf("got ParseLinkAddress(%q) = %s, want = %s", test.s, got, want)
+			}
+
+			// The canonical form must round-trip.
+			s := LinkAddressString(got)
+			if s != "02:1a:b3:4c:d5:6e" {
+				t.Errorf("got LinkAddressString(%s) = %q, want = %q", got, s, "02:1a:b3:4c:d5:6e")
+			}
+			if roundTrip, err := ParseLinkAddress(s); err != nil || roundTrip != want {
+				t.Errorf("got ParseLinkAddress(%q) = (%s, %v), want = (%s, nil)", s, roundTrip, err, want)
+			}
+		})
+	}
+}
+
+func TestLinkAddressString(t *testing.T) {
+	tests := []struct {
+		addr tcpip.LinkAddress
+		want string
+	}{
+		{addr: "", want: ""},
+		{addr: "\x00\x00\x00\x00\x00\x00", want: "00:00:00:00:00:00"},
+		{addr: "\xff\xff\xff\xff\xff\xff", want: "ff:ff:ff:ff:ff:ff"},
+		{addr: "\x01\x02", want: "01:02"},
+	}
+
+	for _, test := range tests {
+		if got := LinkAddressString(test.addr); got != test.want {
+			t.Errorf("got LinkAddressString(%x) = %q, want = %q", []byte(test.addr), got, test.want)
+		}
+	}
+}
+
+func TestEthernetPadding(t *testing.T) {
+	tests := []struct {
+		name     string
+		frameLen int
+		wantPad  int
+	}{
+		{name: "header only", frameLen: EthernetMinimumSize, wantPad: 46},
+		{name: "short", frameLen: 40, wantPad: 20},
+		{name: "minimum", frameLen: EthernetMinimumFrameSize, wantPad: 0},
+		{name: "long", frameLen: 70, wantPad: 0},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := EthernetPadding(test.frameLen); got != test.wantPad {
+				t.Errorf("got EthernetPadding(%d) = %d, want = %d", test.frameLen, got, test.wantPad)
+			}
+
+			// Use a non-zero frame with spare capacity holding garbage to make
+			// sure that the padding is zeroed.
+			buf := bytes.Repeat([]byte{0xaa}, test.frameLen+test.wantPad)
+			frame := buf[:test.frameLen]
+			got := PadEthernetFrame(frame)
+			want := append(bytes.Repeat([]byte{0xaa}, test.frameLen), make([]byte, test.wantPad)...)
+			if !bytes.Equal(got, want) {
+				t.Errorf("got PadEthernetFrame(_) = %x, want = %x", got, want)
+			}
+		})
+	}
+}
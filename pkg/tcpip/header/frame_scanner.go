@@ -0,0 +1,97 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import "fmt"
+
+// FrameFormat describes a length-prefixed framing of a byte stream, where
+// each frame starts with a fixed-size header holding the length of the frame
+// payload that follows it.
+type FrameFormat struct {
+	// HeaderSize is the size of the frame header in bytes.
+	HeaderSize int
+
+	// LengthOffset is the offset of the big-endian length field within the
+	// frame header.
+	LengthOffset int
+
+	// LengthWidth is the width of the length field in bytes, between 1 and 4.
+	LengthWidth int
+}
+
+// HTTP2FrameFormat is the framing of HTTP/2, whose frames start with a 9-byte
+// header holding a 24-bit payload length, as per RFC 7540 section 4.1.
+var HTTP2FrameFormat = FrameFormat{
+	HeaderSize:   9,
+	LengthOffset: 0,
+	LengthWidth:  3,
+}
+
+// FrameScanner splits a reassembled byte stream into frames following a
+// FrameFormat, buffering partial frames until they are complete.
+type FrameScanner struct {
+	format FrameFormat
+	buf    []byte
+}
+
+// MakeFrameScanner returns a FrameScanner for frames following format.
+func MakeFrameScanner(format FrameFormat) FrameScanner {
+	if format.LengthWidth < 1 || format.LengthWidth > 4 {
+		panic(fmt.Sprintf("got LengthWidth = %d, want between 1 and 4", format.LengthWidth))
+	}
+	if format.LengthOffset < 0 || format.LengthOffset+format.LengthWidth > format.HeaderSize {
+		panic(fmt.Sprintf("got length field at offset %d of width %d, want it within the %d bytes header", format.LengthOffset, format.LengthWidth, format.HeaderSize))
+	}
+	return FrameScanner{format: format}
+}
+
+// Write appends data read from the stream to the scanner.
+func (s *FrameScanner) Write(data []byte) {
+	s.buf = append(s.buf, data...)
+}
+
+// Buffered returns the number of bytes written to the scanner that do not
+// belong to a frame returned by Next yet.
+func (s *FrameScanner) Buffered() int {
+	return len(s.buf)
+}
+
+// Next returns the next complete frame, including its header, or false if the
+// scanner does not hold a complete frame yet.
+//
+// The returned frame is not modified by subsequent calls to the scanner.
+func (s *FrameScanner) Next() ([]byte, bool) {
+	f := s.format
+	if len(s.buf) < f.HeaderSize {
+		return nil, false
+	}
+	var payloadLen int
+	for _, b := range s.buf[f.LengthOffset : f.LengthOffset+f.LengthWidth] {
+		payloadLen = payloadLen<<8 | int(b)
+	}
+	frameLen := f.HeaderSize + payloadLen
+	if len(s.buf) < frameLen {
+		return nil, false
+	}
+
+	frame := s.buf[:frameLen:frameLen]
+	s.buf = s.buf[frameLen:]
+	if len(s.buf) == 0 {
+		// Drop the reference to the underlying array so it can be released
+		// once the returned frames are.
+		s.buf = nil
+	}
+	return frame, true
+}
@@ -0,0 +1,134 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+// makeHTTP2Frame returns an HTTP/2 frame of type typ carrying payload.
+func makeHTTP2Frame(typ uint8, payload []byte) []byte {
+	l := len(payload)
+	frame := []byte{byte(l >> 16), byte(l >> 8), byte(l), typ, 0, 0, 0, 0, 1}
+	return append(frame, payload...)
+}
+
+func TestFrameScannerHTTP2(t *testing.T) {
+	frames := [][]byte{
+		makeHTTP2Frame(4 /* SETTINGS */, nil),
+		makeHTTP2Frame(1 /* HEADERS */, []byte{0x82, 0x86, 0x84}),
+		makeHTTP2Frame(0 /* DATA */, make([]byte, 300)),
+	}
+	var stream []byte
+	for _, f := range frames {
+		stream = append(stream, f...)
+	}
+
+	tests := []struct {
+		name      string
+		chunkSize int
+	}{
+		{name: "whole stream", chunkSize: len(stream)},
+		{name: "byte by byte", chunkSize: 1},
+		{name: "split headers", chunkSize: 5},
+		{name: "split payloads", chunkSize: 100},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := header.MakeFrameScanner(header.HTTP2FrameFormat)
+			var got [][]byte
+			for remaining := stream; len(remaining) != 0; {
+				n := test.chunkSize
+				if n > len(remaining) {
+					n = len(remaining)
+				}
+				s.Write(remaining[:n])
+				remaining = remaining[n:]
+				for {
+					frame, ok := s.Next()
+					if !ok {
+						break
+					}
+					got = append(got, frame)
+				}
+			}
+
+			if diff := cmp.Diff(frames, got); diff != "" {
+				t.Errorf("frames mismatch (-want +got):\n%s", diff)
+			}
+			if n := s.Buffered(); n != 0 {
+				t.Errorf("got s.Buffered() = %d, want = 0", n)
+			}
+		})
+	}
+}
+
+func TestFrameScannerPartialFrame(t *testing.T) {
+	frame := makeHTTP2Frame(0 /* DATA */, []byte{1, 2, 3, 4})
+	s := header.MakeFrameScanner(header.HTTP2FrameFormat)
+
+	s.Write(frame[:4])
+	if _, ok := s.Next(); ok {
+		t.Fatal("got s.Next() = (_, true) with a partial header, want = (_, false)")
+	}
+	s.Write(frame[4:10])
+	if _, ok := s.Next(); ok {
+		t.Fatal("got s.Next() = (_, true) with a partial payload, want = (_, false)")
+	}
+	if got, want := s.Buffered(), 10; got != want {
+		t.Errorf("got s.Buffered() = %d, want = %d", got, want)
+	}
+
+	// Write the rest of the frame along with the start of the next one.
+	s.Write(frame[10:])
+	s.Write(frame[:1])
+	got, ok := s.Next()
+	if !ok {
+		t.Fatal("got s.Next() = (_, false) with a complete frame, want = (_, true)")
+	}
+	if diff := cmp.Diff(frame, got); diff != "" {
+		t.Errorf("frame mismatch (-want +got):\n%s", diff)
+	}
+	if got, want := s.Buffered(), 1; got != want {
+		t.Errorf("got s.Buffered() = %d, want = %d", got, want)
+	}
+}
+
+func TestFrameScannerLengthField(t *testing.T) {
+	// A 2-byte header made of a 1-byte type followed by a 1-byte length.
+	s := header.MakeFrameScanner(header.FrameFormat{
+		HeaderSize:   2,
+		LengthOffset: 1,
+		LengthWidth:  1,
+	})
+	s.Write([]byte{0xaa, 2, 1, 2, 0xbb, 0})
+
+	for _, want := range [][]byte{{0xaa, 2, 1, 2}, {0xbb, 0}} {
+		got, ok := s.Next()
+		if !ok {
+			t.Fatal("got s.Next() = (_, false), want = (_, true)")
+		}
+		if diff := cmp.Diff(want, got); diff != "" {
+			t.Errorf("frame mismatch (-want +got):\n%s", diff)
+		}
+	}
+	if _, ok := s.Next(); ok {
+		t.Error("got s.Next() = (_, true) with no data left, want = (_, false)")
+	}
+}
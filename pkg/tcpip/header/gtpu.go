@@ -0,0 +1,205 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import "encoding/binary"
+
+// 3GPP TS 29.281 section 5.1 defines the GTP-U header that follows the outer
+// UDP header as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|Ver  |P|R|E|S|N|  Message Type  |            Length             |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                 Tunnel Endpoint Identifier                    |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|        Sequence Number        | N-PDU Number  | Next Ext Type |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// The last word is only present if any of the E, S or N flags is set, and
+// each of its fields is only meaningful if the matching flag is set. When E is
+// set, a chain of extension headers follows, each holding its length in
+// 4-byte units in its first byte and the type of the next extension header
+// in its last byte.
+const (
+	gtpuFlags          = 0
+	gtpuMessageType    = 1
+	gtpuLength         = 2
+	gtpuTEID           = 4
+	gtpuSequenceNumber = 8
+	gtpuNPDUNumber     = 10
+	gtpuNextExtType    = 11
+
+	gtpuVersionShift                = 5
+	gtpuFlagProtocolType      uint8 = 1 << 4
+	gtpuOptionalFieldsSize          = 4
+	gtpuExtHdrLenBytesPerUnit       = 4
+
+	// gtpuOptionalFieldsFlags are the flags whose presence adds the optional
+	// fields to the header.
+	gtpuOptionalFieldsFlags = GTPUFlagExtensionHeader | GTPUFlagSequenceNumber | GTPUFlagNPDUNumber
+
+	// gtpuNoMoreExtensionHeaders is the next extension header type that ends
+	// the chain of extension headers.
+	gtpuNoMoreExtensionHeaders = 0
+)
+
+const (
+	// GTPUPort is the UDP port for GTP-U, as per 3GPP TS 29.281 section
+	// 4.4.2.
+	GTPUPort = 2152
+
+	// GTPUMinimumSize is the size of the mandatory part of the GTP-U header.
+	GTPUMinimumSize = 8
+
+	// GTPUVersion is the version of GTP-U described by 3GPP TS 29.281.
+	GTPUVersion = 1
+)
+
+// The flags carried in the first byte of the GTP-U header that signal the
+// presence of the optional fields, as per 3GPP TS 29.281 section 5.1.
+const (
+	GTPUFlagExtensionHeader uint8 = 1 << 2
+	GTPUFlagSequenceNumber  uint8 = 1 << 1
+	GTPUFlagNPDUNumber      uint8 = 1 << 0
+)
+
+// GTP-U message types, as per 3GPP TS 29.281 section 6.1.
+const (
+	GTPUMessageTypeEchoRequest     uint8 = 1
+	GTPUMessageTypeEchoResponse    uint8 = 2
+	GTPUMessageTypeErrorIndication uint8 = 26
+	GTPUMessageTypeEndMarker       uint8 = 254
+	GTPUMessageTypeGPDU            uint8 = 255
+)
+
+// GTPU represents a GTP-U header stored in a byte array.
+//
+// Most of the methods of GTPU access to the underlying slice without checking
+// the boundaries and could panic because of 'index out of range'. Always call
+// IsValid() to validate an instance of GTPU before using other methods.
+type GTPU []byte
+
+// IsValid performs basic validation on the GTP-U header: the version must be
+// GTPUVersion with the Protocol Type flag set, and the Length field must
+// cover the optional fields and extension headers and fit in b.
+func (b GTPU) IsValid() bool {
+	if len(b) < GTPUMinimumSize {
+		return false
+	}
+	if b.Version() != GTPUVersion || b[gtpuFlags]&gtpuFlagProtocolType == 0 {
+		return false
+	}
+	end := GTPUMinimumSize + int(b.Length())
+	if end > len(b) {
+		return false
+	}
+	hdrLen, ok := b[:end].headerLength()
+	return ok && hdrLen <= end
+}
+
+// Version returns the version of the GTP-U header.
+func (b GTPU) Version() uint8 {
+	return b[gtpuFlags] >> gtpuVersionShift
+}
+
+// Flags returns the E, S and N flags of the GTP-U header.
+func (b GTPU) Flags() uint8 {
+	return b[gtpuFlags] & gtpuOptionalFieldsFlags
+}
+
+// MessageType returns the message type of the GTP-U header.
+func (b GTPU) MessageType() uint8 {
+	return b[gtpuMessageType]
+}
+
+// Length returns the length, in bytes, of the message following the
+// mandatory part of the GTP-U header, including the optional fields.
+func (b GTPU) Length() uint16 {
+	return binary.BigEndian.Uint16(b[gtpuLength:])
+}
+
+// TEID returns the Tunnel Endpoint Identifier of the GTP-U header.
+func (b GTPU) TEID() uint32 {
+	return binary.BigEndian.Uint32(b[gtpuTEID:])
+}
+
+// SequenceNumber returns the sequence number held in the GTP-U header, if
+// present.
+func (b GTPU) SequenceNumber() (uint16, bool) {
+	if b.Flags()&GTPUFlagSequenceNumber == 0 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint16(b[gtpuSequenceNumber:]), true
+}
+
+// NPDUNumber returns the N-PDU number held in the GTP-U header, if present.
+func (b GTPU) NPDUNumber() (uint8, bool) {
+	if b.Flags()&GTPUFlagNPDUNumber == 0 {
+		return 0, false
+	}
+	return b[gtpuNPDUNumber], true
+}
+
+// NextExtensionHeaderType returns the type of the first extension header
+// following the GTP-U header, if present.
+func (b GTPU) NextExtensionHeaderType() (uint8, bool) {
+	if b.Flags()&GTPUFlagExtensionHeader == 0 {
+		return 0, false
+	}
+	return b[gtpuNextExtType], true
+}
+
+// HeaderLength returns the length of the GTP-U header, including the optional
+// fields and extension headers.
+func (b GTPU) HeaderLength() int {
+	l, _ := b.headerLength()
+	return l
+}
+
+// Payload returns the T-PDU carried by a G-PDU message, or the information
+// elements of other messages.
+func (b GTPU) Payload() []byte {
+	return b[b.HeaderLength() : GTPUMinimumSize+int(b.Length())]
+}
+
+// headerLength returns the length of the GTP-U header by walking the
+// extension headers, or false if they overrun b.
+func (b GTPU) headerLength() (int, bool) {
+	flags := b.Flags()
+	if flags == 0 {
+		return GTPUMinimumSize, true
+	}
+	l := GTPUMinimumSize + gtpuOptionalFieldsSize
+	if len(b) < l {
+		return 0, false
+	}
+	if flags&GTPUFlagExtensionHeader == 0 {
+		return l, true
+	}
+	for next := b[gtpuNextExtType]; next != gtpuNoMoreExtensionHeaders; {
+		if len(b) <= l {
+			return 0, false
+		}
+		extLen := int(b[l]) * gtpuExtHdrLenBytesPerUnit
+		if extLen == 0 || len(b) < l+extLen {
+			return 0, false
+		}
+		l += extLen
+		next = b[l-1]
+	}
+	return l, true
+}
@@ -0,0 +1,122 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestGTPU(t *testing.T) {
+	payload := []byte{0x45, 0, 0, 0}
+
+	tests := []struct {
+		name          string
+		buf           []byte
+		wantValid     bool
+		wantSeq       uint16
+		wantSeqOK     bool
+		wantNextExt   uint8
+		wantNextExtOK bool
+		wantHdrLen    int
+	}{
+		{
+			name:       "G-PDU",
+			buf:        append([]byte{0x30, 0xff, 0x00, 0x04, 0x12, 0x34, 0x56, 0x78}, payload...),
+			wantValid:  true,
+			wantHdrLen: 8,
+		},
+		{
+			name:       "G-PDU with sequence number",
+			buf:        append([]byte{0x32, 0xff, 0x00, 0x08, 0x12, 0x34, 0x56, 0x78, 0x00, 0x2a, 0x00, 0x00}, payload...),
+			wantValid:  true,
+			wantSeq:    42,
+			wantSeqOK:  true,
+			wantHdrLen: 12,
+		},
+		{
+			name: "G-PDU with extension header",
+			buf: append([]byte{
+				0x34, 0xff, 0x00, 0x0c, 0x12, 0x34, 0x56, 0x78,
+				0x00, 0x00, 0x00, 0x85,
+				0x01, 0x00, 0x09, 0x00,
+			}, payload...),
+			wantValid:     true,
+			wantNextExt:   0x85,
+			wantNextExtOK: true,
+			wantHdrLen:    16,
+		},
+		{
+			name: "GTP'",
+			buf:  append([]byte{0x20, 0xff, 0x00, 0x04, 0x12, 0x34, 0x56, 0x78}, payload...),
+		},
+		{
+			name: "version 0",
+			buf:  append([]byte{0x10, 0xff, 0x00, 0x04, 0x12, 0x34, 0x56, 0x78}, payload...),
+		},
+		{
+			name: "length overrun",
+			buf:  append([]byte{0x30, 0xff, 0x00, 0x05, 0x12, 0x34, 0x56, 0x78}, payload...),
+		},
+		{
+			name: "length too short for optional fields",
+			buf:  []byte{0x32, 0xff, 0x00, 0x00, 0x12, 0x34, 0x56, 0x78, 0x00, 0x2a, 0x00, 0x00},
+		},
+		{
+			name: "extension header overrun",
+			buf: []byte{
+				0x34, 0xff, 0x00, 0x08, 0x12, 0x34, 0x56, 0x78,
+				0x00, 0x00, 0x00, 0x85,
+				0x02, 0x00, 0x09, 0x00,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gtpu := header.GTPU(test.buf)
+			if got := gtpu.IsValid(); got != test.wantValid {
+				t.Fatalf("got IsValid() = %t, want = %t", got, test.wantValid)
+			}
+			if !test.wantValid {
+				return
+			}
+
+			if got := gtpu.MessageType(); got != header.GTPUMessageTypeGPDU {
+				t.Errorf("got MessageType() = %d, want = %d", got, header.GTPUMessageTypeGPDU)
+			}
+			if got, want := gtpu.TEID(), uint32(0x12345678); got != want {
+				t.Errorf("got TEID() = %#x, want = %#x", got, want)
+			}
+			if seq, ok := gtpu.SequenceNumber(); seq != test.wantSeq || ok != test.wantSeqOK {
+				t.Errorf("got SequenceNumber() = (%d, %t), want = (%d, %t)", seq, ok, test.wantSeq, test.wantSeqOK)
+			}
+			if _, ok := gtpu.NPDUNumber(); ok {
+				t.Error("got NPDUNumber() = (_, true), want = (_, false)")
+			}
+			if next, ok := gtpu.NextExtensionHeaderType(); next != test.wantNextExt || ok != test.wantNextExtOK {
+				t.Errorf("got NextExtensionHeaderType() = (%d, %t), want = (%d, %t)", next, ok, test.wantNextExt, test.wantNextExtOK)
+			}
+			if got := gtpu.HeaderLength(); got != test.wantHdrLen {
+				t.Errorf("got HeaderLength() = %d, want = %d", got, test.wantHdrLen)
+			}
+			if got := gtpu.Payload(); !bytes.Equal(got, payload) {
+				t.Errorf("got Payload() = %x, want = %x", got, payload)
+			}
+		})
+	}
+}
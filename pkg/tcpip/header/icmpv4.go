@@ -16,6 +16,7 @@ package header
 
 import (
 	"encoding/binary"
+	"fmt"
 
 	"gvisor.dev/gvisor/pkg/tcpip"
 )
@@ -73,6 +74,10 @@ const (
 	// icmpv4SequenceOffset is the offset of the sequence field
 	// in an ICMPv4EchoRequest/Reply message.
 	icmpv4SequenceOffset = 6
+
+	// icmpv4GatewayOffset is the offset of the gateway address field
+	// in an ICMPv4Redirect message.
+	icmpv4GatewayOffset = 4
 )
 
 // ICMPv4Type is the ICMP type field described in RFC 792.
@@ -102,13 +107,41 @@ const (
 	ICMPv4ReassemblyTimeout ICMPv4Code = 1
 )
 
-// ICMP codes for ICMPv4 Destination Unreachable messages as defined in RFC 792.
+// ICMP codes for ICMPv4 Destination Unreachable messages as defined in RFC 792
+// and RFC 1812 section 5.2.7.1.
 const (
-	ICMPv4NetUnreachable      ICMPv4Code = 0
-	ICMPv4HostUnreachable     ICMPv4Code = 1
-	ICMPv4ProtoUnreachable    ICMPv4Code = 2
-	ICMPv4PortUnreachable     ICMPv4Code = 3
-	ICMPv4FragmentationNeeded ICMPv4Code = 4
+	ICMPv4NetUnreachable            ICMPv4Code = 0
+	ICMPv4HostUnreachable           ICMPv4Code = 1
+	ICMPv4ProtoUnreachable          ICMPv4Code = 2
+	ICMPv4PortUnreachable           ICMPv4Code = 3
+	ICMPv4FragmentationNeeded       ICMPv4Code = 4
+	ICMPv4SourceRouteFailed         ICMPv4Code = 5
+	ICMPv4DestinationNetworkUnknown ICMPv4Code = 6
+	ICMPv4DestinationHostUnknown    ICMPv4Code = 7
+	ICMPv4SourceHostIsolated        ICMPv4Code = 8
+	ICMPv4NetProhibited             ICMPv4Code = 9
+	ICMPv4HostProhibited            ICMPv4Code = 10
+	ICMPv4NetUnreachableForTos      ICMPv4Code = 11
+	ICMPv4HostUnreachableForTos     ICMPv4Code = 12
+	ICMPv4AdminProhibited           ICMPv4Code = 13
+	ICMPv4HostPrecedenceViolation   ICMPv4Code = 14
+	ICMPv4PrecedenceCutInEffect     ICMPv4Code = 15
+)
+
+// ICMP codes for ICMPv4 Parameter Problem messages as defined in RFC 792 and
+// RFC 1108.
+const (
+	ICMPv4PointerIndicatesError ICMPv4Code = 0
+	ICMPv4MissingRequiredOption ICMPv4Code = 1
+	ICMPv4BadLength             ICMPv4Code = 2
+)
+
+// ICMP codes for ICMPv4 Redirect messages as defined in RFC 792.
+const (
+	ICMPv4RedirectNet        ICMPv4Code = 0
+	ICMPv4RedirectHost       ICMPv4Code = 1
+	ICMPv4RedirectTOSAndNet  ICMPv4Code = 2
+	ICMPv4RedirectTOSAndHost ICMPv4Code = 3
 )
 
 // ICMPv4UnusedCode is a code to use in ICMP messages where no code is needed.
@@ -195,6 +228,57 @@ func (b ICMPv4) SetSequence(sequence uint16) {
 	binary.BigEndian.PutUint16(b[icmpv4SequenceOffset:], sequence)
 }
 
+// Gateway retrieves the gateway address field from an ICMPv4 Redirect
+// message.
+func (b ICMPv4) Gateway() tcpip.Address {
+	return tcpip.Address(b[icmpv4GatewayOffset:][:IPv4AddressSize])
+}
+
+// SetGateway sets the gateway address field of an ICMPv4 Redirect message.
+func (b ICMPv4) SetGateway(gateway tcpip.Address) {
+	copy(b[icmpv4GatewayOffset:][:IPv4AddressSize], gateway)
+}
+
+// BuildICMPv4Redirect returns an ICMPv4 Redirect message with the given code,
+// redirecting the sender of the IPv4 datagram held in original to gateway, as
+// per RFC 792.
+//
+// original is truncated as per ICMPErrorIncludeLen.
+func BuildICMPv4Redirect(code ICMPv4Code, gateway tcpip.Address, original []byte) []byte {
+	if len(gateway) != IPv4AddressSize {
+		panic(fmt.Sprintf("got len(gateway) = %d, want = %d", len(gateway), IPv4AddressSize))
+	}
+	original = original[:ICMPErrorIncludeLen(original, IPv4ProtocolNumber)]
+
+	b := ICMPv4(make([]byte, ICMPv4MinimumSize+len(original)))
+	b.SetType(ICMPv4Redirect)
+	b.SetCode(code)
+	b.SetGateway(gateway)
+	copy(b.Payload(), original)
+	b.SetChecksum(ICMPv4Checksum(b[:ICMPv4MinimumSize], Checksum(original, 0)))
+	return b
+}
+
+// ICMPv4EmbeddedTransport returns the transport bytes and protocol of the
+// original datagram embedded in the payload of an ICMPv4 error message.
+//
+// The embedded IPv4 header's IHL field is honoured so that any options that
+// were present in the original datagram are skipped. The embedded datagram is
+// usually truncated so its total length field is not checked.
+//
+// ok is false if icmpPayload does not hold a complete IPv4 header.
+func ICMPv4EmbeddedTransport(icmpPayload []byte) ([]byte, uint8, bool) {
+	if len(icmpPayload) < IPv4MinimumSize || IPVersion(icmpPayload) != IPv4Version {
+		return nil, 0, false
+	}
+	ip := IPv4(icmpPayload)
+	hdrLen := int(ip.HeaderLength())
+	if hdrLen < IPv4MinimumSize || hdrLen > len(icmpPayload) {
+		return nil, 0, false
+	}
+	return icmpPayload[hdrLen:], ip.Protocol(), true
+}
+
 // ICMPv4Checksum calculates the ICMP checksum over the provided ICMP header,
 // and payload.
 func ICMPv4Checksum(h ICMPv4, payloadCsum uint16) uint16 {
@@ -204,5 +288,5 @@ func ICMPv4Checksum(h ICMPv4, payloadCsum uint16) uint16 {
 	xsum = Checksum(h[:2], xsum)
 	xsum = Checksum(h[4:], xsum)
 
-	return ^xsum
+	return ChecksumToField(xsum)
 }
@@ -0,0 +1,121 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestICMPv4EmbeddedTransport(t *testing.T) {
+	original := makeIPv4Packet(header.IPv4Fields{
+		Protocol: uint8(header.UDPProtocolNumber),
+		Options: header.IPv4OptionsSerializer{
+			&header.IPv4SerializableRouterAlertOption{},
+		},
+	}, testUDPHeader)
+
+	tests := []struct {
+		name          string
+		payload       []byte
+		wantTransport []byte
+		wantOK        bool
+	}{
+		{
+			name:          "WithOptions",
+			payload:       original,
+			wantTransport: testUDPHeader,
+			wantOK:        true,
+		},
+		{
+			name: "Truncated",
+			// Only the first 8 bytes of the original transport data are included.
+			payload:       original[:header.IPv4MinimumSize+header.IPv4OptionRouterAlertLength+8],
+			wantTransport: testUDPHeader[:8],
+			wantOK:        true,
+		},
+		{
+			name:    "TruncatedOptions",
+			payload: original[:header.IPv4MinimumSize+2],
+		},
+		{
+			name:    "ShortHeader",
+			payload: original[:header.IPv4MinimumSize-1],
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transport, proto, ok := header.ICMPv4EmbeddedTransport(test.payload)
+			if ok != test.wantOK {
+				t.Fatalf("got header.ICMPv4EmbeddedTransport(_) = (_, _, %t), want = (_, _, %t)", ok, test.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got, want := proto, uint8(header.UDPProtocolNumber); got != want {
+				t.Errorf("got proto = %d, want = %d", got, want)
+			}
+			if !bytes.Equal(transport, test.wantTransport) {
+				t.Errorf("got transport = %x, want = %x", transport, test.wantTransport)
+			}
+		})
+	}
+}
+
+func TestBuildICMPv4Redirect(t *testing.T) {
+	const gateway = tcpip.Address("\x0a\x00\x00\xfe")
+
+	tests := []struct {
+		name     string
+		original []byte
+		wantLen  int
+	}{
+		{
+			name:     "short",
+			original: makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber)}, testUDPHeader),
+			wantLen:  header.IPv4MinimumSize + len(testUDPHeader),
+		},
+		{
+			name:     "long",
+			original: makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber)}, make([]byte, 1000)),
+			wantLen:  header.IPv4MinimumProcessableDatagramSize - header.IPv4MinimumSize - header.ICMPv4MinimumSize,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := header.ICMPv4(header.BuildICMPv4Redirect(header.ICMPv4RedirectHost, gateway, test.original))
+			if got := b.Type(); got != header.ICMPv4Redirect {
+				t.Errorf("got b.Type() = %d, want = %d", got, header.ICMPv4Redirect)
+			}
+			if got := b.Code(); got != header.ICMPv4RedirectHost {
+				t.Errorf("got b.Code() = %d, want = %d", got, header.ICMPv4RedirectHost)
+			}
+			if got := b.Gateway(); got != gateway {
+				t.Errorf("got b.Gateway() = %s, want = %s", got, gateway)
+			}
+			if got, want := b.Payload(), test.original[:test.wantLen]; !bytes.Equal(got, want) {
+				t.Errorf("got b.Payload() = %x, want = %x", got, want)
+			}
+			if got := header.Checksum(b, 0); got != 0xffff {
+				t.Errorf("got header.Checksum(b, 0) = %#x, want = 0xffff", got)
+			}
+		})
+	}
+}
@@ -16,8 +16,10 @@ package header
 
 import (
 	"encoding/binary"
+	"fmt"
 
 	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/buffer"
 )
 
 // ICMPv6 represents an ICMPv6 header stored in a byte array.
@@ -48,6 +50,9 @@ const (
 	// neighbor advertisement packet.
 	ICMPv6NeighborAdvertMinimumSize = ICMPv6HeaderSize + NDPNAMinimumSize
 
+	// ICMPv6RedirectMinimumSize is the minimum size of a redirect packet.
+	ICMPv6RedirectMinimumSize = ICMPv6HeaderSize + NDPRedirectMinimumSize
+
 	// ICMPv6EchoMinimumSize is the minimum size of a valid echo packet.
 	ICMPv6EchoMinimumSize = 8
 
@@ -133,6 +138,20 @@ func (typ ICMPv6Type) IsErrorType() bool {
 	return typ&0x80 == 0
 }
 
+// RequiresHopLimit255 returns true if a received ICMPv6 message of type
+// icmpType must be dropped unless its IP hop limit is NDPHopLimit.
+//
+// As per RFC 4861 sections 6.1.1, 6.1.2, 7.1.1, 7.1.2 and 8.1, this applies
+// to all NDP messages so that they cannot be spoofed by off-link nodes.
+func RequiresHopLimit255(icmpType ICMPv6Type) bool {
+	switch icmpType {
+	case ICMPv6RouterSolicit, ICMPv6RouterAdvert, ICMPv6NeighborSolicit, ICMPv6NeighborAdvert, ICMPv6RedirectMsg:
+		return true
+	default:
+		return false
+	}
+}
+
 // ICMPv6Code is the ICMP Code field described in RFC 4443.
 type ICMPv6Code byte
 
@@ -282,5 +301,109 @@ func ICMPv6Checksum(params ICMPv6ChecksumParams) uint16 {
 	xsum = Checksum(h[:2], xsum)
 	xsum = Checksum(h[4:], xsum)
 
-	return ^xsum
+	return ChecksumToField(xsum)
+}
+
+// FillICMPv6Checksum calculates the checksum of the ICMPv6 message made of the
+// header b followed by payload and writes it into b's checksum field.
+func FillICMPv6Checksum(b ICMPv6, src, dst tcpip.Address, payload buffer.VectorisedView) {
+	checkPseudoHeaderAddresses(src, dst, IPv6ProtocolNumber)
+	b.SetChecksum(ICMPv6Checksum(ICMPv6ChecksumParams{
+		Header:      b,
+		Src:         src,
+		Dst:         dst,
+		PayloadCsum: ChecksumVV(payload, 0),
+		PayloadLen:  payload.Size(),
+	}))
+}
+
+// ICMPErrorIncludeLen returns the number of bytes of the datagram held in
+// original that an ICMP error message sent over netProto should embed.
+//
+// For IPv4, as per RFC 1812 section 4.3.2.3, as much of the original datagram
+// is included as possible without the ICMP datagram exceeding 576 bytes. This
+// always covers the IP header and the first 8 bytes of its payload required
+// by RFC 792.
+//
+// For IPv6, as per RFC 4443 section 2.4 (c), as much of the original packet is
+// included as possible without the ICMPv6 packet exceeding the minimum IPv6
+// MTU.
+func ICMPErrorIncludeLen(original []byte, netProto tcpip.NetworkProtocolNumber) int {
+	switch netProto {
+	case IPv4ProtocolNumber:
+		if max := IPv4MinimumProcessableDatagramSize - IPv4MinimumSize - ICMPv4MinimumSize; len(original) > max {
+			return max
+		}
+		return len(original)
+	case IPv6ProtocolNumber:
+		return ICMPv6ErrorPayloadLen(len(original))
+	default:
+		panic(fmt.Sprintf("unsupported network protocol number = %d", netProto))
+	}
+}
+
+// ICMPv6ErrorPayloadLen returns the number of bytes of an original packet of
+// originalLen bytes that an ICMPv6 error message should embed.
+//
+// As per RFC 4443 section 2.4 (c), the original packet is truncated so that
+// the IPv6 packet carrying the ICMPv6 error message, including the IPv6 and
+// ICMPv6 headers, does not exceed the minimum IPv6 MTU.
+func ICMPv6ErrorPayloadLen(originalLen int) int {
+	if max := IPv6MinimumMTU - IPv6MinimumSize - ICMPv6ErrorHeaderSize; originalLen > max {
+		return max
+	}
+	return originalLen
+}
+
+// BuildICMPv6TimeExceeded returns an ICMPv6 Time Exceeded message with the Hop
+// Limit Exceeded code sent from src to dst in response to the IPv6 packet held
+// in original, as per RFC 4443 section 3.3.
+//
+// As per RFC 4443 section 2.4 (c), original is truncated so that the IPv6
+// packet carrying the returned message does not exceed the minimum IPv6 MTU.
+func BuildICMPv6TimeExceeded(src, dst tcpip.Address, original []byte) []byte {
+	return buildICMPv6Error(src, dst, ICMPv6TimeExceeded, ICMPv6HopLimitExceeded, 0, original)
+}
+
+// BuildICMPv6NoRoute returns an ICMPv6 Destination Unreachable message with
+// the No Route to Destination code sent from src to dst in response to the
+// IPv6 packet held in original, as per RFC 4443 section 3.1.
+//
+// As per RFC 4443 section 2.4 (c), original is truncated so that the IPv6
+// packet carrying the returned message does not exceed the minimum IPv6 MTU.
+func BuildICMPv6NoRoute(src, dst tcpip.Address, original []byte) []byte {
+	return buildICMPv6Error(src, dst, ICMPv6DstUnreachable, ICMPv6NetworkUnreachable, 0, original)
+}
+
+// BuildICMPv6ParamProblem returns an ICMPv6 Parameter Problem message with the
+// given code sent from src to dst in response to the IPv6 packet held in
+// original, as per RFC 4443 section 3.4. pointer is the offset, in original,
+// of the byte where the error was detected; e.g. the type of an unrecognized
+// extension header option whose action bits request an error.
+//
+// As per RFC 4443 section 2.4 (c), original is truncated so that the IPv6
+// packet carrying the returned message does not exceed the minimum IPv6 MTU.
+// pointer is kept as is even if it points beyond the truncated original.
+func BuildICMPv6ParamProblem(code ICMPv6Code, pointer uint32, src, dst tcpip.Address, original []byte) []byte {
+	return buildICMPv6Error(src, dst, ICMPv6ParamProblem, code, pointer, original)
+}
+
+// buildICMPv6Error returns an ICMPv6 error message of the given type and code
+// sent from src to dst, with typeSpecific in the 4 bytes following the
+// checksum and carrying as much of original as fits in the minimum IPv6 MTU.
+func buildICMPv6Error(src, dst tcpip.Address, typ ICMPv6Type, code ICMPv6Code, typeSpecific uint32, original []byte) []byte {
+	original = original[:ICMPErrorIncludeLen(original, IPv6ProtocolNumber)]
+
+	b := ICMPv6(make([]byte, ICMPv6ErrorHeaderSize+len(original)))
+	b.SetType(typ)
+	b.SetCode(code)
+	b.SetTypeSpecific(typeSpecific)
+	copy(b.Payload(), original)
+	checkPseudoHeaderAddresses(src, dst, IPv6ProtocolNumber)
+	b.SetChecksum(ICMPv6Checksum(ICMPv6ChecksumParams{
+		Header: b,
+		Src:    src,
+		Dst:    dst,
+	}))
+	return b
 }
@@ -0,0 +1,233 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestBuildICMPv6TimeExceeded(t *testing.T) {
+	const (
+		src = tcpip.Address("\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+		dst = tcpip.Address("\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02")
+
+		maxOriginalSize = header.IPv6MinimumMTU - header.IPv6MinimumSize - header.ICMPv6ErrorHeaderSize
+	)
+
+	makeOriginal := func(size int) []byte {
+		b := make([]byte, size)
+		for i := range b {
+			b[i] = uint8(i)
+		}
+		return b
+	}
+
+	tests := []struct {
+		name         string
+		original     []byte
+		wantOriginal []byte
+	}{
+		{
+			name:         "small",
+			original:     makeOriginal(100),
+			wantOriginal: makeOriginal(100),
+		},
+		{
+			name:         "odd size",
+			original:     makeOriginal(101),
+			wantOriginal: makeOriginal(101),
+		},
+		{
+			name:         "exactly fits",
+			original:     makeOriginal(maxOriginalSize),
+			wantOriginal: makeOriginal(maxOriginalSize),
+		},
+		{
+			name:         "truncated",
+			original:     makeOriginal(2000),
+			wantOriginal: makeOriginal(maxOriginalSize),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			icmp := header.ICMPv6(header.BuildICMPv6TimeExceeded(src, dst, test.original))
+			if got, want := header.IPv6MinimumSize+len(icmp), header.IPv6MinimumMTU; got > want {
+				t.Errorf("got IPv6 packet size = %d, want <= %d", got, want)
+			}
+			if got, want := icmp.Type(), header.ICMPv6TimeExceeded; got != want {
+				t.Errorf("got Type() = %d, want = %d", got, want)
+			}
+			if got, want := icmp.Code(), header.ICMPv6HopLimitExceeded; got != want {
+				t.Errorf("got Code() = %d, want = %d", got, want)
+			}
+			if got := icmp.Payload(); !bytes.Equal(got, test.wantOriginal) {
+				t.Errorf("got Payload() of %d bytes, want %d bytes of the original packet", len(got), len(test.wantOriginal))
+			}
+
+			xsum := header.PseudoHeaderChecksum(header.ICMPv6ProtocolNumber, src, dst, uint16(len(icmp)))
+			if got := header.Checksum(icmp, xsum); got != 0xffff {
+				t.Errorf("got checksum over message with pseudo-header = 0x%04x, want = 0xffff", got)
+			}
+		})
+	}
+}
+
+func TestBuildICMPv6NoRoute(t *testing.T) {
+	const (
+		src = tcpip.Address("\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+		dst = tcpip.Address("\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02")
+	)
+	original := header.BuildUDPv6Packet(dst, uniqueLocalAddr1, 1234, 53, []byte{1, 2, 3, 4, 5}, 64)
+
+	icmp := header.ICMPv6(header.BuildICMPv6NoRoute(src, dst, original))
+	if got, want := icmp.Type(), header.ICMPv6DstUnreachable; got != want {
+		t.Errorf("got Type() = %d, want = %d", got, want)
+	}
+	if got, want := icmp.Code(), header.ICMPv6NetworkUnreachable; got != want {
+		t.Errorf("got Code() = %d, want = %d", got, want)
+	}
+	if got := icmp.Payload(); !bytes.Equal(got, original) {
+		t.Errorf("got Payload() = %x, want = %x", got, original)
+	}
+
+	xsum := header.PseudoHeaderChecksum(header.ICMPv6ProtocolNumber, src, dst, uint16(len(icmp)))
+	if got := header.Checksum(icmp, xsum); got != 0xffff {
+		t.Errorf("got checksum over message with pseudo-header = 0x%04x, want = 0xffff", got)
+	}
+}
+
+func TestBuildICMPv6ParamProblem(t *testing.T) {
+	const (
+		src = tcpip.Address("\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+		dst = tcpip.Address("\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02")
+	)
+	original := makeIPv6Packet(header.IPv6Fields{
+		TransportProtocol: header.UDPProtocolNumber,
+		SrcAddr:           dst,
+		DstAddr:           uniqueLocalAddr1,
+		ExtensionHeaders: header.IPv6ExtHdrSerializer{
+			header.IPv6SerializableHopByHopExtHdr{
+				&header.IPv6RouterAlertOption{Value: header.IPv6RouterAlertMLD},
+			},
+		},
+	}, testUDPHeader)
+	// Point at the type of the Router Alert option, which follows the IPv6
+	// header and the Next Header and Hdr Ext Len fields of the Hop-by-Hop
+	// Options header.
+	const pointer = header.IPv6MinimumSize + 2
+
+	icmp := header.ICMPv6(header.BuildICMPv6ParamProblem(header.ICMPv6UnknownOption, pointer, src, dst, original))
+	if got, want := icmp.Type(), header.ICMPv6ParamProblem; got != want {
+		t.Errorf("got Type() = %d, want = %d", got, want)
+	}
+	if got, want := icmp.Code(), header.ICMPv6UnknownOption; got != want {
+		t.Errorf("got Code() = %d, want = %d", got, want)
+	}
+	if got := icmp.TypeSpecific(); got != pointer {
+		t.Errorf("got TypeSpecific() = %d, want = %d", got, pointer)
+	}
+	if got := icmp.Payload(); !bytes.Equal(got, original) {
+		t.Errorf("got Payload() = %x, want = %x", got, original)
+	}
+
+	xsum := header.PseudoHeaderChecksum(header.ICMPv6ProtocolNumber, src, dst, uint16(len(icmp)))
+	if got := header.Checksum(icmp, xsum); got != 0xffff {
+		t.Errorf("got checksum over message with pseudo-header = 0x%04x, want = 0xffff", got)
+	}
+}
+
+func TestICMPErrorIncludeLen(t *testing.T) {
+	const (
+		maxIPv4 = header.IPv4MinimumProcessableDatagramSize - header.IPv4MinimumSize - header.ICMPv4MinimumSize
+		maxIPv6 = header.IPv6MinimumMTU - header.IPv6MinimumSize - header.ICMPv6ErrorHeaderSize
+	)
+
+	tests := []struct {
+		name        string
+		netProto    tcpip.NetworkProtocolNumber
+		originalLen int
+		want        int
+	}{
+		{name: "IPv4 short", netProto: header.IPv4ProtocolNumber, originalLen: header.IPv4MinimumSize + 8, want: header.IPv4MinimumSize + 8},
+		{name: "IPv4 at limit", netProto: header.IPv4ProtocolNumber, originalLen: maxIPv4, want: maxIPv4},
+		{name: "IPv4 long", netProto: header.IPv4ProtocolNumber, originalLen: 1500, want: maxIPv4},
+		{name: "IPv6 short", netProto: header.IPv6ProtocolNumber, originalLen: header.IPv6MinimumSize + 8, want: header.IPv6MinimumSize + 8},
+		{name: "IPv6 at limit", netProto: header.IPv6ProtocolNumber, originalLen: maxIPv6, want: maxIPv6},
+		{name: "IPv6 long", netProto: header.IPv6ProtocolNumber, originalLen: 1500, want: maxIPv6},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.ICMPErrorIncludeLen(make([]byte, test.originalLen), test.netProto); got != test.want {
+				t.Errorf("got header.ICMPErrorIncludeLen(_, %d) = %d, want = %d", test.netProto, got, test.want)
+			}
+		})
+	}
+}
+
+func TestICMPv6ErrorPayloadLen(t *testing.T) {
+	const max = header.IPv6MinimumMTU - header.IPv6MinimumSize - header.ICMPv6ErrorHeaderSize
+
+	tests := []struct {
+		name        string
+		originalLen int
+		want        int
+	}{
+		{name: "under limit", originalLen: header.IPv6MinimumSize + header.UDPMinimumSize, want: header.IPv6MinimumSize + header.UDPMinimumSize},
+		{name: "at limit", originalLen: max, want: max},
+		{name: "large", originalLen: 9000, want: max},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := header.ICMPv6ErrorPayloadLen(test.originalLen)
+			if got != test.want {
+				t.Errorf("got header.ICMPv6ErrorPayloadLen(%d) = %d, want = %d", test.originalLen, got, test.want)
+			}
+			if msgLen := header.IPv6MinimumSize + header.ICMPv6ErrorHeaderSize + got; msgLen > header.IPv6MinimumMTU {
+				t.Errorf("got ICMPv6 error packet of %d bytes, want at most %d bytes", msgLen, header.IPv6MinimumMTU)
+			}
+		})
+	}
+}
+
+func TestRequiresHopLimit255(t *testing.T) {
+	tests := []struct {
+		name     string
+		icmpType header.ICMPv6Type
+		want     bool
+	}{
+		{name: "Router Solicitation", icmpType: header.ICMPv6RouterSolicit, want: true},
+		{name: "Router Advertisement", icmpType: header.ICMPv6RouterAdvert, want: true},
+		{name: "Neighbor Solicitation", icmpType: header.ICMPv6NeighborSolicit, want: true},
+		{name: "Neighbor Advertisement", icmpType: header.ICMPv6NeighborAdvert, want: true},
+		{name: "Redirect", icmpType: header.ICMPv6RedirectMsg, want: true},
+		{name: "Echo Request", icmpType: header.ICMPv6EchoRequest, want: false},
+		{name: "MLD Query", icmpType: header.ICMPv6MulticastListenerQuery, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.RequiresHopLimit255(test.icmpType); got != test.want {
+				t.Errorf("got header.RequiresHopLimit255(%d) = %t, want = %t", test.icmpType, got, test.want)
+			}
+		})
+	}
+}
@@ -169,7 +169,7 @@ func IGMPCalculateChecksum(h IGMP) uint16 {
 	// the checksum and replace it afterwards.
 	existingXsum := h.Checksum()
 	h.SetChecksum(0)
-	xsum := ^Checksum(h, 0)
+	xsum := ChecksumToField(Checksum(h, 0))
 	h.SetChecksum(existingXsum)
 	return xsum
 }
@@ -0,0 +1,364 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"io"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// TransportHeader returns the transport protocol number and a sub-slice of
+// ipPacket starting at the transport header, skipping IPv4 options and IPv6
+// extension headers. The returned slice aliases ipPacket; no data is copied.
+//
+// ok is false if ipPacket is not a valid packet of the network protocol
+// netProto, if its extension headers are malformed or if it does not hold a
+// transport header. Non-first fragments never hold a transport header.
+func TransportHeader(ipPacket []byte, netProto tcpip.NetworkProtocolNumber) (proto uint8, transportBytes []byte, ok bool) {
+	switch netProto {
+	case IPv4ProtocolNumber:
+		ipv4 := IPv4(ipPacket)
+		if !ipv4.IsValid(len(ipPacket)) || ipv4.FragmentOffset() != 0 {
+			return 0, nil, false
+		}
+		return ipv4.Protocol(), ipv4.Payload(), true
+
+	case IPv6ProtocolNumber:
+		ipv6 := IPv6(ipPacket)
+		if !ipv6.IsValid(len(ipPacket)) {
+			return 0, nil, false
+		}
+		payload := ipv6.Payload()
+		id := IPv6ExtensionHeaderIdentifier(ipv6.NextHeader())
+		for isIPv6ExtHdrIdentifier(id) {
+			nextID, length, err := ipv6ExtHdrLength(id, payload)
+			if err != nil {
+				return 0, nil, false
+			}
+			if id == IPv6FragmentExtHdrIdentifier && IPv6Fragment(payload).FragmentOffset() != 0 {
+				return 0, nil, false
+			}
+			payload = payload[length:]
+			id = nextID
+		}
+		if id == IPv6NoNextHeaderIdentifier {
+			return 0, nil, false
+		}
+		return uint8(id), payload, true
+
+	default:
+		return 0, nil, false
+	}
+}
+
+// PacketFingerprint returns a hash of the fields of the netProto packet held
+// in data that are not modified in transit, so that copies of the same packet
+// seen at different hops produce the same fingerprint.
+//
+// The fingerprint covers the network addresses, the transport protocol, the
+// transport header (including ports) and the payload. The TTL or hop limit
+// and all checksums are ignored. If netProto is neither IPv4 nor IPv6 or data
+// is not a valid netProto packet, the fingerprint covers all of data.
+func PacketFingerprint(data []byte, netProto tcpip.NetworkProtocolNumber) uint64 {
+	// The FNV-1a was chosen because it is a fast hashing algorithm, and
+	// cryptographic properties are not needed here.
+	h := fnv.New64a()
+	write := func(b []byte) {
+		if _, err := h.Write(b); err != nil {
+			panic(fmt.Sprintf("Hash.Write: %s, but Hash' implementation of Write is not expected to ever return an error", err))
+		}
+	}
+
+	var ip Network
+	switch netProto {
+	case IPv4ProtocolNumber:
+		if ipv4 := IPv4(data); ipv4.IsValid(len(data)) {
+			ip = ipv4
+		}
+	case IPv6ProtocolNumber:
+		if ipv6 := IPv6(data); ipv6.IsValid(len(data)) {
+			ip = ipv6
+		}
+	}
+	if ip == nil {
+		write(data)
+		return h.Sum64()
+	}
+
+	write([]byte(ip.SourceAddress()))
+	write([]byte(ip.DestinationAddress()))
+	proto, transport, ok := TransportHeader(data, netProto)
+	if !ok {
+		// Hash fragments without a transport header as opaque data.
+		write(ip.Payload())
+		return h.Sum64()
+	}
+	write([]byte{proto})
+
+	xsumOffset := -1
+	switch tcpip.TransportProtocolNumber(proto) {
+	case TCPProtocolNumber:
+		xsumOffset = TCPChecksumOffset
+	case UDPProtocolNumber, UDPLiteProtocolNumber:
+		xsumOffset = udpChecksum
+	case ICMPv4ProtocolNumber:
+		xsumOffset = icmpv4ChecksumOffset
+	case ICMPv6ProtocolNumber:
+		xsumOffset = icmpv6ChecksumOffset
+	}
+	if xsumOffset < 0 || len(transport) < xsumOffset+2 {
+		write(transport)
+		return h.Sum64()
+	}
+	write(transport[:xsumOffset])
+	write(transport[xsumOffset+2:])
+	return h.Sum64()
+}
+
+// RecomputeChecksums recomputes, in place, the IPv4 header checksum and the
+// TCP, UDP, ICMPv4 or ICMPv6 checksum of the netProto packet held in data. It
+// is meant to be used after arbitrary fields of the packet were modified, when
+// updating the checksums incrementally is impractical.
+//
+// The transport checksum of a fragment covers the reassembled datagram so it
+// is left untouched, as is a disabled (zero) UDP checksum over IPv4. Packets
+// carrying other transport protocols only have their IPv4 header checksum
+// recomputed. An error is returned if netProto is neither IPv4 nor IPv6 or if
+// data is not a valid netProto packet.
+func RecomputeChecksums(data []byte, netProto tcpip.NetworkProtocolNumber) error {
+	var ip Network
+	var fragment bool
+	switch netProto {
+	case IPv4ProtocolNumber:
+		ipv4 := IPv4(data)
+		if !ipv4.IsValid(len(data)) {
+			return fmt.Errorf("got invalid IPv4 packet of %d bytes", len(data))
+		}
+		ipv4.SetChecksum(0)
+		ipv4.SetChecksum(ChecksumToField(ipv4.CalculateChecksum()))
+		ip = ipv4
+		fragment = ipv4.More() || ipv4.FragmentOffset() != 0
+	case IPv6ProtocolNumber:
+		ipv6 := IPv6(data)
+		if !ipv6.IsValid(len(data)) {
+			return fmt.Errorf("got invalid IPv6 packet of %d bytes", len(data))
+		}
+		ip = ipv6
+		fragment = ipv6HasFragmentExtHdr(ipv6)
+	default:
+		return fmt.Errorf("unsupported network protocol number = %d", netProto)
+	}
+	if fragment {
+		return nil
+	}
+
+	proto, transport, ok := TransportHeader(data, netProto)
+	if !ok {
+		return nil
+	}
+	src, dst := ip.SourceAddress(), ip.DestinationAddress()
+	if netProto == IPv6ProtocolNumber {
+		dst = ChecksumDestV6(IPv6(data))
+	}
+
+	transProto := tcpip.TransportProtocolNumber(proto)
+	var xsumOffset, minSize int
+	pseudoHeader := true
+	switch transProto {
+	case TCPProtocolNumber:
+		xsumOffset, minSize = TCPChecksumOffset, TCPMinimumSize
+	case UDPProtocolNumber:
+		xsumOffset, minSize = udpChecksum, UDPMinimumSize
+		if netProto == IPv4ProtocolNumber && len(transport) >= minSize && UDP(transport).Checksum() == 0 {
+			return nil
+		}
+	case ICMPv4ProtocolNumber:
+		xsumOffset, minSize = icmpv4ChecksumOffset, ICMPv4MinimumSize
+		pseudoHeader = false
+	case ICMPv6ProtocolNumber:
+		xsumOffset, minSize = icmpv6ChecksumOffset, ICMPv6MinimumSize
+	default:
+		return nil
+	}
+	if len(transport) < minSize {
+		return fmt.Errorf("got %d bytes for transport protocol %d header, want at least %d: %w", len(transport), proto, minSize, io.ErrUnexpectedEOF)
+	}
+
+	binary.BigEndian.PutUint16(transport[xsumOffset:], 0)
+	var xsum uint16
+	if pseudoHeader {
+		xsum = PseudoHeaderChecksum(transProto, src, dst, uint16(len(transport)))
+	}
+	xsum = ChecksumToField(Checksum(transport, xsum))
+	if transProto == UDPProtocolNumber && xsum == 0 {
+		// As per RFC 768, a computed checksum of zero is transmitted as all
+		// ones since zero means no checksum was computed.
+		xsum = 0xffff
+	}
+	binary.BigEndian.PutUint16(transport[xsumOffset:], xsum)
+	return nil
+}
+
+// FragmentPayload returns the payload of the netProto fragment held in
+// ipPacket, bounded by the length fields of the IP header so that any link
+// layer padding following the packet is excluded from reassembly. The
+// returned slice aliases ipPacket; no data is copied.
+//
+// For IPv4, the payload follows the header and options. For IPv6, it follows
+// the Fragment extension header; the extension headers preceding it are part
+// of the unfragmentable part. An error is returned if netProto is neither IPv4
+// nor IPv6, if ipPacket is not a valid netProto packet, if it is not a
+// fragment or if its extension headers are malformed.
+func FragmentPayload(ipPacket []byte, netProto tcpip.NetworkProtocolNumber) ([]byte, error) {
+	switch netProto {
+	case IPv4ProtocolNumber:
+		ipv4 := IPv4(ipPacket)
+		if !ipv4.IsValid(len(ipPacket)) {
+			return nil, fmt.Errorf("got invalid IPv4 packet of %d bytes", len(ipPacket))
+		}
+		if !ipv4.More() && ipv4.FragmentOffset() == 0 {
+			return nil, fmt.Errorf("got IPv4 packet with ID = %d that is not a fragment", ipv4.ID())
+		}
+		return ipv4.Payload(), nil
+
+	case IPv6ProtocolNumber:
+		ipv6 := IPv6(ipPacket)
+		if !ipv6.IsValid(len(ipPacket)) {
+			return nil, fmt.Errorf("got invalid IPv6 packet of %d bytes", len(ipPacket))
+		}
+		payload := ipv6.Payload()
+		id := IPv6ExtensionHeaderIdentifier(ipv6.NextHeader())
+		for isIPv6ExtHdrIdentifier(id) {
+			nextID, length, err := ipv6ExtHdrLength(id, payload)
+			if err != nil {
+				return nil, err
+			}
+			if id == IPv6FragmentExtHdrIdentifier {
+				return payload[length:], nil
+			}
+			payload = payload[length:]
+			id = nextID
+		}
+		return nil, fmt.Errorf("got IPv6 packet without Fragment extension header")
+
+	default:
+		return nil, fmt.Errorf("unsupported network protocol number = %d", netProto)
+	}
+}
+
+// SameSubnet returns true iff a and b are IPv4 or IPv6 addresses of the same
+// family whose first prefixLen bits are equal.
+//
+// As with tcpip.AddressWithPrefix.Subnet, a prefixLen below zero is treated as
+// zero and a prefixLen above the address length covers the whole address.
+func SameSubnet(a, b tcpip.Address, prefixLen int) bool {
+	if len(a) != len(b) || (len(a) != IPv4AddressSize && len(a) != IPv6AddressSize) {
+		return false
+	}
+	if prefixLen <= 0 {
+		return true
+	}
+	if prefixLen >= len(a)*8 {
+		return a == b
+	}
+
+	n := prefixLen / 8
+	if a[:n] != b[:n] {
+		return false
+	}
+	mask := ^byte(0xff >> (prefixLen % 8))
+	return a[n]&mask == b[n]&mask
+}
+
+// IsAllNodesMulticast returns true iff addr is the IPv4 all systems multicast
+// address (224.0.0.1) or the IPv6 link-local all nodes multicast address
+// (ff02::1).
+func IsAllNodesMulticast(addr tcpip.Address) bool {
+	return addr == IPv4AllSystems || addr == IPv6AllNodesMulticastAddress
+}
+
+// IsAllRoutersMulticast returns true iff addr is the IPv4 all routers
+// multicast address (224.0.0.2) or the IPv6 link-local all routers multicast
+// address (ff02::2).
+func IsAllRoutersMulticast(addr tcpip.Address) bool {
+	return addr == IPv4AllRoutersGroup || addr == IPv6AllRoutersLinkLocalMulticastAddress
+}
+
+// IsForUs returns true iff a packet destined to dst should be delivered
+// locally by a host that owns the addresses in localAddrs, rather than
+// forwarded.
+//
+// Besides the local addresses, packets destined to the all nodes multicast
+// address are always for us, and packets destined to the IPv4 limited
+// broadcast address are for us iff acceptBroadcast is true.
+func IsForUs(dst tcpip.Address, localAddrs []tcpip.Address, acceptBroadcast bool) bool {
+	if IsAllNodesMulticast(dst) {
+		return true
+	}
+	if dst == IPv4Broadcast {
+		return acceptBroadcast
+	}
+	for _, addr := range localAddrs {
+		if dst == addr {
+			return true
+		}
+	}
+	return false
+}
+
+// ShouldGenerateICMPError returns true iff an ICMP error message may be sent in
+// response to the netProto packet held in original.
+//
+// For IPv4, as per RFC 1122 section 3.2.2, an error must not be sent in
+// response to a datagram destined to a broadcast or multicast address, to a
+// datagram whose source address does not define a single host (the zero, a
+// broadcast, a multicast or a Class E address) or to a non-first fragment.
+// Loopback sources are not suppressed since they are only seen on the
+// loopback interface, where they identify this host.
+//
+// For IPv6, as per RFC 4443 section 2.4 (e), an error must not be sent in
+// response to a packet destined to a multicast address or whose source address
+// does not uniquely identify a single node.
+//
+// Packets that are not valid netProto packets never generate errors.
+func ShouldGenerateICMPError(original []byte, netProto tcpip.NetworkProtocolNumber) bool {
+	switch netProto {
+	case IPv4ProtocolNumber:
+		ipv4 := IPv4(original)
+		if !ipv4.IsValid(len(original)) || ipv4.FragmentOffset() != 0 {
+			return false
+		}
+		if dst := ipv4.DestinationAddress(); dst == IPv4Broadcast || IsV4MulticastAddress(dst) {
+			return false
+		}
+		// Class E addresses (240.0.0.0/4) include the limited broadcast
+		// address.
+		src := ipv4.SourceAddress()
+		return src != IPv4Any && !IsV4MulticastAddress(src) && src[0]&0xf0 != 0xf0
+	case IPv6ProtocolNumber:
+		ipv6 := IPv6(original)
+		if !ipv6.IsValid(len(original)) || IsV6MulticastAddress(ipv6.DestinationAddress()) {
+			return false
+		}
+		src := ipv6.SourceAddress()
+		return src != IPv6Any && !IsV6MulticastAddress(src)
+	default:
+		panic(fmt.Sprintf("unsupported network protocol number = %d", netProto))
+	}
+}
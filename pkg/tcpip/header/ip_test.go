@@ -0,0 +1,744 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+const (
+	testIPv4SrcAddr = tcpip.Address("\x0a\x00\x00\x01")
+	testIPv4DstAddr = tcpip.Address("\x0a\x00\x00\x02")
+)
+
+var testUDPHeader = []byte{0x04, 0xd2, 0x16, 0x2e, 0, 12, 0, 0, 1, 2, 3, 4}
+
+func makeIPv4Packet(fields header.IPv4Fields, payload []byte) []byte {
+	hdrLen := header.IPv4MinimumSize + int(fields.Options.Length())
+	b := make([]byte, hdrLen+len(payload))
+	fields.TotalLength = uint16(len(b))
+	if fields.TTL == 0 {
+		fields.TTL = 64
+	}
+	if fields.SrcAddr == "" {
+		fields.SrcAddr = testIPv4SrcAddr
+	}
+	if fields.DstAddr == "" {
+		fields.DstAddr = testIPv4DstAddr
+	}
+	ip := header.IPv4(b)
+	ip.Encode(&fields)
+	ip.SetChecksum(^ip.CalculateChecksum())
+	copy(b[hdrLen:], payload)
+	return b
+}
+
+func makeIPv6Packet(fields header.IPv6Fields, payload []byte) []byte {
+	extHdrsLen := fields.ExtensionHeaders.Length()
+	b := make([]byte, header.IPv6MinimumSize+extHdrsLen+len(payload))
+	fields.PayloadLength = uint16(extHdrsLen + len(payload))
+	if fields.HopLimit == 0 {
+		fields.HopLimit = 64
+	}
+	if fields.SrcAddr == "" {
+		fields.SrcAddr = header.IPv6Loopback
+	}
+	if fields.DstAddr == "" {
+		fields.DstAddr = header.IPv6Loopback
+	}
+	header.IPv6(b).Encode(&fields)
+	copy(b[header.IPv6MinimumSize+extHdrsLen:], payload)
+	return b
+}
+
+func TestTransportHeader(t *testing.T) {
+	tests := []struct {
+		name      string
+		netProto  tcpip.NetworkProtocolNumber
+		pkt       []byte
+		wantProto uint8
+		wantOK    bool
+	}{
+		{
+			name:      "IPv4",
+			netProto:  header.IPv4ProtocolNumber,
+			pkt:       makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber)}, testUDPHeader),
+			wantProto: uint8(header.UDPProtocolNumber),
+			wantOK:    true,
+		},
+		{
+			name:     "IPv4WithOptions",
+			netProto: header.IPv4ProtocolNumber,
+			pkt: makeIPv4Packet(header.IPv4Fields{
+				Protocol: uint8(header.UDPProtocolNumber),
+				Options: header.IPv4OptionsSerializer{
+					&header.IPv4SerializableNOPOption{},
+					&header.IPv4SerializableRouterAlertOption{},
+				},
+			}, testUDPHeader),
+			wantProto: uint8(header.UDPProtocolNumber),
+			wantOK:    true,
+		},
+		{
+			name:     "IPv4FirstFragment",
+			netProto: header.IPv4ProtocolNumber,
+			pkt: makeIPv4Packet(header.IPv4Fields{
+				Protocol: uint8(header.UDPProtocolNumber),
+				Flags:    header.IPv4FlagMoreFragments,
+			}, testUDPHeader),
+			wantProto: uint8(header.UDPProtocolNumber),
+			wantOK:    true,
+		},
+		{
+			name:     "IPv4MidFragment",
+			netProto: header.IPv4ProtocolNumber,
+			pkt: makeIPv4Packet(header.IPv4Fields{
+				Protocol:       uint8(header.UDPProtocolNumber),
+				Flags:          header.IPv4FlagMoreFragments,
+				FragmentOffset: 8,
+			}, testUDPHeader),
+		},
+		{
+			name:      "IPv6",
+			netProto:  header.IPv6ProtocolNumber,
+			pkt:       makeIPv6Packet(header.IPv6Fields{TransportProtocol: header.UDPProtocolNumber}, testUDPHeader),
+			wantProto: uint8(header.UDPProtocolNumber),
+			wantOK:    true,
+		},
+		{
+			name:     "IPv6WithExtHdrs",
+			netProto: header.IPv6ProtocolNumber,
+			pkt: makeIPv6Packet(header.IPv6Fields{
+				TransportProtocol: header.UDPProtocolNumber,
+				ExtensionHeaders: header.IPv6ExtHdrSerializer{
+					header.IPv6SerializableHopByHopExtHdr{
+						&header.IPv6RouterAlertOption{Value: header.IPv6RouterAlertMLD},
+					},
+					&header.IPv6SerializableFragmentExtHdr{
+						M:              true,
+						Identification: 1,
+					},
+				},
+			}, testUDPHeader),
+			wantProto: uint8(header.UDPProtocolNumber),
+			wantOK:    true,
+		},
+		{
+			name:     "IPv6MidFragment",
+			netProto: header.IPv6ProtocolNumber,
+			pkt: makeIPv6Packet(header.IPv6Fields{
+				TransportProtocol: header.UDPProtocolNumber,
+				ExtensionHeaders: header.IPv6ExtHdrSerializer{
+					&header.IPv6SerializableFragmentExtHdr{
+						FragmentOffset: 1,
+						M:              true,
+						Identification: 1,
+					},
+				},
+			}, testUDPHeader),
+		},
+		{
+			name:     "IPv6NoNextHeader",
+			netProto: header.IPv6ProtocolNumber,
+			pkt:      makeIPv6Packet(header.IPv6Fields{TransportProtocol: tcpip.TransportProtocolNumber(header.IPv6NoNextHeaderIdentifier)}, nil),
+		},
+		{
+			name:     "TruncatedIPv4",
+			netProto: header.IPv4ProtocolNumber,
+			pkt:      make([]byte, header.IPv4MinimumSize-1),
+		},
+		{
+			name:     "WrongNetworkProtocol",
+			netProto: header.IPv6ProtocolNumber,
+			pkt:      makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber)}, testUDPHeader),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			proto, transport, ok := header.TransportHeader(test.pkt, test.netProto)
+			if ok != test.wantOK {
+				t.Fatalf("got header.TransportHeader(_, %d) = (_, _, %t), want = (_, _, %t)", test.netProto, ok, test.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if proto != test.wantProto {
+				t.Errorf("got proto = %d, want = %d", proto, test.wantProto)
+			}
+			if got, want := len(transport), len(testUDPHeader); got != want {
+				t.Fatalf("got len(transportBytes) = %d, want = %d", got, want)
+			}
+			// The returned slice must alias the packet.
+			if &transport[0] != &test.pkt[len(test.pkt)-len(testUDPHeader)] {
+				t.Error("transportBytes does not alias the packet")
+			}
+		})
+	}
+}
+
+func TestSameSubnet(t *testing.T) {
+	tests := []struct {
+		name      string
+		a, b      tcpip.Address
+		prefixLen int
+		want      bool
+	}{
+		{
+			name:      "IPv4 /24 match",
+			a:         "\xc0\xa8\x01\x0a",
+			b:         "\xc0\xa8\x01\xfe",
+			prefixLen: 24,
+			want:      true,
+		},
+		{
+			name:      "IPv4 /24 differ",
+			a:         "\xc0\xa8\x01\x0a",
+			b:         "\xc0\xa8\x02\x0a",
+			prefixLen: 24,
+			want:      false,
+		},
+		{
+			name:      "IPv4 /23 match",
+			a:         "\xc0\xa8\x02\x0a",
+			b:         "\xc0\xa8\x03\x0a",
+			prefixLen: 23,
+			want:      true,
+		},
+		{
+			name:      "IPv4 /23 differ",
+			a:         "\xc0\xa8\x01\x0a",
+			b:         "\xc0\xa8\x02\x0a",
+			prefixLen: 23,
+			want:      false,
+		},
+		{
+			name:      "IPv4 /32 differ",
+			a:         "\xc0\xa8\x01\x0a",
+			b:         "\xc0\xa8\x01\x0b",
+			prefixLen: 32,
+			want:      false,
+		},
+		{
+			name:      "IPv4 /0",
+			a:         "\xc0\xa8\x01\x0a",
+			b:         "\x0a\x00\x00\x01",
+			prefixLen: 0,
+			want:      true,
+		},
+		{
+			name:      "IPv6 /64 match",
+			a:         "\x20\x01\x0d\xb8\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x01",
+			b:         "\x20\x01\x0d\xb8\x00\x00\x00\x01\xff\xff\xff\xff\xff\xff\xff\xff",
+			prefixLen: 64,
+			want:      true,
+		},
+		{
+			name:      "IPv6 /64 differ",
+			a:         "\x20\x01\x0d\xb8\x00\x00\x00\x01\x00\x00\x00\x00\x00\x00\x00\x01",
+			b:         "\x20\x01\x0d\xb8\x00\x00\x00\x02\x00\x00\x00\x00\x00\x00\x00\x01",
+			prefixLen: 64,
+			want:      false,
+		},
+		{
+			name:      "mixed families",
+			a:         "\x00\x00\x00\x00",
+			b:         header.IPv6Any,
+			prefixLen: 0,
+			want:      false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.SameSubnet(test.a, test.b, test.prefixLen); got != test.want {
+				t.Errorf("got header.SameSubnet(%s, %s, %d) = %t, want = %t", test.a, test.b, test.prefixLen, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsAllNodesAndRoutersMulticast(t *testing.T) {
+	tests := []struct {
+		name           string
+		addr           tcpip.Address
+		wantAllNodes   bool
+		wantAllRouters bool
+	}{
+		{name: "IPv4 all systems", addr: "\xe0\x00\x00\x01", wantAllNodes: true},
+		{name: "IPv4 all routers", addr: "\xe0\x00\x00\x02", wantAllRouters: true},
+		{name: "IPv4 other multicast", addr: "\xe0\x00\x00\x16"},
+		{name: "IPv4 unicast", addr: testIPv4SrcAddr},
+		{name: "IPv6 all nodes", addr: "\xff\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01", wantAllNodes: true},
+		{name: "IPv6 all routers", addr: "\xff\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02", wantAllRouters: true},
+		{name: "IPv6 interface-local all nodes", addr: "\xff\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01"},
+		{name: "IPv6 site-local all routers", addr: header.IPv6AllRoutersSiteLocalMulticastAddress},
+		{name: "IPv6 loopback", addr: header.IPv6Loopback},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.IsAllNodesMulticast(test.addr); got != test.wantAllNodes {
+				t.Errorf("got header.IsAllNodesMulticast(%s) = %t, want = %t", test.addr, got, test.wantAllNodes)
+			}
+			if got := header.IsAllRoutersMulticast(test.addr); got != test.wantAllRouters {
+				t.Errorf("got header.IsAllRoutersMulticast(%s) = %t, want = %t", test.addr, got, test.wantAllRouters)
+			}
+		})
+	}
+}
+
+func TestIsForUs(t *testing.T) {
+	localAddrs := []tcpip.Address{
+		testIPv4SrcAddr,
+		"\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01",
+	}
+
+	tests := []struct {
+		name            string
+		dst             tcpip.Address
+		acceptBroadcast bool
+		want            bool
+	}{
+		{name: "IPv4 local unicast", dst: testIPv4SrcAddr, want: true},
+		{name: "IPv6 local unicast", dst: "\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01", want: true},
+		{name: "broadcast accepted", dst: header.IPv4Broadcast, acceptBroadcast: true, want: true},
+		{name: "broadcast not accepted", dst: header.IPv4Broadcast, acceptBroadcast: false, want: false},
+		{name: "IPv4 all systems", dst: header.IPv4AllSystems, want: true},
+		{name: "IPv6 all nodes", dst: header.IPv6AllNodesMulticastAddress, want: true},
+		{name: "IPv4 foreign", dst: testIPv4DstAddr, acceptBroadcast: true, want: false},
+		{name: "IPv6 foreign", dst: "\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.IsForUs(test.dst, localAddrs, test.acceptBroadcast); got != test.want {
+				t.Errorf("got header.IsForUs(%s, %s, %t) = %t, want = %t", test.dst, localAddrs, test.acceptBroadcast, got, test.want)
+			}
+		})
+	}
+}
+
+func TestPacketFingerprint(t *testing.T) {
+	payload := []byte{1, 2, 3, 4}
+	v4 := func(ttl uint8, payload []byte) []byte {
+		return header.BuildUDPv4Packet(testIPv4SrcAddr, testIPv4DstAddr, 1234, 53, payload, ttl)
+	}
+	v6 := func(hopLimit uint8, payload []byte) []byte {
+		return header.BuildUDPv6Packet(uniqueLocalAddr1, uniqueLocalAddr2, 1234, 53, payload, hopLimit)
+	}
+	corruptUDPChecksum := func(pkt []byte, netProto tcpip.NetworkProtocolNumber) []byte {
+		_, transport, ok := header.TransportHeader(pkt, netProto)
+		if !ok {
+			t.Fatal("got header.TransportHeader(_, _) = (_, _, false), want = (_, _, true)")
+		}
+		udp := header.UDP(transport)
+		udp.SetChecksum(^udp.Checksum())
+		return pkt
+	}
+
+	tests := []struct {
+		name     string
+		netProto tcpip.NetworkProtocolNumber
+		a, b     []byte
+		wantSame bool
+	}{
+		{
+			name:     "IPv4 different TTL",
+			netProto: header.IPv4ProtocolNumber,
+			a:        v4(64, payload),
+			b:        v4(63, payload),
+			wantSame: true,
+		},
+		{
+			name:     "IPv4 different transport checksum",
+			netProto: header.IPv4ProtocolNumber,
+			a:        v4(64, payload),
+			b:        corruptUDPChecksum(v4(64, payload), header.IPv4ProtocolNumber),
+			wantSame: true,
+		},
+		{
+			name:     "IPv4 different payload",
+			netProto: header.IPv4ProtocolNumber,
+			a:        v4(64, payload),
+			b:        v4(64, []byte{1, 2, 3, 5}),
+		},
+		{
+			name:     "IPv4 different source port",
+			netProto: header.IPv4ProtocolNumber,
+			a:        v4(64, payload),
+			b:        header.BuildUDPv4Packet(testIPv4SrcAddr, testIPv4DstAddr, 1235, 53, payload, 64),
+		},
+		{
+			name:     "IPv6 different hop limit",
+			netProto: header.IPv6ProtocolNumber,
+			a:        v6(64, payload),
+			b:        v6(1, payload),
+			wantSame: true,
+		},
+		{
+			name:     "IPv6 different destination",
+			netProto: header.IPv6ProtocolNumber,
+			a:        v6(64, payload),
+			b:        header.BuildUDPv6Packet(uniqueLocalAddr1, globalAddr, 1234, 53, payload, 64),
+		},
+		{
+			name:     "unknown network protocol same data",
+			netProto: header.ARPProtocolNumber,
+			a:        v4(64, payload),
+			b:        v4(64, payload),
+			wantSame: true,
+		},
+		{
+			name:     "unknown network protocol different TTL",
+			netProto: header.ARPProtocolNumber,
+			a:        v4(64, payload),
+			b:        v4(63, payload),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := header.PacketFingerprint(test.a, test.netProto)
+			b := header.PacketFingerprint(test.b, test.netProto)
+			if got := a == b; got != test.wantSame {
+				t.Errorf("got fingerprints %#x and %#x, want same = %t", a, b, test.wantSame)
+			}
+		})
+	}
+}
+
+func TestRecomputeChecksums(t *testing.T) {
+	tcp := make([]byte, header.TCPMinimumSize+4)
+	header.TCP(tcp).Encode(&header.TCPFields{
+		SrcPort:    1234,
+		DstPort:    80,
+		DataOffset: header.TCPMinimumSize,
+		Flags:      header.TCPFlagAck,
+		WindowSize: 1024,
+	})
+	icmpv4 := []byte{byte(header.ICMPv4Echo), 0, 0, 0, 0, 1, 0, 1, 1, 2, 3, 4}
+	icmpv6 := []byte{byte(header.ICMPv6EchoRequest), 0, 0, 0, 0, 1, 0, 1, 1, 2, 3, 4}
+
+	tests := []struct {
+		name       string
+		netProto   tcpip.NetworkProtocolNumber
+		transProto tcpip.TransportProtocolNumber
+		pkt        []byte
+	}{
+		{
+			name:       "IPv4 TCP",
+			netProto:   header.IPv4ProtocolNumber,
+			transProto: header.TCPProtocolNumber,
+			pkt:        makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.TCPProtocolNumber)}, tcp),
+		},
+		{
+			name:       "IPv4 UDP",
+			netProto:   header.IPv4ProtocolNumber,
+			transProto: header.UDPProtocolNumber,
+			pkt:        header.BuildUDPv4Packet(testIPv4SrcAddr, testIPv4DstAddr, 1234, 53, []byte{1, 2, 3, 4}, 64),
+		},
+		{
+			name:       "IPv4 ICMP",
+			netProto:   header.IPv4ProtocolNumber,
+			transProto: header.ICMPv4ProtocolNumber,
+			pkt:        makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.ICMPv4ProtocolNumber)}, icmpv4),
+		},
+		{
+			name:       "IPv6 TCP",
+			netProto:   header.IPv6ProtocolNumber,
+			transProto: header.TCPProtocolNumber,
+			pkt:        makeIPv6Packet(header.IPv6Fields{TransportProtocol: header.TCPProtocolNumber, SrcAddr: uniqueLocalAddr1, DstAddr: uniqueLocalAddr2}, tcp),
+		},
+		{
+			name:       "IPv6 UDP",
+			netProto:   header.IPv6ProtocolNumber,
+			transProto: header.UDPProtocolNumber,
+			pkt:        header.BuildUDPv6Packet(uniqueLocalAddr1, uniqueLocalAddr2, 1234, 53, []byte{1, 2, 3, 4}, 64),
+		},
+		{
+			name:       "IPv6 ICMP",
+			netProto:   header.IPv6ProtocolNumber,
+			transProto: header.ICMPv6ProtocolNumber,
+			pkt:        makeIPv6Packet(header.IPv6Fields{TransportProtocol: header.ICMPv6ProtocolNumber, SrcAddr: uniqueLocalAddr1, DstAddr: uniqueLocalAddr2}, icmpv6),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pkt := test.pkt
+			var src, dst tcpip.Address
+			switch test.netProto {
+			case header.IPv4ProtocolNumber:
+				ip := header.IPv4(pkt)
+				ip.SetSourceAddress(tcpip.Address("\x0a\x00\x00\x03"))
+				src, dst = ip.SourceAddress(), ip.DestinationAddress()
+			case header.IPv6ProtocolNumber:
+				ip := header.IPv6(pkt)
+				ip.SetSourceAddress(globalAddr)
+				src, dst = ip.SourceAddress(), ip.DestinationAddress()
+			}
+			pkt[len(pkt)-1]++
+
+			if err := header.RecomputeChecksums(pkt, test.netProto); err != nil {
+				t.Fatalf("header.RecomputeChecksums(_, %d): %s", test.netProto, err)
+			}
+
+			if test.netProto == header.IPv4ProtocolNumber {
+				if got := header.IPv4(pkt).CalculateChecksum(); got != 0xffff {
+					t.Errorf("got IPv4 header checksum = %#x, want = 0xffff", got)
+				}
+			}
+			_, transport, ok := header.TransportHeader(pkt, test.netProto)
+			if !ok {
+				t.Fatal("got header.TransportHeader(_, _) = (_, _, false), want = (_, _, true)")
+			}
+			var xsum uint16
+			if test.transProto != header.ICMPv4ProtocolNumber {
+				xsum = header.PseudoHeaderChecksum(test.transProto, src, dst, uint16(len(transport)))
+			}
+			if got := header.Checksum(transport, xsum); got != 0xffff {
+				t.Errorf("got transport checksum = %#x, want = 0xffff", got)
+			}
+		})
+	}
+
+	t.Run("IPv4 UDP zero checksum", func(t *testing.T) {
+		pkt := makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber)}, testUDPHeader)
+		if err := header.RecomputeChecksums(pkt, header.IPv4ProtocolNumber); err != nil {
+			t.Fatalf("header.RecomputeChecksums(_, %d): %s", header.IPv4ProtocolNumber, err)
+		}
+		if got := header.UDP(header.IPv4(pkt).Payload()).Checksum(); got != 0 {
+			t.Errorf("got UDP checksum = %#x, want = 0", got)
+		}
+	})
+
+	t.Run("truncated transport header", func(t *testing.T) {
+		pkt := makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.TCPProtocolNumber)}, tcp[:header.TCPMinimumSize-1])
+		if err := header.RecomputeChecksums(pkt, header.IPv4ProtocolNumber); !errors.Is(err, io.ErrUnexpectedEOF) {
+			t.Errorf("got header.RecomputeChecksums(_, %d) = %v, want = %s", header.IPv4ProtocolNumber, err, io.ErrUnexpectedEOF)
+		}
+	})
+
+	t.Run("unknown network protocol", func(t *testing.T) {
+		pkt := makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber)}, testUDPHeader)
+		if err := header.RecomputeChecksums(pkt, header.ARPProtocolNumber); err == nil {
+			t.Errorf("got header.RecomputeChecksums(_, %d) = nil, want = non-nil", header.ARPProtocolNumber)
+		}
+	})
+}
+
+func TestShouldGenerateICMPError(t *testing.T) {
+	tests := []struct {
+		name     string
+		netProto tcpip.NetworkProtocolNumber
+		original []byte
+		want     bool
+	}{
+		{
+			name:     "IPv4 unicast",
+			netProto: header.IPv4ProtocolNumber,
+			original: makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber)}, testUDPHeader),
+			want:     true,
+		},
+		{
+			name:     "IPv4 broadcast destination",
+			netProto: header.IPv4ProtocolNumber,
+			original: makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber), DstAddr: header.IPv4Broadcast}, testUDPHeader),
+		},
+		{
+			name:     "IPv4 multicast destination",
+			netProto: header.IPv4ProtocolNumber,
+			original: makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber), DstAddr: tcpip.Address("\xe0\x00\x00\xfb")}, testUDPHeader),
+		},
+		{
+			name:     "IPv4 unspecified source",
+			netProto: header.IPv4ProtocolNumber,
+			original: makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber), SrcAddr: header.IPv4Any}, testUDPHeader),
+		},
+		{
+			name:     "IPv4 Class E source",
+			netProto: header.IPv4ProtocolNumber,
+			original: makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber), SrcAddr: tcpip.Address("\xf0\x00\x00\x01")}, testUDPHeader),
+		},
+		{
+			name:     "IPv4 first fragment",
+			netProto: header.IPv4ProtocolNumber,
+			original: makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber), Flags: header.IPv4FlagMoreFragments}, testUDPHeader),
+			want:     true,
+		},
+		{
+			name:     "IPv4 non-first fragment",
+			netProto: header.IPv4ProtocolNumber,
+			original: makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber), FragmentOffset: 8}, testUDPHeader),
+		},
+		{
+			name:     "IPv6 unicast",
+			netProto: header.IPv6ProtocolNumber,
+			original: makeIPv6Packet(header.IPv6Fields{TransportProtocol: header.UDPProtocolNumber, SrcAddr: uniqueLocalAddr1, DstAddr: uniqueLocalAddr2}, testUDPHeader),
+			want:     true,
+		},
+		{
+			name:     "IPv6 multicast destination",
+			netProto: header.IPv6ProtocolNumber,
+			original: makeIPv6Packet(header.IPv6Fields{TransportProtocol: header.UDPProtocolNumber, SrcAddr: uniqueLocalAddr1, DstAddr: header.IPv6AllNodesMulticastAddress}, testUDPHeader),
+		},
+		{
+			name:     "IPv6 unspecified source",
+			netProto: header.IPv6ProtocolNumber,
+			original: makeIPv6Packet(header.IPv6Fields{TransportProtocol: header.UDPProtocolNumber, SrcAddr: header.IPv6Any, DstAddr: uniqueLocalAddr2}, testUDPHeader),
+		},
+		{
+			name:     "truncated",
+			netProto: header.IPv4ProtocolNumber,
+			original: makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber)}, testUDPHeader)[:header.IPv4MinimumSize-1],
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.ShouldGenerateICMPError(test.original, test.netProto); got != test.want {
+				t.Errorf("got header.ShouldGenerateICMPError(_, %d) = %t, want = %t", test.netProto, got, test.want)
+			}
+		})
+	}
+}
+
+func TestFragmentPayload(t *testing.T) {
+	data := []byte{1, 2, 3, 4, 5, 6, 7, 8}
+	padding := make([]byte, 6)
+	pad := func(pkt []byte) []byte {
+		return append(pkt, padding...)
+	}
+	v4Fragment := makeIPv4Packet(header.IPv4Fields{
+		ID:       1,
+		Flags:    header.IPv4FlagMoreFragments,
+		Protocol: uint8(header.UDPProtocolNumber),
+	}, data)
+	v4LastFragment := makeIPv4Packet(header.IPv4Fields{
+		ID:             1,
+		FragmentOffset: 8,
+		Protocol:       uint8(header.UDPProtocolNumber),
+	}, data)
+	v4NotFragment := makeIPv4Packet(header.IPv4Fields{
+		Protocol: uint8(header.UDPProtocolNumber),
+	}, data)
+	// Next Header, Reserved, Fragment Offset = 0 with the M flag set and
+	// Identification.
+	fragmentHdr := []byte{uint8(header.UDPProtocolNumber), 0, 0, 1, 0, 0, 0, 1}
+	v6Fragment := makeIPv6Packet(header.IPv6Fields{
+		TransportProtocol: tcpip.TransportProtocolNumber(header.IPv6FragmentExtHdrIdentifier),
+	}, append(append([]byte{}, fragmentHdr...), data...))
+	v6FragmentAfterHopByHop := makeIPv6Packet(header.IPv6Fields{
+		TransportProtocol: tcpip.TransportProtocolNumber(header.IPv6FragmentExtHdrIdentifier),
+		ExtensionHeaders: header.IPv6ExtHdrSerializer{
+			header.IPv6SerializableHopByHopExtHdr{
+				&header.IPv6RouterAlertOption{Value: header.IPv6RouterAlertMLD},
+			},
+		},
+	}, append(append([]byte{}, fragmentHdr...), data...))
+	v6NotFragment := makeIPv6Packet(header.IPv6Fields{
+		TransportProtocol: header.UDPProtocolNumber,
+	}, data)
+
+	tests := []struct {
+		name     string
+		pkt      []byte
+		netProto tcpip.NetworkProtocolNumber
+		wantErr  bool
+	}{
+		{
+			name:     "IPv4 first fragment",
+			pkt:      v4Fragment,
+			netProto: header.IPv4ProtocolNumber,
+		},
+		{
+			name:     "IPv4 padded first fragment",
+			pkt:      pad(v4Fragment),
+			netProto: header.IPv4ProtocolNumber,
+		},
+		{
+			name:     "IPv4 padded last fragment",
+			pkt:      pad(v4LastFragment),
+			netProto: header.IPv4ProtocolNumber,
+		},
+		{
+			name:     "IPv4 not a fragment",
+			pkt:      v4NotFragment,
+			netProto: header.IPv4ProtocolNumber,
+			wantErr:  true,
+		},
+		{
+			name:     "IPv4 truncated",
+			pkt:      v4Fragment[:len(v4Fragment)-1],
+			netProto: header.IPv4ProtocolNumber,
+			wantErr:  true,
+		},
+		{
+			name:     "IPv6 fragment",
+			pkt:      v6Fragment,
+			netProto: header.IPv6ProtocolNumber,
+		},
+		{
+			name:     "IPv6 padded fragment",
+			pkt:      pad(v6Fragment),
+			netProto: header.IPv6ProtocolNumber,
+		},
+		{
+			name:     "IPv6 padded fragment after Hop-by-Hop Options",
+			pkt:      pad(v6FragmentAfterHopByHop),
+			netProto: header.IPv6ProtocolNumber,
+		},
+		{
+			name:     "IPv6 not a fragment",
+			pkt:      v6NotFragment,
+			netProto: header.IPv6ProtocolNumber,
+			wantErr:  true,
+		},
+		{
+			name:     "IPv6 truncated",
+			pkt:      v6Fragment[:len(v6Fragment)-1],
+			netProto: header.IPv6ProtocolNumber,
+			wantErr:  true,
+		},
+		{
+			name:     "unknown network protocol",
+			pkt:      v4Fragment,
+			netProto: header.ARPProtocolNumber,
+			wantErr:  true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := header.FragmentPayload(test.pkt, test.netProto)
+			if gotErr := err != nil; gotErr != test.wantErr {
+				t.Fatalf("got header.FragmentPayload(_, %d) = (_, %v), want error = %t", test.netProto, err, test.wantErr)
+			}
+			if test.wantErr {
+				return
+			}
+			if !bytes.Equal(payload, data) {
+				t.Errorf("got header.FragmentPayload(_, %d) = (%x, nil), want = (%x, nil)", test.netProto, payload, data)
+			}
+		})
+	}
+}
@@ -0,0 +1,91 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+const (
+	flowExportVersion = 0
+	ipfixLength       = 2
+	flowSetID         = 0
+	flowSetLength     = 2
+)
+
+const (
+	// IPFIXVersion is the version number of IPFIX messages, as per RFC 7011
+	// section 3.1.
+	IPFIXVersion = 10
+
+	// NetFlowV9Version is the version number of NetFlow version 9 export
+	// packets, as per RFC 3954 section 5.1.
+	NetFlowV9Version = 9
+
+	// IPFIXMessageHeaderSize is the size of an IPFIX Message Header, as per
+	// RFC 7011 section 3.1.
+	IPFIXMessageHeaderSize = 16
+
+	// NetFlowV9HeaderSize is the size of a NetFlow version 9 Packet Header,
+	// as per RFC 3954 section 5.1.
+	NetFlowV9HeaderSize = 20
+
+	// IPFIXSetHeaderSize is the size of an IPFIX Set Header (and a NetFlow
+	// version 9 FlowSet header), as per RFC 7011 section 3.3.2.
+	IPFIXSetHeaderSize = 4
+)
+
+// IPFIXMessage parses the message header and the header of the first set of an
+// IPFIX (version 10) or NetFlow version 9 export message held in udpPayload.
+//
+// For IPFIX, length is the Length field of the message header. NetFlow version
+// 9 headers carry a record count instead of a length, so length is the size of
+// udpPayload for those messages.
+//
+// ok is false if udpPayload is not a well-framed IPFIX or NetFlow version 9
+// message, including when the first set does not fit in the message.
+func IPFIXMessage(udpPayload []byte) (version uint16, length uint16, setID uint16, ok bool) {
+	if len(udpPayload) < IPFIXMessageHeaderSize {
+		return 0, 0, 0, false
+	}
+
+	var hdrSize int
+	switch version = binary.BigEndian.Uint16(udpPayload[flowExportVersion:]); version {
+	case IPFIXVersion:
+		hdrSize = IPFIXMessageHeaderSize
+		length = binary.BigEndian.Uint16(udpPayload[ipfixLength:])
+		if int(length) > len(udpPayload) {
+			return 0, 0, 0, false
+		}
+	case NetFlowV9Version:
+		hdrSize = NetFlowV9HeaderSize
+		if len(udpPayload) > math.MaxUint16 {
+			return 0, 0, 0, false
+		}
+		length = uint16(len(udpPayload))
+	default:
+		return 0, 0, 0, false
+	}
+
+	if int(length) < hdrSize+IPFIXSetHeaderSize {
+		return 0, 0, 0, false
+	}
+	set := udpPayload[hdrSize:length]
+	if setLen := int(binary.BigEndian.Uint16(set[flowSetLength:])); setLen < IPFIXSetHeaderSize || setLen > len(set) {
+		return 0, 0, 0, false
+	}
+	return version, length, binary.BigEndian.Uint16(set[flowSetID:]), true
+}
@@ -0,0 +1,132 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestIPFIXMessage(t *testing.T) {
+	tests := []struct {
+		name        string
+		payload     []byte
+		wantVersion uint16
+		wantLength  uint16
+		wantSetID   uint16
+		wantOK      bool
+	}{
+		{
+			name: "IPFIX",
+			payload: []byte{
+				// Version, Length.
+				0, 10, 0, 24,
+				// Export Time.
+				0x60, 0x00, 0x00, 0x00,
+				// Sequence Number.
+				0, 0, 0, 1,
+				// Observation Domain ID.
+				0, 0, 0, 7,
+				// Set ID (Template Set), Length.
+				0, 2, 0, 8,
+				// Template ID, Field Count.
+				1, 0, 0, 0,
+			},
+			wantVersion: header.IPFIXVersion,
+			wantLength:  24,
+			wantSetID:   2,
+			wantOK:      true,
+		},
+		{
+			name: "NetFlowV9",
+			payload: []byte{
+				// Version, Count.
+				0, 9, 0, 1,
+				// System Uptime.
+				0, 0, 0x10, 0x00,
+				// UNIX Seconds.
+				0x60, 0x00, 0x00, 0x00,
+				// Sequence Number.
+				0, 0, 0, 1,
+				// Source ID.
+				0, 0, 0, 7,
+				// FlowSet ID (data), Length.
+				1, 0, 0, 8,
+				// Record.
+				10, 0, 0, 1,
+			},
+			wantVersion: header.NetFlowV9Version,
+			wantLength:  28,
+			wantSetID:   256,
+			wantOK:      true,
+		},
+		{
+			name: "IPFIXLengthExceedsPayload",
+			payload: []byte{
+				0, 10, 0, 64,
+				0, 0, 0, 0,
+				0, 0, 0, 0,
+				0, 0, 0, 0,
+				0, 2, 0, 4,
+			},
+		},
+		{
+			name: "SetLengthExceedsMessage",
+			payload: []byte{
+				0, 10, 0, 20,
+				0, 0, 0, 0,
+				0, 0, 0, 0,
+				0, 0, 0, 0,
+				0, 2, 0, 8,
+			},
+		},
+		{
+			name: "UnknownVersion",
+			payload: []byte{
+				0, 5, 0, 20,
+				0, 0, 0, 0,
+				0, 0, 0, 0,
+				0, 0, 0, 0,
+				0, 2, 0, 4,
+			},
+		},
+		{
+			name:    "Truncated",
+			payload: []byte{0, 10, 0, 16},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			version, length, setID, ok := header.IPFIXMessage(test.payload)
+			if ok != test.wantOK {
+				t.Fatalf("got header.IPFIXMessage(_) ok = %t, want = %t", ok, test.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if version != test.wantVersion {
+				t.Errorf("got version = %d, want = %d", version, test.wantVersion)
+			}
+			if length != test.wantLength {
+				t.Errorf("got length = %d, want = %d", length, test.wantLength)
+			}
+			if setID != test.wantSetID {
+				t.Errorf("got setID = %d, want = %d", setID, test.wantSetID)
+			}
+		})
+	}
+}
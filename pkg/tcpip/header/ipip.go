@@ -0,0 +1,142 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"fmt"
+	"math"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+const (
+	// IPv4EncapsulationProtocolNumber is the protocol number carried by an
+	// IP header encapsulating an IPv4 packet, as per RFC 2003.
+	IPv4EncapsulationProtocolNumber tcpip.TransportProtocolNumber = 4
+
+	// IPv6EncapsulationProtocolNumber is the protocol number carried by an
+	// IP header encapsulating an IPv6 packet, as per RFC 4213 and RFC 2473.
+	IPv6EncapsulationProtocolNumber tcpip.TransportProtocolNumber = 41
+)
+
+// DecapsulateIPinIP returns the packet tunnelled in the IPv4 or IPv6 packet
+// outer and its network protocol. The returned slice aliases outer; no data
+// is copied.
+//
+// ok is false if outer is not a valid IP packet, if it is a fragment, if it
+// does not carry an IPv4 or IPv6 packet or if the version of the inner packet
+// does not match the protocol number of the outer header.
+func DecapsulateIPinIP(outer []byte) (inner []byte, innerProto tcpip.NetworkProtocolNumber, ok bool) {
+	var outerProto tcpip.NetworkProtocolNumber
+	switch IPVersion(outer) {
+	case IPv4Version:
+		outerProto = IPv4ProtocolNumber
+		if ipv4 := IPv4(outer); !ipv4.IsValid(len(outer)) || ipv4.More() {
+			return nil, 0, false
+		}
+	case IPv6Version:
+		outerProto = IPv6ProtocolNumber
+		if ipv6 := IPv6(outer); !ipv6.IsValid(len(outer)) || ipv6HasFragmentExtHdr(ipv6) {
+			return nil, 0, false
+		}
+	default:
+		return nil, 0, false
+	}
+
+	proto, inner, ok := TransportHeader(outer, outerProto)
+	if !ok {
+		return nil, 0, false
+	}
+	switch tcpip.TransportProtocolNumber(proto) {
+	case IPv4EncapsulationProtocolNumber:
+		if IPVersion(inner) != IPv4Version {
+			return nil, 0, false
+		}
+		return inner, IPv4ProtocolNumber, true
+	case IPv6EncapsulationProtocolNumber:
+		if IPVersion(inner) != IPv6Version {
+			return nil, 0, false
+		}
+		return inner, IPv6ProtocolNumber, true
+	default:
+		return nil, 0, false
+	}
+}
+
+// EncapsulateIPinIP returns a new packet made of an outerNetProto header sent
+// from src to dst with a TTL or hop limit of ttl, followed by the IPv4 or IPv6
+// packet inner. The protocol number of the outer header is derived from the
+// version of inner and the IPv4 header checksum is computed.
+//
+// An error is returned if inner is not an IPv4 or IPv6 packet, if
+// outerNetProto is neither IPv4 nor IPv6, if src or dst is not an
+// outerNetProto address or if the encapsulated packet would exceed the maximum
+// size of an outerNetProto packet.
+func EncapsulateIPinIP(inner []byte, src, dst tcpip.Address, outerNetProto tcpip.NetworkProtocolNumber, ttl uint8) ([]byte, error) {
+	var proto tcpip.TransportProtocolNumber
+	switch v := IPVersion(inner); v {
+	case IPv4Version:
+		proto = IPv4EncapsulationProtocolNumber
+	case IPv6Version:
+		proto = IPv6EncapsulationProtocolNumber
+	default:
+		return nil, fmt.Errorf("got inner packet with IP version %d, want %d or %d", v, IPv4Version, IPv6Version)
+	}
+
+	switch outerNetProto {
+	case IPv4ProtocolNumber:
+		if len(src) != IPv4AddressSize || len(dst) != IPv4AddressSize {
+			return nil, fmt.Errorf("got addresses of %d and %d bytes, want %d for outer IPv4 header", len(src), len(dst), IPv4AddressSize)
+		}
+		totalLen := IPv4MinimumSize + len(inner)
+		if totalLen > math.MaxUint16 {
+			return nil, fmt.Errorf("got inner packet of %d bytes, want at most %d bytes", len(inner), math.MaxUint16-IPv4MinimumSize)
+		}
+		b := make([]byte, totalLen)
+		ip := IPv4(b)
+		ip.Encode(&IPv4Fields{
+			TotalLength: uint16(totalLen),
+			TTL:         ttl,
+			Protocol:    uint8(proto),
+			SrcAddr:     src,
+			DstAddr:     dst,
+		})
+		ip.SetChecksum(ChecksumToField(ip.CalculateChecksum()))
+		copy(ip.Payload(), inner)
+		return b, nil
+
+	case IPv6ProtocolNumber:
+		if len(src) != IPv6AddressSize || len(dst) != IPv6AddressSize {
+			return nil, fmt.Errorf("got addresses of %d and %d bytes, want %d for outer IPv6 header", len(src), len(dst), IPv6AddressSize)
+		}
+		if len(inner) > IPv6MaximumPayloadSize {
+			return nil, fmt.Errorf("got inner packet of %d bytes, want at most %d bytes", len(inner), IPv6MaximumPayloadSize)
+		}
+		b := make([]byte, IPv6MinimumSize+len(inner))
+		ip := IPv6(b)
+		ip.Encode(&IPv6Fields{
+			PayloadLength:     uint16(len(inner)),
+			TransportProtocol: proto,
+			HopLimit:          ttl,
+			SrcAddr:           src,
+			DstAddr:           dst,
+		})
+		copy(ip.Payload(), inner)
+		return b, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported network protocol number = %d", outerNetProto)
+	}
+}
@@ -0,0 +1,207 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestDecapsulateIPinIP(t *testing.T) {
+	innerV4 := header.BuildUDPv4Packet(testIPv4SrcAddr, testIPv4DstAddr, 1234, 53, []byte{1, 2, 3, 4}, 64)
+	innerV6 := header.BuildUDPv6Packet(uniqueLocalAddr1, uniqueLocalAddr2, 1234, 53, []byte{1, 2, 3, 4}, 64)
+	v4Outer := func(proto tcpip.TransportProtocolNumber, inner []byte) []byte {
+		return makeIPv4Packet(header.IPv4Fields{
+			Protocol: uint8(proto),
+			SrcAddr:  tcpip.Address("\xc0\x00\x02\x01"),
+			DstAddr:  tcpip.Address("\xc0\x00\x02\x02"),
+		}, inner)
+	}
+
+	tests := []struct {
+		name           string
+		outer          []byte
+		wantInner      []byte
+		wantInnerProto tcpip.NetworkProtocolNumber
+		wantOK         bool
+	}{
+		{
+			name:           "4in4",
+			outer:          v4Outer(header.IPv4EncapsulationProtocolNumber, innerV4),
+			wantInner:      innerV4,
+			wantInnerProto: header.IPv4ProtocolNumber,
+			wantOK:         true,
+		},
+		{
+			name:           "6in4",
+			outer:          v4Outer(header.IPv6EncapsulationProtocolNumber, innerV6),
+			wantInner:      innerV6,
+			wantInnerProto: header.IPv6ProtocolNumber,
+			wantOK:         true,
+		},
+		{
+			name: "4in6",
+			outer: makeIPv6Packet(header.IPv6Fields{
+				TransportProtocol: header.IPv4EncapsulationProtocolNumber,
+				SrcAddr:           globalAddr,
+				DstAddr:           uniqueLocalAddr1,
+			}, innerV4),
+			wantInner:      innerV4,
+			wantInnerProto: header.IPv4ProtocolNumber,
+			wantOK:         true,
+		},
+		{
+			name:  "version mismatch",
+			outer: v4Outer(header.IPv4EncapsulationProtocolNumber, innerV6),
+		},
+		{
+			name:  "not a tunnel",
+			outer: innerV4,
+		},
+		{
+			name:  "truncated outer header",
+			outer: v4Outer(header.IPv4EncapsulationProtocolNumber, innerV4)[:header.IPv4MinimumSize-1],
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			inner, innerProto, ok := header.DecapsulateIPinIP(test.outer)
+			if ok != test.wantOK {
+				t.Fatalf("got header.DecapsulateIPinIP(_) = (_, _, %t), want = (_, _, %t)", ok, test.wantOK)
+			}
+			if innerProto != test.wantInnerProto {
+				t.Errorf("got innerProto = %d, want = %d", innerProto, test.wantInnerProto)
+			}
+			if !bytes.Equal(inner, test.wantInner) {
+				t.Errorf("got inner = %x, want = %x", inner, test.wantInner)
+			}
+		})
+	}
+}
+
+func TestEncapsulateIPinIP(t *testing.T) {
+	innerV4 := header.BuildUDPv4Packet(testIPv4SrcAddr, testIPv4DstAddr, 1234, 53, []byte{1, 2, 3, 4}, 64)
+	innerV6 := header.BuildUDPv6Packet(uniqueLocalAddr1, uniqueLocalAddr2, 1234, 53, []byte{1, 2, 3, 4}, 64)
+	outerV4Src := tcpip.Address("\xc0\x00\x02\x01")
+	outerV4Dst := tcpip.Address("\xc0\x00\x02\x02")
+
+	tests := []struct {
+		name          string
+		inner         []byte
+		src, dst      tcpip.Address
+		outerNetProto tcpip.NetworkProtocolNumber
+		wantProto     tcpip.TransportProtocolNumber
+		wantErr       bool
+	}{
+		{
+			name:          "4in4",
+			inner:         innerV4,
+			src:           outerV4Src,
+			dst:           outerV4Dst,
+			outerNetProto: header.IPv4ProtocolNumber,
+			wantProto:     header.IPv4EncapsulationProtocolNumber,
+		},
+		{
+			name:          "6in4",
+			inner:         innerV6,
+			src:           outerV4Src,
+			dst:           outerV4Dst,
+			outerNetProto: header.IPv4ProtocolNumber,
+			wantProto:     header.IPv6EncapsulationProtocolNumber,
+		},
+		{
+			name:          "4in6",
+			inner:         innerV4,
+			src:           globalAddr,
+			dst:           uniqueLocalAddr1,
+			outerNetProto: header.IPv6ProtocolNumber,
+			wantProto:     header.IPv4EncapsulationProtocolNumber,
+		},
+		{
+			name:          "outer address version mismatch",
+			inner:         innerV4,
+			src:           globalAddr,
+			dst:           uniqueLocalAddr1,
+			outerNetProto: header.IPv4ProtocolNumber,
+			wantErr:       true,
+		},
+		{
+			name:          "unknown inner version",
+			inner:         []byte{0x50, 0, 0, 0},
+			src:           outerV4Src,
+			dst:           outerV4Dst,
+			outerNetProto: header.IPv4ProtocolNumber,
+			wantErr:       true,
+		},
+		{
+			name:          "unknown outer network protocol",
+			inner:         innerV4,
+			src:           outerV4Src,
+			dst:           outerV4Dst,
+			outerNetProto: header.ARPProtocolNumber,
+			wantErr:       true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pkt, err := header.EncapsulateIPinIP(test.inner, test.src, test.dst, test.outerNetProto, 64)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("got header.EncapsulateIPinIP(...) = (_, nil), want = (_, non-nil)")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("header.EncapsulateIPinIP(...): %s", err)
+			}
+
+			var ip header.Network
+			switch test.outerNetProto {
+			case header.IPv4ProtocolNumber:
+				ipv4 := header.IPv4(pkt)
+				if !ipv4.IsValid(len(pkt)) {
+					t.Fatalf("got invalid outer IPv4 header = %x", pkt)
+				}
+				if got := ipv4.CalculateChecksum(); got != 0xffff {
+					t.Errorf("got outer IPv4 header checksum = %#x, want = 0xffff", got)
+				}
+				ip = ipv4
+			case header.IPv6ProtocolNumber:
+				ipv6 := header.IPv6(pkt)
+				if !ipv6.IsValid(len(pkt)) {
+					t.Fatalf("got invalid outer IPv6 header = %x", pkt)
+				}
+				ip = ipv6
+			}
+			if got := ip.TransportProtocol(); got != test.wantProto {
+				t.Errorf("got outer protocol = %d, want = %d", got, test.wantProto)
+			}
+			if got := ip.SourceAddress(); got != test.src {
+				t.Errorf("got outer source address = %s, want = %s", got, test.src)
+			}
+			if got := ip.DestinationAddress(); got != test.dst {
+				t.Errorf("got outer destination address = %s, want = %s", got, test.dst)
+			}
+			if got, _, ok := header.DecapsulateIPinIP(pkt); !ok || !bytes.Equal(got, test.inner) {
+				t.Errorf("got header.DecapsulateIPinIP(_) = (%x, _, %t), want = (%x, _, true)", got, ok, test.inner)
+			}
+		})
+	}
+}
@@ -16,7 +16,10 @@ package header
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
+	"io"
+	"math"
 
 	"gvisor.dev/gvisor/pkg/tcpip"
 )
@@ -198,6 +201,56 @@ var ipv4LinkLocalMulticastSubnet = func() tcpip.Subnet {
 	return subnet
 }()
 
+// ipv4LoopbackSubnet is the IPv4 loopback subnet as defined by RFC 1122
+// section 3.2.1.3.
+var ipv4LoopbackSubnet = func() tcpip.Subnet {
+	subnet, err := tcpip.NewSubnet("\x7f\x00\x00\x00", tcpip.AddressMask("\xff\x00\x00\x00"))
+	if err != nil {
+		panic(err)
+	}
+	return subnet
+}()
+
+// ipv4PrivateSubnets are the IPv4 private address subnets as defined by RFC
+// 1918 section 3.
+var ipv4PrivateSubnets = func() []tcpip.Subnet {
+	var subnets []tcpip.Subnet
+	for _, s := range []struct {
+		addr tcpip.Address
+		mask tcpip.AddressMask
+	}{
+		{addr: "\x0a\x00\x00\x00", mask: "\xff\x00\x00\x00"},
+		{addr: "\xac\x10\x00\x00", mask: "\xff\xf0\x00\x00"},
+		{addr: "\xc0\xa8\x00\x00", mask: "\xff\xff\x00\x00"},
+	} {
+		subnet, err := tcpip.NewSubnet(s.addr, s.mask)
+		if err != nil {
+			panic(err)
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets
+}()
+
+// ipv4DocumentationSubnets are the IPv4 address blocks reserved for
+// documentation (TEST-NET-1, TEST-NET-2 and TEST-NET-3), as defined by RFC
+// 5737 section 3.
+var ipv4DocumentationSubnets = func() []tcpip.Subnet {
+	var subnets []tcpip.Subnet
+	for _, addr := range []tcpip.Address{
+		"\xc0\x00\x02\x00",
+		"\xc6\x33\x64\x00",
+		"\xcb\x00\x71\x00",
+	} {
+		subnet, err := tcpip.NewSubnet(addr, tcpip.AddressMask("\xff\xff\xff\x00"))
+		if err != nil {
+			panic(err)
+		}
+		subnets = append(subnets, subnet)
+	}
+	return subnets
+}()
+
 // IPv4EmptySubnet is the empty IPv4 subnet.
 var IPv4EmptySubnet = func() tcpip.Subnet {
 	subnet, err := tcpip.NewSubnet(IPv4Any, tcpip.AddressMask(IPv4Any))
@@ -245,6 +298,28 @@ func (b IPv4) HeaderLength() uint8 {
 	return (b[versIHL] & ipIHLMask) * IPv4IHLStride
 }
 
+// ErrIPv4InvalidHeaderLength is returned by IPv4.CheckedHeaderLength when the
+// IHL field is below the minimum of 5 words.
+var ErrIPv4InvalidHeaderLength = errors.New("invalid IPv4 header length")
+
+// CheckedHeaderLength returns the length of the IPv4 header in bytes, like
+// HeaderLength, after checking that the IHL field is at least 5 as required by
+// RFC 791 and that b holds the whole header. It allows the forwarding path to
+// drop malformed packets before slicing them.
+func (b IPv4) CheckedHeaderLength() (uint8, error) {
+	if len(b) < IPv4MinimumSize {
+		return 0, fmt.Errorf("got %d bytes, want at least %d: %w", len(b), IPv4MinimumSize, io.ErrUnexpectedEOF)
+	}
+	hdrLen := b.HeaderLength()
+	if hdrLen < IPv4MinimumSize {
+		return 0, fmt.Errorf("got IHL = %d, want >= %d: %w", hdrLen/IPv4IHLStride, IPv4MinimumSize/IPv4IHLStride, ErrIPv4InvalidHeaderLength)
+	}
+	if int(hdrLen) > len(b) {
+		return 0, fmt.Errorf("got header length = %d, want <= %d: %w", hdrLen, len(b), io.ErrUnexpectedEOF)
+	}
+	return hdrLen, nil
+}
+
 // SetHeaderLength sets the value of the "Internet Header Length" field.
 func (b IPv4) SetHeaderLength(hdrLen uint8) {
 	if hdrLen > IPv4MaximumHeaderSize {
@@ -283,6 +358,17 @@ func (b IPv4) FragmentOffset() uint16 {
 	return binary.BigEndian.Uint16(b[flagsFO:]) << 3
 }
 
+// FlagsFragmentOffset returns the "flags" and "fragment offset" fields of the
+// IPv4 header, with the offset in bytes as returned by FragmentOffset.
+//
+// Both fields are decoded from a single read of the 16-bit word holding them,
+// so they are consistent with each other even if the header is rewritten
+// between calls to Flags and FragmentOffset.
+func (b IPv4) FlagsFragmentOffset() (flags uint8, offsetBytes uint16) {
+	v := binary.BigEndian.Uint16(b[flagsFO:])
+	return uint8(v >> 13), v << 3
+}
+
 // TotalLength returns the "total length" field of the IPv4 header.
 func (b IPv4) TotalLength() uint16 {
 	return binary.BigEndian.Uint16(b[IPv4TotalLenOffset:])
@@ -321,6 +407,50 @@ func (b IPv4) Options() IPv4Options {
 	return IPv4Options(b[options:hdrLen:hdrLen])
 }
 
+// OptionsValid returns true iff the options of the IPv4 header are well
+// formed, i.e. each of them fits in the region delimited by the IHL field.
+//
+// Parsing stops at the first End of Option List option; the remaining bytes
+// are padding.
+func (b IPv4) OptionsValid() bool {
+	if len(b) < IPv4MinimumSize {
+		return false
+	}
+	if hdrLen := int(b.HeaderLength()); hdrLen < IPv4MinimumSize || hdrLen > len(b) {
+		return false
+	}
+	it := b.Options().MakeIterator()
+	for {
+		opt, done, optProblem := it.Next()
+		if optProblem != nil {
+			return false
+		}
+		if done || opt.Type() == IPv4OptionListEndType {
+			return true
+		}
+	}
+}
+
+// HasRouterAlert returns true iff the options of the IPv4 header hold a Router
+// Alert option with the value defined by RFC 2113.
+//
+// Malformed options are treated as not holding the option.
+func (b IPv4) HasRouterAlert() bool {
+	it := b.Options().MakeIterator()
+	for {
+		opt, done, optProblem := it.Next()
+		if done || optProblem != nil {
+			return false
+		}
+		switch opt.Type() {
+		case IPv4OptionListEndType:
+			return false
+		case IPv4OptionRouterAlertType:
+			return opt.(*IPv4OptionRouterAlert).Value() == IPv4OptionRouterAlertValue
+		}
+	}
+}
+
 // TransportProtocol implements Network.TransportProtocol.
 func (b IPv4) TransportProtocol() tcpip.TransportProtocolNumber {
 	return tcpip.TransportProtocolNumber(b.Protocol())
@@ -421,7 +551,7 @@ func (b IPv4) Encode(i *IPv4Fields) {
 func (b IPv4) EncodePartial(partialChecksum, totalLength uint16) {
 	b.SetTotalLength(totalLength)
 	checksum := Checksum(b[IPv4TotalLenOffset:IPv4TotalLenOffset+2], partialChecksum)
-	b.SetChecksum(^checksum)
+	b.SetChecksum(ChecksumToField(checksum))
 }
 
 // IsValid performs basic validation on the packet.
@@ -443,6 +573,78 @@ func (b IPv4) IsValid(pktSize int) bool {
 	return true
 }
 
+// ReassembledSize returns the size of the payload of an IPv4 datagram once
+// reassembled, given the fragment offset and payload length of its last
+// fragment, i.e. the fragment with the More Fragments flag cleared.
+//
+// lastFragOffset is in bytes, as returned by IPv4.FragmentOffset.
+func ReassembledSize(lastFragOffset uint16, lastFragLen int) int {
+	return int(lastFragOffset) + lastFragLen
+}
+
+// FragmentExceedsMax returns true if a fragment whose payload of fragLen bytes
+// starts offset bytes into the original datagram's payload would extend the
+// reassembled datagram past 65535 bytes, the largest length the Total Length
+// field can describe. Such a fragment is malformed and must be dropped.
+//
+// offset is in bytes, as returned by IPv4.FragmentOffset.
+func FragmentExceedsMax(offset uint16, fragLen int) bool {
+	return ReassembledSize(offset, fragLen) > math.MaxUint16
+}
+
+// IPv4AddressScope is the scope of an IPv4 unicast address.
+//
+// Scopes are ordered from the narrowest to the widest.
+type IPv4AddressScope int
+
+const (
+	// IPv4LoopbackScope indicates a loopback address (127.0.0.0/8).
+	IPv4LoopbackScope IPv4AddressScope = iota
+
+	// IPv4LinkLocalScope indicates a link-local address (169.254.0.0/16).
+	IPv4LinkLocalScope
+
+	// IPv4PrivateScope indicates a private address, as defined by RFC 1918.
+	IPv4PrivateScope
+
+	// IPv4GlobalScope indicates a global address.
+	IPv4GlobalScope
+)
+
+// V4AddressScope returns the scope of the IPv4 address addr.
+func V4AddressScope(addr tcpip.Address) IPv4AddressScope {
+	if len(addr) != IPv4AddressSize {
+		panic(fmt.Sprintf("got len(addr) = %d, want = %d", len(addr), IPv4AddressSize))
+	}
+
+	switch {
+	case ipv4LoopbackSubnet.Contains(addr):
+		return IPv4LoopbackScope
+	case ipv4LinkLocalUnicastSubnet.Contains(addr):
+		return IPv4LinkLocalScope
+	}
+	for _, subnet := range ipv4PrivateSubnets {
+		if subnet.Contains(addr) {
+			return IPv4PrivateScope
+		}
+	}
+	return IPv4GlobalScope
+}
+
+// IsV4Documentation determines if the provided address is an IPv4
+// documentation address, which should never appear on the wire.
+func IsV4Documentation(addr tcpip.Address) bool {
+	if len(addr) != IPv4AddressSize {
+		return false
+	}
+	for _, subnet := range ipv4DocumentationSubnets {
+		if subnet.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
 // IsV4LinkLocalUnicastAddress determines if the provided address is an IPv4
 // link-local unicast address.
 func IsV4LinkLocalUnicastAddress(addr tcpip.Address) bool {
@@ -510,8 +712,18 @@ const (
 
 	// IPv4OptionLengthOffset is the offset in an option of its length field.
 	IPv4OptionLengthOffset = 1
+
+	// ipv4OptionCopiedFlag is the copied flag in an option's type field.
+	ipv4OptionCopiedFlag = 0x80
 )
 
+// IPv4OptionCopied returns true iff the copied flag of the option type optType
+// is set, i.e. the option must be copied into all fragments on fragmentation,
+// as per RFC 791 page 15.
+func IPv4OptionCopied(optType IPv4OptionType) bool {
+	return optType&ipv4OptionCopiedFlag != 0
+}
+
 // IPv4OptParameterProblem indicates that a Parameter Problem message
 // should be generated, and gives the offset in the current entity
 // that should be used in that packet.
@@ -1080,6 +1292,43 @@ func (o *IPv4SerializableRouterAlertOption) serializeInto(buffer []byte) uint8 {
 	return o.length()
 }
 
+// AddRouterAlert returns a copy of the IPv4 packet held in hdr with a Router
+// Alert option added to its header, updating the header length, total length
+// and checksum accordingly.
+//
+// The option is placed before any existing options so that it is not hidden by
+// an End of Option List option. hdr is returned as is if it already holds a
+// Router Alert option.
+func AddRouterAlert(hdr []byte) ([]byte, error) {
+	ip := IPv4(hdr)
+	if !ip.IsValid(len(hdr)) {
+		return nil, fmt.Errorf("got invalid IPv4 packet of %d bytes", len(hdr))
+	}
+	if ip.HasRouterAlert() {
+		return hdr, nil
+	}
+	hdrLen := int(ip.HeaderLength()) + IPv4OptionRouterAlertLength
+	if hdrLen > IPv4MaximumHeaderSize {
+		return nil, fmt.Errorf("got IPv4 header size = %d with a Router Alert option, want <= %d", hdrLen, IPv4MaximumHeaderSize)
+	}
+	totalLen := int(ip.TotalLength()) + IPv4OptionRouterAlertLength
+	if totalLen > math.MaxUint16 {
+		return nil, fmt.Errorf("got IPv4 total length = %d with a Router Alert option, want <= %d", totalLen, math.MaxUint16)
+	}
+
+	b := make([]byte, len(hdr)+IPv4OptionRouterAlertLength)
+	copy(b, hdr[:options])
+	n := IPv4OptionsSerializer{&IPv4SerializableRouterAlertOption{}}.Serialize(b[options:])
+	copy(b[options+int(n):], hdr[options:])
+
+	ip = IPv4(b)
+	ip.SetHeaderLength(uint8(hdrLen))
+	ip.SetTotalLength(uint16(totalLen))
+	ip.SetChecksum(0)
+	ip.SetChecksum(ChecksumToField(ip.CalculateChecksum()))
+	return b, nil
+}
+
 var _ IPv4SerializableOption = (*IPv4SerializableNOPOption)(nil)
 
 // IPv4SerializableNOPOption provides serialization for the IPv4 no-op option.
@@ -15,6 +15,8 @@
 package header_test
 
 import (
+	"errors"
+	"io"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -252,3 +254,416 @@ func TestIsV4LinkLocalMulticastAddress(t *testing.T) {
 		})
 	}
 }
+
+func TestIPv4CalculateChecksum(t *testing.T) {
+	tests := []struct {
+		name    string
+		options header.IPv4OptionsSerializer
+	}{
+		{name: "no options"},
+		{
+			name: "router alert",
+			options: header.IPv4OptionsSerializer{
+				&header.IPv4SerializableRouterAlertOption{},
+			},
+		},
+		{
+			name: "NOP and router alert",
+			options: header.IPv4OptionsSerializer{
+				&header.IPv4SerializableNOPOption{},
+				&header.IPv4SerializableRouterAlertOption{},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ip := header.IPv4(makeIPv4Packet(header.IPv4Fields{
+				ID:       0xbeef,
+				Protocol: uint8(header.UDPProtocolNumber),
+				Options:  test.options,
+			}, testUDPHeader))
+
+			hdr := ip[:ip.HeaderLength()]
+			if got, want := ip.CalculateChecksum(), header.ChecksumOld(hdr, 0); got != want {
+				t.Errorf("got CalculateChecksum() = 0x%04x, want = 0x%04x", got, want)
+			}
+			if got := ip.CalculateChecksum(); got != 0xffff {
+				t.Errorf("got CalculateChecksum() = 0x%04x over a header with a valid checksum, want = 0xffff", got)
+			}
+		})
+	}
+}
+
+func TestIPv4RouterAlert(t *testing.T) {
+	tests := []struct {
+		name          string
+		options       header.IPv4OptionsSerializer
+		wantHasBefore bool
+		wantHdrLen    uint8
+	}{
+		{
+			name:          "no options",
+			wantHasBefore: false,
+			wantHdrLen:    header.IPv4MinimumSize + header.IPv4OptionRouterAlertLength,
+		},
+		{
+			name: "NOP",
+			options: header.IPv4OptionsSerializer{
+				&header.IPv4SerializableNOPOption{},
+			},
+			wantHasBefore: false,
+			wantHdrLen:    header.IPv4MinimumSize + 4 + header.IPv4OptionRouterAlertLength,
+		},
+		{
+			name: "router alert",
+			options: header.IPv4OptionsSerializer{
+				&header.IPv4SerializableRouterAlertOption{},
+			},
+			wantHasBefore: true,
+			wantHdrLen:    header.IPv4MinimumSize + header.IPv4OptionRouterAlertLength,
+		},
+		{
+			name: "NOP and router alert",
+			options: header.IPv4OptionsSerializer{
+				&header.IPv4SerializableNOPOption{},
+				&header.IPv4SerializableRouterAlertOption{},
+			},
+			wantHasBefore: true,
+			wantHdrLen:    header.IPv4MinimumSize + 8,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pkt := makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber), Options: test.options}, testUDPHeader)
+			if got := header.IPv4(pkt).HasRouterAlert(); got != test.wantHasBefore {
+				t.Errorf("got HasRouterAlert() = %t, want = %t", got, test.wantHasBefore)
+			}
+
+			pkt, err := header.AddRouterAlert(pkt)
+			if err != nil {
+				t.Fatalf("header.AddRouterAlert(_): %s", err)
+			}
+			ip := header.IPv4(pkt)
+			if !ip.IsValid(len(pkt)) {
+				t.Fatalf("got IsValid(%d) = false, want = true", len(pkt))
+			}
+			if !ip.HasRouterAlert() {
+				t.Error("got HasRouterAlert() = false after adding the option, want = true")
+			}
+			if got := ip.HeaderLength(); got != test.wantHdrLen {
+				t.Errorf("got HeaderLength() = %d, want = %d", got, test.wantHdrLen)
+			}
+			if got, want := int(ip.TotalLength()), len(pkt); got != want {
+				t.Errorf("got TotalLength() = %d, want = %d", got, want)
+			}
+			if got := ip.CalculateChecksum(); got != 0xffff {
+				t.Errorf("got CalculateChecksum() = 0x%04x, want = 0xffff", got)
+			}
+			if diff := cmp.Diff(testUDPHeader, ip.Payload()); diff != "" {
+				t.Errorf("payload mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestAddRouterAlertNoRoom(t *testing.T) {
+	var opts header.IPv4OptionsSerializer
+	for i := 0; i < header.IPv4MaximumOptionsSize; i++ {
+		opts = append(opts, &header.IPv4SerializableNOPOption{})
+	}
+	pkt := makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber), Options: opts}, testUDPHeader)
+
+	if _, err := header.AddRouterAlert(pkt); err == nil {
+		t.Error("got header.AddRouterAlert(_) = (_, nil), want non-nil error")
+	}
+}
+
+func TestIPv4OptionsValid(t *testing.T) {
+	tests := []struct {
+		name    string
+		ihl     uint8
+		options []byte
+		want    bool
+	}{
+		{name: "no options", ihl: 5, want: true},
+		{name: "NOPs and end of list", ihl: 6, options: []byte{1, 1, 1, 0}, want: true},
+		{name: "router alert", ihl: 6, options: []byte{148, 4, 0, 0}, want: true},
+		{name: "end of list followed by garbage", ihl: 6, options: []byte{0, 148, 40, 0}, want: true},
+		{name: "option length exceeds remaining bytes", ihl: 6, options: []byte{1, 130, 8, 0}, want: false},
+		{name: "option length exceeds IHL", ihl: 6, options: []byte{130, 8, 0, 0, 0, 0, 0, 0}, want: false},
+		{name: "option length too small", ihl: 6, options: []byte{130, 1, 0, 0}, want: false},
+		{name: "missing option length", ihl: 6, options: []byte{1, 1, 1, 130}, want: false},
+		{name: "IHL exceeds buffer", ihl: 7, options: []byte{1, 1, 1, 1}, want: false},
+		{name: "IHL too small", ihl: 4, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := make([]byte, header.IPv4MinimumSize+len(test.options))
+			b[0] = header.IPv4Version<<4 | test.ihl
+			copy(b[header.IPv4MinimumSize:], test.options)
+			if got := header.IPv4(b).OptionsValid(); got != test.want {
+				t.Errorf("got OptionsValid() = %t, want = %t", got, test.want)
+			}
+		})
+	}
+}
+
+func TestReassembledSize(t *testing.T) {
+	const fragmentSize = 1480
+	payload := make([]byte, 4000)
+
+	var fragments []header.IPv4
+	for offset := 0; offset < len(payload); offset += fragmentSize {
+		end := offset + fragmentSize
+		var flags uint8
+		if end < len(payload) {
+			flags = header.IPv4FlagMoreFragments
+		} else {
+			end = len(payload)
+		}
+		fragments = append(fragments, header.IPv4(makeIPv4Packet(header.IPv4Fields{
+			Flags:          flags,
+			FragmentOffset: uint16(offset),
+			Protocol:       uint8(header.UDPProtocolNumber),
+		}, payload[offset:end])))
+	}
+	if got, want := len(fragments), 3; got != want {
+		t.Fatalf("got len(fragments) = %d, want = %d", got, want)
+	}
+
+	last := fragments[len(fragments)-1]
+	if last.More() {
+		t.Fatal("got last.More() = true, want = false")
+	}
+	if got, want := header.ReassembledSize(last.FragmentOffset(), int(last.PayloadLength())), len(payload); got != want {
+		t.Errorf("got header.ReassembledSize(%d, %d) = %d, want = %d", last.FragmentOffset(), last.PayloadLength(), got, want)
+	}
+}
+
+func TestFragmentExceedsMax(t *testing.T) {
+	// The largest fragment offset is 8191 units of 8 bytes.
+	const maxOffset = 8191 * 8
+
+	tests := []struct {
+		name    string
+		offset  uint16
+		fragLen int
+		want    bool
+	}{
+		{name: "first fragment", offset: 0, fragLen: 1480, want: false},
+		{name: "boundary", offset: maxOffset, fragLen: 65535 - maxOffset, want: false},
+		{name: "one byte over", offset: maxOffset, fragLen: 65535 - maxOffset + 1, want: true},
+		{name: "over max", offset: maxOffset, fragLen: 1480, want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.FragmentExceedsMax(test.offset, test.fragLen); got != test.want {
+				t.Errorf("got header.FragmentExceedsMax(%d, %d) = %t, want = %t", test.offset, test.fragLen, got, test.want)
+			}
+		})
+	}
+}
+
+func TestV4AddressScope(t *testing.T) {
+	tests := []struct {
+		name string
+		addr tcpip.Address
+		want header.IPv4AddressScope
+	}{
+		{name: "loopback", addr: "\x7f\x00\x00\x01", want: header.IPv4LoopbackScope},
+		{name: "loopback upper bound", addr: "\x7f\xff\xff\xff", want: header.IPv4LoopbackScope},
+		{name: "link-local", addr: "\xa9\xfe\x01\x02", want: header.IPv4LinkLocalScope},
+		{name: "private 10/8", addr: "\x0a\x01\x02\x03", want: header.IPv4PrivateScope},
+		{name: "private 172.16/12", addr: "\xac\x1f\xff\xff", want: header.IPv4PrivateScope},
+		{name: "private 192.168/16", addr: "\xc0\xa8\x01\x01", want: header.IPv4PrivateScope},
+		{name: "just outside 172.16/12", addr: "\xac\x20\x00\x00", want: header.IPv4GlobalScope},
+		{name: "global", addr: "\x08\x08\x08\x08", want: header.IPv4GlobalScope},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.V4AddressScope(test.addr); got != test.want {
+				t.Errorf("got header.V4AddressScope(%s) = %d, want = %d", test.addr, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIsV4Documentation(t *testing.T) {
+	tests := []struct {
+		name string
+		addr tcpip.Address
+		want bool
+	}{
+		{name: "TEST-NET-1", addr: "\xc0\x00\x02\x01", want: true},
+		{name: "TEST-NET-2", addr: "\xc6\x33\x64\xff", want: true},
+		{name: "TEST-NET-3", addr: "\xcb\x00\x71\x00", want: true},
+		{name: "outside TEST-NET-1", addr: "\xc0\x00\x03\x01", want: false},
+		{name: "outside TEST-NET-2", addr: "\xc6\x33\x65\x01", want: false},
+		{name: "global", addr: "\x08\x08\x08\x08", want: false},
+		{name: "IPv6 documentation", addr: "\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.IsV4Documentation(test.addr); got != test.want {
+				t.Errorf("got header.IsV4Documentation(%s) = %t, want = %t", test.addr, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIPv4CheckedHeaderLength(t *testing.T) {
+	tests := []struct {
+		name       string
+		ihl        uint8
+		size       int
+		wantHdrLen uint8
+		wantErr    error
+	}{
+		{
+			name:    "IHL 4",
+			ihl:     4,
+			size:    header.IPv4MaximumHeaderSize,
+			wantErr: header.ErrIPv4InvalidHeaderLength,
+		},
+		{
+			name:       "IHL 5",
+			ihl:        5,
+			size:       header.IPv4MinimumSize,
+			wantHdrLen: header.IPv4MinimumSize,
+		},
+		{
+			name:       "IHL 15",
+			ihl:        15,
+			size:       header.IPv4MaximumHeaderSize,
+			wantHdrLen: header.IPv4MaximumHeaderSize,
+		},
+		{
+			name:    "IHL 15 truncated",
+			ihl:     15,
+			size:    header.IPv4MaximumHeaderSize - 1,
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:    "shorter than minimum header",
+			ihl:     5,
+			size:    header.IPv4MinimumSize - 1,
+			wantErr: io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ip := header.IPv4(make([]byte, test.size))
+			ip[0] = header.IPv4Version<<4 | test.ihl
+			hdrLen, err := ip.CheckedHeaderLength()
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("got ip.CheckedHeaderLength() = (_, %v), want = (_, %v)", err, test.wantErr)
+			}
+			if hdrLen != test.wantHdrLen {
+				t.Errorf("got ip.CheckedHeaderLength() = (%d, _), want = (%d, _)", hdrLen, test.wantHdrLen)
+			}
+		})
+	}
+}
+
+func TestIPv4FlagsFragmentOffset(t *testing.T) {
+	tests := []struct {
+		name       string
+		flags      uint8
+		offset     uint16
+		wantFlags  uint8
+		wantOffset uint16
+	}{
+		{
+			name:       "DF with offset",
+			flags:      header.IPv4FlagDontFragment,
+			offset:     1480,
+			wantFlags:  header.IPv4FlagDontFragment,
+			wantOffset: 1480,
+		},
+		{
+			name:       "MF",
+			flags:      header.IPv4FlagMoreFragments,
+			offset:     0,
+			wantFlags:  header.IPv4FlagMoreFragments,
+			wantOffset: 0,
+		},
+		{
+			name:       "MF with maximum offset",
+			flags:      header.IPv4FlagMoreFragments,
+			offset:     0xfff8,
+			wantFlags:  header.IPv4FlagMoreFragments,
+			wantOffset: 0xfff8,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ip := header.IPv4(make([]byte, header.IPv4MinimumSize))
+			ip.SetFlagsFragmentOffset(test.flags, test.offset)
+			flags, offset := ip.FlagsFragmentOffset()
+			if flags != test.wantFlags || offset != test.wantOffset {
+				t.Errorf("got ip.FlagsFragmentOffset() = (%d, %d), want = (%d, %d)", flags, offset, test.wantFlags, test.wantOffset)
+			}
+			if got := ip.Flags(); got != flags {
+				t.Errorf("got ip.Flags() = %d, want = %d", got, flags)
+			}
+			if got := ip.FragmentOffset(); got != offset {
+				t.Errorf("got ip.FragmentOffset() = %d, want = %d", got, offset)
+			}
+		})
+	}
+}
+
+func TestIPv4OptionCopied(t *testing.T) {
+	tests := []struct {
+		name    string
+		optType header.IPv4OptionType
+		want    bool
+	}{
+		{
+			name:    "Loose Source Route",
+			optType: 131,
+			want:    true,
+		},
+		{
+			name:    "Strict Source Route",
+			optType: 137,
+			want:    true,
+		},
+		{
+			name:    "Router Alert",
+			optType: header.IPv4OptionRouterAlertType,
+			want:    true,
+		},
+		{
+			name:    "Record Route",
+			optType: header.IPv4OptionRecordRouteType,
+			want:    false,
+		},
+		{
+			name:    "Timestamp",
+			optType: header.IPv4OptionTimestampType,
+			want:    false,
+		},
+		{
+			name:    "NOP",
+			optType: header.IPv4OptionNOPType,
+			want:    false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.IPv4OptionCopied(test.optType); got != test.want {
+				t.Errorf("got header.IPv4OptionCopied(%d) = %t, want = %t", test.optType, got, test.want)
+			}
+		})
+	}
+}
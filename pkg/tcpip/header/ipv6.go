@@ -174,6 +174,13 @@ var IPv4MappedIPv6Subnet = tcpip.AddressWithPrefix{
 	PrefixLen: 96,
 }.Subnet()
 
+// ipv6DocumentationSubnet is the IPv6 address prefix reserved for
+// documentation, as defined by RFC 3849 section 4.
+var ipv6DocumentationSubnet = tcpip.AddressWithPrefix{
+	Address:   "\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
+	PrefixLen: 32,
+}.Subnet()
+
 // IPv6LinkLocalPrefix is the prefix for IPv6 link-local addresses, as defined
 // by RFC 4291 section 2.5.6.
 //
@@ -238,6 +245,18 @@ func (b IPv6) SetTOS(t uint8, l uint32) {
 	binary.BigEndian.PutUint32(b[versTCFL:], vtf)
 }
 
+// TrafficClass returns the "traffic class" field of the ipv6 header.
+func (b IPv6) TrafficClass() uint8 {
+	return uint8(binary.BigEndian.Uint16(b[versTCFL:]) >> 4)
+}
+
+// SetTrafficClass sets the "traffic class" field of the ipv6 header, leaving
+// the "version" and "flow label" fields untouched.
+func (b IPv6) SetTrafficClass(tc uint8) {
+	v := binary.BigEndian.Uint16(b[versTCFL:])
+	binary.BigEndian.PutUint16(b[versTCFL:], v&0xf00f|uint16(tc)<<4)
+}
+
 // SetPayloadLength sets the "payload length" field of the ipv6 header.
 func (b IPv6) SetPayloadLength(payloadLength uint16) {
 	binary.BigEndian.PutUint16(b[IPv6PayloadLenOffset:], payloadLength)
@@ -299,6 +318,36 @@ func (b IPv6) IsValid(pktSize int) bool {
 	return true
 }
 
+// IPv6MaxTransportPayload returns the largest transport payload that fits in
+// an IPv6 packet of at most mtu bytes carrying extHdrsLen bytes of extension
+// headers and a transport header of transportHdrLen bytes.
+//
+// Returns 0 if the headers alone do not fit in mtu.
+func IPv6MaxTransportPayload(mtu int, extHdrsLen int, transportHdrLen int) int {
+	maxPayload := mtu - IPv6MinimumSize
+	if maxPayload > IPv6MaximumPayloadSize {
+		maxPayload = IPv6MaximumPayloadSize
+	}
+	if maxPayload -= extHdrsLen + transportHdrLen; maxPayload < 0 {
+		return 0
+	}
+	return maxPayload
+}
+
+// ClampV6MTU returns the path MTU to use after receiving an ICMPv6 Packet Too
+// Big message reporting an MTU of reported, which is never below
+// IPv6MinimumMTU as per RFC 8201 section 4.
+//
+// useFragmentHeader is true iff reported is below IPv6MinimumMTU, in which case
+// RFC 2460 section 5 required packets to be sent with a Fragment header. Note
+// that RFC 8200 and RFC 8021 deprecated this behaviour (atomic fragments).
+func ClampV6MTU(reported uint32) (mtu uint32, useFragmentHeader bool) {
+	if reported < IPv6MinimumMTU {
+		return IPv6MinimumMTU, true
+	}
+	return reported, false
+}
+
 // IsV4MappedAddress determines if the provided address is an IPv4 mapped
 // address by checking if its prefix is 0:0:0:0:0:ffff::/96.
 func IsV4MappedAddress(addr tcpip.Address) bool {
@@ -309,6 +358,39 @@ func IsV4MappedAddress(addr tcpip.Address) bool {
 	return IPv4MappedIPv6Subnet.Contains(addr)
 }
 
+// V4MappedToV4 returns the IPv4 address embedded in the IPv4 mapped address
+// addr, or false if addr is not an IPv4 mapped address.
+func V4MappedToV4(addr tcpip.Address) (tcpip.Address, bool) {
+	if !IsV4MappedAddress(addr) {
+		return "", false
+	}
+	return addr[IPv6AddressSize-IPv4AddressSize:], true
+}
+
+// IsV4CompatibleAddress determines if the provided address is a deprecated
+// IPv4-compatible IPv6 address (::a.b.c.d), as per RFC 4291 section 2.5.5.1.
+//
+// The unspecified (::) and loopback (::1) addresses share the same prefix but
+// are not considered IPv4-compatible.
+func IsV4CompatibleAddress(addr tcpip.Address) bool {
+	if len(addr) != IPv6AddressSize {
+		return false
+	}
+	for _, b := range addr[:IPv6AddressSize-IPv4AddressSize] {
+		if b != 0 {
+			return false
+		}
+	}
+	return addr != IPv6Any && addr != IPv6Loopback
+}
+
+// IsV6Documentation determines if the provided address is an IPv6
+// documentation address (2001:db8::/32), which should never appear on the
+// wire.
+func IsV6Documentation(addr tcpip.Address) bool {
+	return len(addr) == IPv6AddressSize && ipv6DocumentationSubnet.Contains(addr)
+}
+
 // IsV6MulticastAddress determines if the provided address is an IPv6
 // multicast address (anything starting with FF).
 func IsV6MulticastAddress(addr tcpip.Address) bool {
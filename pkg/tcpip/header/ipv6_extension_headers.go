@@ -47,6 +47,10 @@ const (
 	// Destination Options extension header, as per RFC 8200 section 4.6.
 	IPv6DestinationOptionsExtHdrIdentifier IPv6ExtensionHeaderIdentifier = 60
 
+	// IPv6MobilityExtHdrIdentifier is the header identifier of a Mobility
+	// extension header, as per RFC 6275 section 6.1.
+	IPv6MobilityExtHdrIdentifier IPv6ExtensionHeaderIdentifier = 135
+
 	// IPv6NoNextHeaderIdentifier is the header identifier used to signify the end
 	// of an IPv6 payload, as per RFC 8200 section 4.7.
 	IPv6NoNextHeaderIdentifier IPv6ExtensionHeaderIdentifier = 59
@@ -66,10 +70,23 @@ const (
 	// from the action value for an unrecognized option identifier.
 	ipv6UnknownExtHdrOptionActionShift = 6
 
+	// ipv6RoutingExtHdrTypeIdx is the index to the Routing Type field within
+	// an IPv6RoutingExtHdr.
+	ipv6RoutingExtHdrTypeIdx = 0
+
 	// ipv6RoutingExtHdrSegmentsLeftIdx is the index to the Segments Left field
 	// within an IPv6RoutingExtHdr.
 	ipv6RoutingExtHdrSegmentsLeftIdx = 1
 
+	// ipv6RoutingExtHdrType0AddressesIdx is the index to the first address
+	// within a type 0 IPv6RoutingExtHdr, following the Reserved field, as per
+	// RFC 2460 section 4.4.
+	ipv6RoutingExtHdrType0AddressesIdx = 6
+
+	// IPv6RoutingType0 is the deprecated type 0 Routing header, as per RFC
+	// 2460 section 4.4 and RFC 5095.
+	IPv6RoutingType0 = 0
+
 	// IPv6FragmentExtHdrLength is the length of an IPv6 extension header, in
 	// bytes.
 	IPv6FragmentExtHdrLength = 8
@@ -256,6 +273,18 @@ const (
 	// Alert Hop by Hop option as defined in RFC 2711 section 2.1.
 	ipv6RouterAlertHopByHopOptionIdentifier IPv6ExtHdrOptionIdentifier = 5
 
+	// ipv6JumboPayloadHopByHopOptionIdentifier is the identifier for the Jumbo
+	// Payload Hop by Hop option as defined in RFC 2675 section 2.
+	ipv6JumboPayloadHopByHopOptionIdentifier IPv6ExtHdrOptionIdentifier = 0xc2
+
+	// ipv6JumboPayloadLength is the length of the Jumbo Payload option's
+	// data, as defined in RFC 2675 section 2.
+	ipv6JumboPayloadLength = 4
+
+	// ipv6HomeAddressDestinationOptionIdentifier is the identifier for the
+	// Home Address Destination option as defined in RFC 6275 section 6.3.
+	ipv6HomeAddressDestinationOptionIdentifier IPv6ExtHdrOptionIdentifier = 201
+
 	// ipv6ExtHdrOptionTypeOffset is the option type offset in an extension header
 	// option as defined in RFC 8200 section 4.2.
 	ipv6ExtHdrOptionTypeOffset = 0
@@ -414,6 +443,180 @@ func (b IPv6RoutingExtHdr) SegmentsLeft() uint8 {
 	return b[ipv6RoutingExtHdrSegmentsLeftIdx]
 }
 
+// RoutingType returns the Routing Type field.
+func (b IPv6RoutingExtHdr) RoutingType() uint8 {
+	return b[ipv6RoutingExtHdrTypeIdx]
+}
+
+// ChecksumDestV6 returns the destination address that must be used in the
+// upper-layer pseudo-header checksum of the IPv6 packet b.
+//
+// As per RFC 8200 section 8.1, if the packet contains a Routing header, the
+// final destination must be used. While segments are left to visit, it is the
+// last address of the Routing header rather than the Destination Address
+// field. Only type 0 Routing headers carry a list of addresses this way; for
+// any other packet, the Destination Address field is returned.
+func ChecksumDestV6(b IPv6) tcpip.Address {
+	dst := b.DestinationAddress()
+	it := MakeIPv6PayloadIterator(IPv6ExtensionHeaderIdentifier(b.NextHeader()), buffer.View(b.Payload()).ToVectorisedView())
+	for {
+		h, done, err := it.Next()
+		if err != nil || done {
+			return dst
+		}
+
+		switch h := h.(type) {
+		case IPv6RoutingExtHdr:
+			if h.RoutingType() != IPv6RoutingType0 || h.SegmentsLeft() == 0 {
+				continue
+			}
+			if addrs := h[ipv6RoutingExtHdrType0AddressesIdx:]; len(addrs) >= IPv6AddressSize {
+				return tcpip.Address(addrs[len(addrs)-IPv6AddressSize:])
+			}
+		case IPv6RawPayloadHeader:
+			return dst
+		}
+	}
+}
+
+// HomeAddressOption returns the home address held in the Home Address option
+// of a Destination Options extension header of the IPv6 packet ipv6, as per
+// RFC 6275 section 6.3. Mobile nodes away from home use it to carry their home
+// address while the Source Address field holds their care-of address.
+//
+// Like ChecksumDestV6 substitutes the final destination for the Destination
+// Address field, the home address must be substituted for the Source Address
+// field in the upper-layer pseudo-header checksum of a packet carrying this
+// option, as the checksum is computed by the mobile node using its home
+// address.
+//
+// The returned bool is false if ipv6 is not a valid IPv6 packet, if it does
+// not carry a Home Address option or if the extension headers preceding the
+// option are malformed.
+func HomeAddressOption(ipv6 IPv6) (tcpip.Address, bool) {
+	if !ipv6.IsValid(len(ipv6)) {
+		return "", false
+	}
+	it := MakeIPv6PayloadIterator(IPv6ExtensionHeaderIdentifier(ipv6.NextHeader()), buffer.View(ipv6.Payload()).ToVectorisedView())
+	for {
+		h, done, err := it.Next()
+		if err != nil || done {
+			return "", false
+		}
+
+		switch h := h.(type) {
+		case IPv6DestinationOptionsExtHdr:
+			optsIt := h.Iter()
+			for {
+				opt, done, err := optsIt.Next()
+				if err != nil {
+					return "", false
+				}
+				if done {
+					break
+				}
+				unknown, ok := opt.(*IPv6UnknownExtHdrOption)
+				if !ok || unknown.Identifier != ipv6HomeAddressDestinationOptionIdentifier {
+					continue
+				}
+				if len(unknown.Data) != IPv6AddressSize {
+					return "", false
+				}
+				return tcpip.Address(unknown.Data), true
+			}
+		case IPv6RawPayloadHeader:
+			return "", false
+		}
+	}
+}
+
+// V6RouterAlertValue returns the value of the Router Alert option held in the
+// Hop-by-Hop Options extension header of the IPv6 packet ipv6, as per RFC
+// 2711. A value of IPv6RouterAlertMLD indicates an MLD message.
+//
+// As per RFC 8200 section 4.1, the Hop-by-Hop Options header may only
+// immediately follow the IPv6 header. The returned bool is false if ipv6 is
+// not a valid IPv6 packet, if it does not start with a Hop-by-Hop Options
+// header holding a Router Alert option or if that header is malformed.
+func V6RouterAlertValue(ipv6 IPv6) (IPv6RouterAlertValue, bool) {
+	if !ipv6.IsValid(len(ipv6)) {
+		return 0, false
+	}
+	it, ok := ipv6HopByHopOptions(ipv6.NextHeader(), ipv6.Payload())
+	if !ok {
+		return 0, false
+	}
+	for {
+		opt, done, err := it.Next()
+		if err != nil || done {
+			return 0, false
+		}
+		if ra, ok := opt.(*IPv6RouterAlertOption); ok {
+			return ra.Value, true
+		}
+	}
+}
+
+// IsJumbogram returns true and the length of the payload carried in the Jumbo
+// Payload option of the Hop-by-Hop Options header iff b is a jumbogram, as
+// per RFC 2675.
+//
+// As per RFC 2675 section 3, the Payload Length field of a jumbogram must be
+// zero and the Jumbo Payload Length must be greater than 65,535. A packet
+// carrying a Jumbo Payload option that violates either rule, or with a
+// malformed Hop-by-Hop Options header, is not considered a jumbogram.
+func (b IPv6) IsJumbogram() (bool, uint32) {
+	if len(b) < IPv6MinimumSize {
+		return false, 0
+	}
+	// The Payload Length field of a jumbogram is zero so the payload is not
+	// delimited by it.
+	it, ok := ipv6HopByHopOptions(b.NextHeader(), b[IPv6MinimumSize:])
+	if !ok {
+		return false, 0
+	}
+	for {
+		opt, done, err := it.Next()
+		if err != nil || done {
+			return false, 0
+		}
+		unknown, ok := opt.(*IPv6UnknownExtHdrOption)
+		if !ok || unknown.Identifier != ipv6JumboPayloadHopByHopOptionIdentifier {
+			continue
+		}
+		if len(unknown.Data) != ipv6JumboPayloadLength {
+			return false, 0
+		}
+		length := binary.BigEndian.Uint32(unknown.Data)
+		if b.PayloadLength() != 0 || length <= math.MaxUint16 {
+			return false, 0
+		}
+		return true, length
+	}
+}
+
+// ipv6HopByHopOptions returns an iterator over the options of the Hop-by-Hop
+// Options header held at the start of payload, the bytes following an IPv6
+// header whose Next Header field is nextHdr.
+//
+// The returned bool is false if nextHdr does not identify a Hop-by-Hop Options
+// header or if the header is malformed.
+func ipv6HopByHopOptions(nextHdr uint8, payload []byte) (IPv6OptionsExtHdrOptionsIterator, bool) {
+	if IPv6ExtensionHeaderIdentifier(nextHdr) != IPv6HopByHopOptionsExtHdrIdentifier {
+		return IPv6OptionsExtHdrOptionsIterator{}, false
+	}
+	it := MakeIPv6PayloadIterator(IPv6HopByHopOptionsExtHdrIdentifier, buffer.View(payload).ToVectorisedView())
+	h, done, err := it.Next()
+	if err != nil || done {
+		return IPv6OptionsExtHdrOptionsIterator{}, false
+	}
+	hbh, ok := h.(IPv6HopByHopOptionsExtHdr)
+	if !ok {
+		return IPv6OptionsExtHdrOptionsIterator{}, false
+	}
+	return hbh.Iter(), true
+}
+
 // IPv6FragmentExtHdr is a buffer holding the Fragment extension header specific
 // data as outlined in RFC 8200 section 4.5.
 //
@@ -609,6 +812,23 @@ func (i *IPv6PayloadIterator) Next() (IPv6PayloadHeader, bool, error) {
 
 		i.nextHdrIdentifier = nextHdrIdentifier
 		return IPv6DestinationOptionsExtHdr{ipv6OptionsExtHdr: bytes}, false, nil
+	case IPv6MobilityExtHdrIdentifier:
+		// The Payload Proto field is not followed; the Mobility header is the
+		// last header in the chain as per RFC 6275 section 6.1.1.
+		payloadProto, bytes, err := i.nextHeaderData(false /* fragmentHdr */, nil)
+		if err != nil {
+			return nil, true, err
+		}
+
+		// Return the whole Mobility header, including the Payload Proto and
+		// Header Len fields consumed by nextHeaderData.
+		mh := make(IPv6Mobility, mobilityType+len(bytes))
+		mh[mobilityPayloadProto] = uint8(payloadProto)
+		mh[mobilityHeaderLen] = uint8(len(mh)/ipv6ExtHdrLenBytesPerUnit - 1)
+		copy(mh[mobilityType:], bytes)
+
+		i.nextHdrIdentifier = IPv6NoNextHeaderIdentifier
+		return mh, false, nil
 	case IPv6NoNextHeaderIdentifier:
 		// This indicates the end of the IPv6 payload.
 		return nil, true, nil
@@ -683,6 +903,100 @@ func (i *IPv6PayloadIterator) nextHeaderData(fragmentHdr bool, bytes []byte) (IP
 	return IPv6ExtensionHeaderIdentifier(nextHdrIdentifier), bytes, nil
 }
 
+// isIPv6ExtHdrIdentifier returns true iff id identifies an IPv6 extension
+// header that can be skipped by walking its Length field.
+func isIPv6ExtHdrIdentifier(id IPv6ExtensionHeaderIdentifier) bool {
+	switch id {
+	case IPv6HopByHopOptionsExtHdrIdentifier,
+		IPv6RoutingExtHdrIdentifier,
+		IPv6FragmentExtHdrIdentifier,
+		IPv6DestinationOptionsExtHdrIdentifier:
+		return true
+	default:
+		return false
+	}
+}
+
+// ipv6HasFragmentExtHdr returns true iff the extension headers of the IPv6
+// packet b include a Fragment extension header.
+func ipv6HasFragmentExtHdr(b IPv6) bool {
+	payload := b.Payload()
+	id := IPv6ExtensionHeaderIdentifier(b.NextHeader())
+	for isIPv6ExtHdrIdentifier(id) {
+		if id == IPv6FragmentExtHdrIdentifier {
+			return true
+		}
+		nextID, length, err := ipv6ExtHdrLength(id, payload)
+		if err != nil {
+			return false
+		}
+		payload = payload[length:]
+		id = nextID
+	}
+	return false
+}
+
+// ipv6ExtHdrLength returns the Next Header field and the total length, in
+// bytes, of the extension header identified by id held at the start of b.
+func ipv6ExtHdrLength(id IPv6ExtensionHeaderIdentifier, b []byte) (IPv6ExtensionHeaderIdentifier, int, error) {
+	if len(b) < ipv6ExtHdrLenBytesPerUnit {
+		return 0, 0, fmt.Errorf("got %d bytes for extension header with id = %d, want at least %d: %w", len(b), id, ipv6ExtHdrLenBytesPerUnit, io.ErrUnexpectedEOF)
+	}
+
+	length := ipv6ExtHdrLenBytesPerUnit
+	// The Length field is Reserved for the Fragment extension header.
+	if id != IPv6FragmentExtHdrIdentifier {
+		length += int(b[ipv6HopByHopExtHdrLengthOffset]) * ipv6ExtHdrLenBytesPerUnit
+	}
+	if len(b) < length {
+		return 0, 0, fmt.Errorf("got %d bytes for extension header with id = %d, want %d: %w", len(b), id, length, io.ErrUnexpectedEOF)
+	}
+	return IPv6ExtensionHeaderIdentifier(b[ipv6HopByHopExtHdrNextHeaderOffset]), length, nil
+}
+
+// CountExtensionHeaders returns the number of extension headers that precede
+// the upper layer data in the IPv6 packet held in ipv6.
+//
+// The walk stops at the first header that is not an extension header, at a No
+// Next Header identifier, after the Mobility header as it is always the last
+// header in the chain, or after the Fragment extension header of a non-first
+// fragment as the data following it does not hold any headers.
+//
+// Every extension header is at least 8 bytes long so the walk is bounded by the
+// size of the payload; a crafted chain cannot make it loop forever. Callers
+// are expected to compare the returned count against their own policy limit.
+func CountExtensionHeaders(ipv6 IPv6) (int, error) {
+	if !ipv6.IsValid(len(ipv6)) {
+		return 0, fmt.Errorf("got invalid IPv6 packet of %d bytes", len(ipv6))
+	}
+
+	payload := ipv6.Payload()
+	id := IPv6ExtensionHeaderIdentifier(ipv6.NextHeader())
+	maxHdrs := len(payload) / ipv6ExtHdrLenBytesPerUnit
+	count := 0
+	for ; count < maxHdrs && isIPv6ExtHdrIdentifier(id); count++ {
+		nextID, length, err := ipv6ExtHdrLength(id, payload)
+		if err != nil {
+			return count, err
+		}
+		if id == IPv6FragmentExtHdrIdentifier && IPv6Fragment(payload).FragmentOffset() != 0 {
+			return count + 1, nil
+		}
+		payload = payload[length:]
+		id = nextID
+	}
+	if id == IPv6MobilityExtHdrIdentifier {
+		if _, _, err := ipv6ExtHdrLength(id, payload); err != nil {
+			return count, err
+		}
+		return count + 1, nil
+	}
+	if isIPv6ExtHdrIdentifier(id) {
+		return count, fmt.Errorf("got %d bytes for extension header with id = %d, want at least %d: %w", len(payload), id, ipv6ExtHdrLenBytesPerUnit, io.ErrUnexpectedEOF)
+	}
+	return count, nil
+}
+
 // IPv6SerializableExtHdr provides serialization for IPv6 extension
 // headers.
 type IPv6SerializableExtHdr interface {
@@ -702,6 +702,31 @@ func TestIPv6ExtHdrIter(t *testing.T) {
 		// With a non-atomic fragment that is not the first fragment, the payload
 		// after the fragment will not be parsed because the payload is expected to
 		// only hold upper layer data.
+		{
+			name:         "destopts - mobility (binding update)",
+			firstNextHdr: IPv6DestinationOptionsExtHdrIdentifier,
+			payload: makeVectorisedViewFromByteBuffers([]byte{
+				// Destination Options extension header.
+				uint8(IPv6MobilityExtHdrIdentifier), 0, 1, 4, 1, 2, 3, 4,
+
+				// Mobility header.
+				//
+				// MH Type = Binding Update, Checksum = 0xabcd, Sequence # = 0x1234,
+				// A and H flags set, Lifetime = 16, PadN option.
+				//
+				// The Mobility header is always the last header so the Payload
+				// Proto field should be ignored.
+				uint8(IPv6DestinationOptionsExtHdrIdentifier), 1, 5, 0, 0xab, 0xcd, 0x12, 0x34,
+				0xc0, 0, 0, 0x10, 1, 2, 0, 0,
+			}),
+			expected: []IPv6PayloadHeader{
+				IPv6DestinationOptionsExtHdr{ipv6OptionsExtHdr: []byte{1, 4, 1, 2, 3, 4}},
+				IPv6Mobility([]byte{
+					uint8(IPv6DestinationOptionsExtHdrIdentifier), 1, 5, 0, 0xab, 0xcd, 0x12, 0x34,
+					0xc0, 0, 0, 0x10, 1, 2, 0, 0,
+				}),
+			},
+		},
 		{
 			name:         "hopbyhop - fragment (not first) - routing - upper",
 			firstNextHdr: IPv6HopByHopOptionsExtHdrIdentifier,
@@ -1344,3 +1369,183 @@ func TestIPv6ExtHdrSerializer(t *testing.T) {
 		})
 	}
 }
+
+func TestCountExtensionHeaders(t *testing.T) {
+	makeIPv6 := func(nextHdr IPv6ExtensionHeaderIdentifier, payload []byte) IPv6 {
+		b := IPv6(make([]byte, IPv6MinimumSize+len(payload)))
+		b.Encode(&IPv6Fields{
+			PayloadLength:     uint16(len(payload)),
+			TransportProtocol: tcpip.TransportProtocolNumber(nextHdr),
+			HopLimit:          64,
+			SrcAddr:           IPv6Loopback,
+			DstAddr:           IPv6Loopback,
+		})
+		copy(b[IPv6MinimumSize:], payload)
+		return b
+	}
+
+	// deepChain is a chain of the maximum number of 8 byte Destination Options
+	// extension headers that fit in an IPv6 payload.
+	const deepChainLen = IPv6MaximumPayloadSize / ipv6ExtHdrLenBytesPerUnit
+	deepChain := make([]byte, deepChainLen*ipv6ExtHdrLenBytesPerUnit)
+	for i := 0; i < deepChainLen; i++ {
+		hdr := deepChain[i*ipv6ExtHdrLenBytesPerUnit:]
+		hdr[0] = uint8(IPv6DestinationOptionsExtHdrIdentifier)
+		// PadN option filling the rest of the header.
+		hdr[2], hdr[3] = 1, 4
+	}
+	deepChain[(deepChainLen-1)*ipv6ExtHdrLenBytesPerUnit] = uint8(IPv6NoNextHeaderIdentifier)
+
+	tests := []struct {
+		name      string
+		pkt       IPv6
+		wantCount int
+		wantErr   error
+	}{
+		{
+			name:      "NoExtHdrs",
+			pkt:       makeIPv6(IPv6ExtensionHeaderIdentifier(UDPProtocolNumber), []byte{1, 2, 3, 4, 5, 6, 7, 8}),
+			wantCount: 0,
+		},
+		{
+			name: "HopByHopAndRouting",
+			pkt: makeIPv6(IPv6HopByHopOptionsExtHdrIdentifier, []byte{
+				// Hop By Hop Options extension header.
+				uint8(IPv6RoutingExtHdrIdentifier), 0, 1, 4, 0, 0, 0, 0,
+				// Routing extension header.
+				uint8(UDPProtocolNumber), 0, 0, 0, 0, 0, 0, 0,
+				// UDP header.
+				0, 1, 0, 2, 0, 8, 0, 0,
+			}),
+			wantCount: 2,
+		},
+		{
+			name: "NonFirstFragment",
+			pkt: makeIPv6(IPv6FragmentExtHdrIdentifier, []byte{
+				// Fragment extension header with a Next Header value that would
+				// otherwise be parsed as an extension header.
+				uint8(IPv6DestinationOptionsExtHdrIdentifier), 0, 0, 8, 0, 0, 0, 1,
+				// Fragment data.
+				uint8(IPv6DestinationOptionsExtHdrIdentifier), 255, 0, 0, 0, 0, 0, 0,
+			}),
+			wantCount: 1,
+		},
+		{
+			name: "DestOptsAndMobility",
+			pkt: makeIPv6(IPv6DestinationOptionsExtHdrIdentifier, []byte{
+				// Destination Options extension header.
+				uint8(IPv6MobilityExtHdrIdentifier), 0, 1, 4, 0, 0, 0, 0,
+				// Mobility header with a Payload Proto value that would otherwise be
+				// parsed as an extension header.
+				uint8(IPv6DestinationOptionsExtHdrIdentifier), 0, 5, 0, 0, 0, 0, 0,
+			}),
+			wantCount: 2,
+		},
+		{
+			name:      "DeepChain",
+			pkt:       makeIPv6(IPv6DestinationOptionsExtHdrIdentifier, deepChain),
+			wantCount: deepChainLen,
+		},
+		{
+			name: "TruncatedChain",
+			pkt: makeIPv6(IPv6DestinationOptionsExtHdrIdentifier, []byte{
+				// Destination Options extension header claiming 16 more bytes.
+				uint8(UDPProtocolNumber), 2, 1, 4, 0, 0, 0, 0,
+			}),
+			wantCount: 0,
+			wantErr:   io.ErrUnexpectedEOF,
+		},
+		{
+			name: "ChainWithoutUpperLayer",
+			pkt: makeIPv6(IPv6DestinationOptionsExtHdrIdentifier, []byte{
+				uint8(IPv6DestinationOptionsExtHdrIdentifier), 0, 1, 4, 0, 0, 0, 0,
+			}),
+			wantCount: 1,
+			wantErr:   io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			count, err := CountExtensionHeaders(test.pkt)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("got CountExtensionHeaders(_) = (_, %v), want = (_, %v)", err, test.wantErr)
+			}
+			if count != test.wantCount {
+				t.Errorf("got CountExtensionHeaders(_) = (%d, _), want = (%d, _)", count, test.wantCount)
+			}
+		})
+	}
+}
+
+func TestChecksumDestV6(t *testing.T) {
+	const (
+		src       = tcpip.Address("\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+		dst       = tcpip.Address("\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02")
+		waypoint  = tcpip.Address("\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x03")
+		finalDest = tcpip.Address("\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x04")
+	)
+
+	routingHdr := func(routingType, segmentsLeft uint8) []byte {
+		// A Routing header holding two addresses has a Hdr Ext Len of 4.
+		b := []byte{uint8(UDPProtocolNumber), 4, routingType, segmentsLeft, 0, 0, 0, 0}
+		b = append(b, waypoint...)
+		return append(b, finalDest...)
+	}
+
+	tests := []struct {
+		name    string
+		nextHdr uint8
+		extHdrs []byte
+		wantDst tcpip.Address
+	}{
+		{
+			name:    "no extension headers",
+			nextHdr: uint8(UDPProtocolNumber),
+			wantDst: dst,
+		},
+		{
+			name:    "type 0 with segments left",
+			nextHdr: uint8(IPv6RoutingExtHdrIdentifier),
+			extHdrs: routingHdr(IPv6RoutingType0, 2),
+			wantDst: finalDest,
+		},
+		{
+			name:    "type 0 with no segments left",
+			nextHdr: uint8(IPv6RoutingExtHdrIdentifier),
+			extHdrs: routingHdr(IPv6RoutingType0, 0),
+			wantDst: dst,
+		},
+		{
+			name:    "type 0 after hop-by-hop options",
+			nextHdr: uint8(IPv6HopByHopOptionsExtHdrIdentifier),
+			extHdrs: append([]byte{uint8(IPv6RoutingExtHdrIdentifier), 0, 1, 4, 0, 0, 0, 0}, routingHdr(IPv6RoutingType0, 1)...),
+			wantDst: finalDest,
+		},
+		{
+			name:    "unknown routing type",
+			nextHdr: uint8(IPv6RoutingExtHdrIdentifier),
+			extHdrs: routingHdr(253, 2),
+			wantDst: dst,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			payload := append(test.extHdrs, make([]byte, UDPMinimumSize)...)
+			b := IPv6(make([]byte, IPv6MinimumSize+len(payload)))
+			b.Encode(&IPv6Fields{
+				PayloadLength:     uint16(len(payload)),
+				TransportProtocol: tcpip.TransportProtocolNumber(test.nextHdr),
+				HopLimit:          64,
+				SrcAddr:           src,
+				DstAddr:           dst,
+			})
+			copy(b[IPv6MinimumSize:], payload)
+
+			if got := ChecksumDestV6(b); got != test.wantDst {
+				t.Errorf("got ChecksumDestV6(_) = %s, want = %s", got, test.wantDst)
+			}
+		})
+	}
+}
@@ -16,15 +16,21 @@ package header
 
 import (
 	"encoding/binary"
+	"fmt"
 
 	"gvisor.dev/gvisor/pkg/tcpip"
 )
 
 const (
 	nextHdrFrag = 0
+	reservedV6  = 1
 	fragOff     = 2
 	more        = 3
 	idV6        = 4
+
+	// fragResMask is the mask of the 2-bit Res field, which sits between the
+	// fragment offset and the M flag.
+	fragResMask = 0x6
 )
 
 var _ IPv6SerializableExtHdr = (*IPv6SerializableFragmentExtHdr)(nil)
@@ -65,6 +71,64 @@ func (h *IPv6SerializableFragmentExtHdr) serializeInto(nextHeader uint8, b []byt
 	return IPv6FragmentHeaderSize
 }
 
+// BuildFragmentHeaders returns the serialized Fragment extension headers for
+// each fragment of a single datagram, as per RFC 8200 section 4.5.
+//
+// offsets holds the byte offset of each fragment's payload within the
+// original fragmentable part and mores holds the value of each fragment's
+// M flag. All fragments share nextHdr and id.
+//
+// BuildFragmentHeaders panics if offsets and mores have different lengths or
+// if an offset is not a multiple of 8 bytes or does not fit in the Fragment
+// Offset field.
+func BuildFragmentHeaders(nextHdr uint8, id uint32, offsets []int, mores []bool) [][]byte {
+	if len(offsets) != len(mores) {
+		panic(fmt.Sprintf("got len(offsets) = %d, want = len(mores) = %d", len(offsets), len(mores)))
+	}
+
+	hdrs := make([][]byte, 0, len(offsets))
+	for i, offset := range offsets {
+		if offset < 0 || offset%IPv6FragmentExtHdrFragmentOffsetBytesPerUnit != 0 {
+			panic(fmt.Sprintf("fragment offset %d is not a non-negative multiple of %d", offset, IPv6FragmentExtHdrFragmentOffsetBytesPerUnit))
+		}
+		units := offset / IPv6FragmentExtHdrFragmentOffsetBytesPerUnit
+		if units > 0xffff>>ipv6FragmentExtHdrFragmentOffsetShift {
+			panic(fmt.Sprintf("fragment offset %d does not fit in the Fragment Offset field", offset))
+		}
+
+		fragHdr := IPv6SerializableFragmentExtHdr{
+			FragmentOffset: uint16(units),
+			M:              mores[i],
+			Identification: id,
+		}
+		b := make([]byte, IPv6FragmentHeaderSize)
+		fragHdr.serializeInto(nextHdr, b)
+		hdrs = append(hdrs, b)
+	}
+	return hdrs
+}
+
+// FragmentCount returns the number of fragments an IPv6 datagram of totalLen
+// bytes is split into to fit in mtu, where the first unfragmentableLen bytes
+// of the datagram (the IPv6 header and the extension headers that precede the
+// Fragment header) are repeated in every fragment.
+//
+// As per RFC 8200 section 4.5, every fragment but the last carries a multiple
+// of 8 bytes of the fragmentable part, after a Fragment header. A datagram
+// that fits in mtu is not fragmented and counts as 1. FragmentCount returns 0
+// if mtu leaves no room for at least 8 bytes of fragmentable data.
+func FragmentCount(totalLen, mtu, unfragmentableLen int) int {
+	if totalLen <= mtu {
+		return 1
+	}
+	fragmentPayloadLen := (mtu - unfragmentableLen - IPv6FragmentHeaderSize) &^ 7
+	if fragmentPayloadLen < IPv6FragmentExtHdrFragmentOffsetBytesPerUnit {
+		return 0
+	}
+	fragmentableLen := totalLen - unfragmentableLen
+	return (fragmentableLen + fragmentPayloadLen - 1) / fragmentPayloadLen
+}
+
 // IPv6Fragment represents an ipv6 fragment header stored in a byte array.
 // Most of the methods of IPv6Fragment access to the underlying slice without
 // checking the boundaries and could panic because of 'index out of range'.
@@ -85,6 +149,29 @@ func (b IPv6Fragment) IsValid() bool {
 	return len(b) >= IPv6FragmentHeaderSize
 }
 
+// IsStrictlyValid performs the validation done by IsValid and additionally
+// requires the Reserved and Res fields to be zero and, for fragments other
+// than the last one, the fragment data to be a non-empty multiple of 8 bytes,
+// as per RFC 8200 section 4.5.
+//
+// b must hold the Fragment header followed by exactly the fragment data.
+//
+// Note that RFC 8200 requires the reserved fields to be ignored on reception;
+// this is meant for validators that check what the sender transmitted.
+func (b IPv6Fragment) IsStrictlyValid() bool {
+	if !b.IsValid() {
+		return false
+	}
+	if b[reservedV6] != 0 || b[more]&fragResMask != 0 {
+		return false
+	}
+	if b.More() {
+		l := len(b.Payload())
+		return l != 0 && l%IPv6FragmentExtHdrFragmentOffsetBytesPerUnit == 0
+	}
+	return true
+}
+
 // NextHeader returns the value of the "next header" field of the ipv6 fragment.
 func (b IPv6Fragment) NextHeader() uint8 {
 	return b[nextHdrFrag]
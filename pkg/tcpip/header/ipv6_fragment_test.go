@@ -0,0 +1,196 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestBuildFragmentHeaders(t *testing.T) {
+	const (
+		nextHdr = uint8(header.UDPProtocolNumber)
+		id      = 0x01020304
+	)
+	offsets := []int{0, 1232, 2464}
+	mores := []bool{true, true, false}
+
+	hdrs := header.BuildFragmentHeaders(nextHdr, id, offsets, mores)
+	if got, want := len(hdrs), len(offsets); got != want {
+		t.Fatalf("got len(hdrs) = %d, want = %d", got, want)
+	}
+
+	want := [][]byte{
+		{nextHdr, 0, 0x00, 0x01, 1, 2, 3, 4},
+		{nextHdr, 0, 0x04, 0xd1, 1, 2, 3, 4},
+		{nextHdr, 0, 0x09, 0xa0, 1, 2, 3, 4},
+	}
+	if diff := cmp.Diff(want, hdrs); diff != "" {
+		t.Errorf("fragment headers mismatch (-want +got):\n%s", diff)
+	}
+
+	for i, b := range hdrs {
+		frag := header.IPv6Fragment(b)
+		if !frag.IsValid() {
+			t.Fatalf("fragment header %d is not valid", i)
+		}
+		if got := frag.NextHeader(); got != nextHdr {
+			t.Errorf("got frag[%d].NextHeader() = %d, want = %d", i, got, nextHdr)
+		}
+		if got, want := int(frag.FragmentOffset())*header.IPv6FragmentExtHdrFragmentOffsetBytesPerUnit, offsets[i]; got != want {
+			t.Errorf("got frag[%d] offset = %d, want = %d", i, got, want)
+		}
+		if got, want := frag.More(), mores[i]; got != want {
+			t.Errorf("got frag[%d].More() = %t, want = %t", i, got, want)
+		}
+		if got := frag.ID(); got != id {
+			t.Errorf("got frag[%d].ID() = %d, want = %d", i, got, id)
+		}
+	}
+}
+
+func TestFragmentCount(t *testing.T) {
+	tests := []struct {
+		name              string
+		totalLen          int
+		mtu               int
+		unfragmentableLen int
+		want              int
+	}{
+		{
+			name:              "fits",
+			totalLen:          1280,
+			mtu:               1280,
+			unfragmentableLen: header.IPv6MinimumSize,
+			want:              1,
+		},
+		{
+			// Each fragment carries (1280 - 40 - 8) &^ 7 = 1232 bytes, so the
+			// 2464 bytes of fragmentable data divide evenly.
+			name:              "even",
+			totalLen:          header.IPv6MinimumSize + 2*1232,
+			mtu:               1280,
+			unfragmentableLen: header.IPv6MinimumSize,
+			want:              2,
+		},
+		{
+			name:              "uneven",
+			totalLen:          header.IPv6MinimumSize + 2*1232 + 1,
+			mtu:               1280,
+			unfragmentableLen: header.IPv6MinimumSize,
+			want:              3,
+		},
+		{
+			// Each fragment carries (1283 - 40 - 8) &^ 7 = 1232 bytes rather
+			// than 1235 since the payload must be a multiple of 8.
+			name:              "unaligned MTU",
+			totalLen:          header.IPv6MinimumSize + 2*1235,
+			mtu:               1283,
+			unfragmentableLen: header.IPv6MinimumSize,
+			want:              3,
+		},
+		{
+			// Each fragment carries (1280 - 64 - 8) &^ 7 = 1208 bytes.
+			name:              "with unfragmentable extension headers",
+			totalLen:          64 + 2416,
+			mtu:               1280,
+			unfragmentableLen: 64,
+			want:              2,
+		},
+		{
+			name:              "MTU too small",
+			totalLen:          100,
+			mtu:               header.IPv6MinimumSize + header.IPv6FragmentHeaderSize + 7,
+			unfragmentableLen: header.IPv6MinimumSize,
+			want:              0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.FragmentCount(test.totalLen, test.mtu, test.unfragmentableLen); got != test.want {
+				t.Errorf("got header.FragmentCount(%d, %d, %d) = %d, want = %d", test.totalLen, test.mtu, test.unfragmentableLen, got, test.want)
+			}
+		})
+	}
+}
+
+func TestIPv6FragmentIsStrictlyValid(t *testing.T) {
+	const nextHdr = uint8(header.UDPProtocolNumber)
+
+	tests := []struct {
+		name         string
+		b            []byte
+		wantValid    bool
+		wantStrictly bool
+	}{
+		{
+			name:         "first fragment",
+			b:            []byte{nextHdr, 0, 0x00, 0x01, 1, 2, 3, 4, 1, 2, 3, 4, 5, 6, 7, 8},
+			wantValid:    true,
+			wantStrictly: true,
+		},
+		{
+			name:         "last fragment",
+			b:            []byte{nextHdr, 0, 0x00, 0x08, 1, 2, 3, 4, 1, 2, 3},
+			wantValid:    true,
+			wantStrictly: true,
+		},
+		{
+			name:         "atomic fragment",
+			b:            []byte{nextHdr, 0, 0x00, 0x00, 1, 2, 3, 4, 1, 2, 3},
+			wantValid:    true,
+			wantStrictly: true,
+		},
+		{
+			name:      "nonzero Res bits",
+			b:         []byte{nextHdr, 0, 0x00, 0x07, 1, 2, 3, 4, 1, 2, 3, 4, 5, 6, 7, 8},
+			wantValid: true,
+		},
+		{
+			name:      "nonzero Reserved field",
+			b:         []byte{nextHdr, 0xff, 0x00, 0x01, 1, 2, 3, 4, 1, 2, 3, 4, 5, 6, 7, 8},
+			wantValid: true,
+		},
+		{
+			name:      "unaligned first fragment",
+			b:         []byte{nextHdr, 0, 0x00, 0x01, 1, 2, 3, 4, 1, 2, 3},
+			wantValid: true,
+		},
+		{
+			name:      "empty first fragment",
+			b:         []byte{nextHdr, 0, 0x00, 0x01, 1, 2, 3, 4},
+			wantValid: true,
+		},
+		{
+			name: "truncated",
+			b:    []byte{nextHdr, 0, 0x00, 0x01},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			frag := header.IPv6Fragment(test.b)
+			if got := frag.IsValid(); got != test.wantValid {
+				t.Errorf("got IsValid() = %t, want = %t", got, test.wantValid)
+			}
+			if got := frag.IsStrictlyValid(); got != test.wantStrictly {
+				t.Errorf("got IsStrictlyValid() = %t, want = %t", got, test.wantStrictly)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import "encoding/binary"
+
+// IPv6MobilityType is the MH Type field of an IPv6 Mobility header.
+type IPv6MobilityType uint8
+
+// Mobility header message types, as per RFC 6275 section 6.1.2 - 6.1.9.
+const (
+	IPv6MobilityBindingRefreshRequest IPv6MobilityType = 0
+	IPv6MobilityHomeTestInit          IPv6MobilityType = 1
+	IPv6MobilityCareOfTestInit        IPv6MobilityType = 2
+	IPv6MobilityHomeTest              IPv6MobilityType = 3
+	IPv6MobilityCareOfTest            IPv6MobilityType = 4
+	IPv6MobilityBindingUpdate         IPv6MobilityType = 5
+	IPv6MobilityBindingAck            IPv6MobilityType = 6
+	IPv6MobilityBindingError          IPv6MobilityType = 7
+)
+
+// IPv6Mobility represents an IPv6 Mobility header stored in a byte array, as
+// per RFC 6275 section 6.1.1.
+//
+//    0                   1                   2                   3
+//    0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//   | Payload Proto |  Header Len   |   MH Type     | Reserved      |
+//   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//   |           Checksum            |                               |
+//   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+                               |
+//   .                                                               .
+//   .                       Message Data                            .
+//   .                                                               .
+//   +-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// Most of the methods of IPv6Mobility access to the underlying slice without
+// checking the boundaries and could panic because of 'index out of range'.
+// Always call IsValid() to validate an instance of IPv6Mobility before using
+// other methods.
+type IPv6Mobility []byte
+
+const (
+	mobilityPayloadProto = 0
+	mobilityHeaderLen    = 1
+	mobilityType         = 2
+	mobilityChecksum     = 4
+	mobilityMessageData  = 6
+
+	// IPv6MobilityMinimumSize is the minimum size of a valid Mobility header.
+	IPv6MobilityMinimumSize = 8
+)
+
+// isIPv6PayloadHeader implements IPv6PayloadHeader.isIPv6PayloadHeader.
+func (IPv6Mobility) isIPv6PayloadHeader() {}
+
+// IsValid performs basic validation on the Mobility header.
+func (b IPv6Mobility) IsValid() bool {
+	return len(b) >= IPv6MobilityMinimumSize && len(b) >= b.HeaderLength()
+}
+
+// PayloadProto returns the "payload proto" field of the Mobility header.
+//
+// The field is reserved for future use and should be set to
+// IPv6NoNextHeaderIdentifier.
+func (b IPv6Mobility) PayloadProto() uint8 {
+	return b[mobilityPayloadProto]
+}
+
+// HeaderLength returns the length of the Mobility header in bytes, as
+// described by the "header len" field.
+func (b IPv6Mobility) HeaderLength() int {
+	return (int(b[mobilityHeaderLen]) + 1) * ipv6ExtHdrLenBytesPerUnit
+}
+
+// Type returns the "MH type" field of the Mobility header.
+func (b IPv6Mobility) Type() IPv6MobilityType {
+	return IPv6MobilityType(b[mobilityType])
+}
+
+// Checksum returns the "checksum" field of the Mobility header.
+func (b IPv6Mobility) Checksum() uint16 {
+	return binary.BigEndian.Uint16(b[mobilityChecksum:])
+}
+
+// MessageData returns the message data region of the Mobility header.
+func (b IPv6Mobility) MessageData() []byte {
+	return b[mobilityMessageData:b.HeaderLength()]
+}
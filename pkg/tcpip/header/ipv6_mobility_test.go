@@ -0,0 +1,97 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+// bindingUpdate is a Mobility header holding a Binding Update message, as per
+// RFC 6275 section 6.1.7, padded to a multiple of 8 bytes with a PadN option.
+var bindingUpdate = []byte{
+	// Payload Proto = No Next Header, Header Len = 1, MH Type = 5, Reserved.
+	59, 1, 5, 0,
+	// Checksum.
+	0xab, 0xcd,
+	// Sequence #, A and H flags, Reserved, Lifetime.
+	0x12, 0x34, 0xc0, 0, 0, 0x10,
+	// PadN option.
+	1, 2, 0, 0,
+}
+
+func TestIPv6Mobility(t *testing.T) {
+	tests := []struct {
+		name            string
+		buf             []byte
+		wantValid       bool
+		wantType        header.IPv6MobilityType
+		wantChecksum    uint16
+		wantMessageData []byte
+	}{
+		{
+			name:            "binding update",
+			buf:             bindingUpdate,
+			wantValid:       true,
+			wantType:        header.IPv6MobilityBindingUpdate,
+			wantChecksum:    0xabcd,
+			wantMessageData: bindingUpdate[6:],
+		},
+		{
+			name:            "binding update with trailing bytes",
+			buf:             append(append([]byte(nil), bindingUpdate...), 1, 2, 3, 4),
+			wantValid:       true,
+			wantType:        header.IPv6MobilityBindingUpdate,
+			wantChecksum:    0xabcd,
+			wantMessageData: bindingUpdate[6:],
+		},
+		{
+			name:      "truncated",
+			buf:       bindingUpdate[:header.IPv6MobilityMinimumSize],
+			wantValid: false,
+		},
+		{
+			name:      "too small",
+			buf:       bindingUpdate[:header.IPv6MobilityMinimumSize-1],
+			wantValid: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mh := header.IPv6Mobility(test.buf)
+			if got := mh.IsValid(); got != test.wantValid {
+				t.Fatalf("got IsValid() = %t, want = %t", got, test.wantValid)
+			}
+			if !test.wantValid {
+				return
+			}
+			if got, want := mh.PayloadProto(), uint8(header.IPv6NoNextHeaderIdentifier); got != want {
+				t.Errorf("got PayloadProto() = %d, want = %d", got, want)
+			}
+			if got := mh.Type(); got != test.wantType {
+				t.Errorf("got Type() = %d, want = %d", got, test.wantType)
+			}
+			if got := mh.Checksum(); got != test.wantChecksum {
+				t.Errorf("got Checksum() = 0x%04x, want = 0x%04x", got, test.wantChecksum)
+			}
+			if got := mh.MessageData(); !bytes.Equal(got, test.wantMessageData) {
+				t.Errorf("got MessageData() = %x, want = %x", got, test.wantMessageData)
+			}
+		})
+	}
+}
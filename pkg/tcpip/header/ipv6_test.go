@@ -17,6 +17,7 @@ package header_test
 import (
 	"bytes"
 	"crypto/sha256"
+	"encoding/binary"
 	"fmt"
 	"testing"
 
@@ -453,3 +454,378 @@ func TestV6MulticastScope(t *testing.T) {
 		})
 	}
 }
+
+func TestIPv6MaxTransportPayload(t *testing.T) {
+	tests := []struct {
+		name            string
+		mtu             int
+		extHdrsLen      int
+		transportHdrLen int
+		want            int
+	}{
+		{
+			name:            "MinimumMTU UDP",
+			mtu:             header.IPv6MinimumMTU,
+			transportHdrLen: header.UDPMinimumSize,
+			want:            1232,
+		},
+		{
+			name:            "MinimumMTU TCP",
+			mtu:             header.IPv6MinimumMTU,
+			transportHdrLen: header.TCPMinimumSize,
+			want:            1220,
+		},
+		{
+			name:            "MinimumMTU TCP with Hop-by-Hop",
+			mtu:             header.IPv6MinimumMTU,
+			extHdrsLen:      8,
+			transportHdrLen: header.TCPMinimumSize,
+			want:            1212,
+		},
+		{
+			name:            "MinimumMTU TCP with Hop-by-Hop and Fragment",
+			mtu:             header.IPv6MinimumMTU,
+			extHdrsLen:      8 + header.IPv6FragmentHeaderSize,
+			transportHdrLen: header.TCPMinimumSize,
+			want:            1204,
+		},
+		{
+			name:            "Jumbo MTU",
+			mtu:             100000,
+			transportHdrLen: header.UDPMinimumSize,
+			want:            header.IPv6MaximumPayloadSize - header.UDPMinimumSize,
+		},
+		{
+			name:            "headers do not fit",
+			mtu:             header.IPv6MinimumSize + header.TCPMinimumSize - 1,
+			transportHdrLen: header.TCPMinimumSize,
+			want:            0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.IPv6MaxTransportPayload(test.mtu, test.extHdrsLen, test.transportHdrLen); got != test.want {
+				t.Errorf("got header.IPv6MaxTransportPayload(%d, %d, %d) = %d, want = %d", test.mtu, test.extHdrsLen, test.transportHdrLen, got, test.want)
+			}
+		})
+	}
+}
+
+func TestClampV6MTU(t *testing.T) {
+	tests := []struct {
+		reported              uint32
+		wantMTU               uint32
+		wantUseFragmentHeader bool
+	}{
+		{reported: 0, wantMTU: header.IPv6MinimumMTU, wantUseFragmentHeader: true},
+		{reported: 1000, wantMTU: header.IPv6MinimumMTU, wantUseFragmentHeader: true},
+		{reported: header.IPv6MinimumMTU - 1, wantMTU: header.IPv6MinimumMTU, wantUseFragmentHeader: true},
+		{reported: header.IPv6MinimumMTU, wantMTU: header.IPv6MinimumMTU, wantUseFragmentHeader: false},
+		{reported: 1400, wantMTU: 1400, wantUseFragmentHeader: false},
+	}
+
+	for _, test := range tests {
+		t.Run(fmt.Sprintf("%d", test.reported), func(t *testing.T) {
+			mtu, useFragmentHeader := header.ClampV6MTU(test.reported)
+			if mtu != test.wantMTU || useFragmentHeader != test.wantUseFragmentHeader {
+				t.Errorf("got header.ClampV6MTU(%d) = (%d, %t), want = (%d, %t)", test.reported, mtu, useFragmentHeader, test.wantMTU, test.wantUseFragmentHeader)
+			}
+		})
+	}
+}
+
+func TestIPv6TrafficClass(t *testing.T) {
+	const flowLabel = 0xabcde
+
+	for _, tc := range []uint8{0, 0xb8, 0x01, 0xff} {
+		t.Run(fmt.Sprintf("%#x", tc), func(t *testing.T) {
+			ip := header.IPv6(makeIPv6Packet(header.IPv6Fields{
+				TrafficClass: 0x5a,
+				FlowLabel:    flowLabel,
+			}, nil))
+			if got, want := ip.TrafficClass(), uint8(0x5a); got != want {
+				t.Fatalf("got ip.TrafficClass() = %#x, want = %#x", got, want)
+			}
+
+			ip.SetTrafficClass(tc)
+			if got := ip.TrafficClass(); got != tc {
+				t.Errorf("got ip.TrafficClass() = %#x, want = %#x", got, tc)
+			}
+			if got := header.IPVersion(ip); got != header.IPv6Version {
+				t.Errorf("got header.IPVersion(ip) = %d, want = %d", got, header.IPv6Version)
+			}
+			gotTC, gotFlowLabel := ip.TOS()
+			if gotTC != tc || gotFlowLabel != flowLabel {
+				t.Errorf("got ip.TOS() = (%#x, %#x), want = (%#x, %#x)", gotTC, gotFlowLabel, tc, flowLabel)
+			}
+		})
+	}
+}
+
+func TestV4MappedAndCompatibleAddresses(t *testing.T) {
+	const v4Addr = tcpip.Address("\xc0\x00\x02\x01")
+
+	tests := []struct {
+		name           string
+		addr           tcpip.Address
+		wantMapped     bool
+		wantV4         tcpip.Address
+		wantCompatible bool
+	}{
+		{
+			name:       "IPv4 mapped",
+			addr:       "\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xff\xff\xc0\x00\x02\x01",
+			wantMapped: true,
+			wantV4:     v4Addr,
+		},
+		{
+			name:           "IPv4 compatible",
+			addr:           "\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xc0\x00\x02\x01",
+			wantCompatible: true,
+		},
+		{
+			name: "unspecified",
+			addr: header.IPv6Any,
+		},
+		{
+			name: "loopback",
+			addr: header.IPv6Loopback,
+		},
+		{
+			name: "global",
+			addr: globalAddr,
+		},
+		{
+			name: "IPv4",
+			addr: v4Addr,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.IsV4MappedAddress(test.addr); got != test.wantMapped {
+				t.Errorf("got header.IsV4MappedAddress(%s) = %t, want = %t", test.addr, got, test.wantMapped)
+			}
+			v4, ok := header.V4MappedToV4(test.addr)
+			if v4 != test.wantV4 || ok != test.wantMapped {
+				t.Errorf("got header.V4MappedToV4(%s) = (%s, %t), want = (%s, %t)", test.addr, v4, ok, test.wantV4, test.wantMapped)
+			}
+			if got := header.IsV4CompatibleAddress(test.addr); got != test.wantCompatible {
+				t.Errorf("got header.IsV4CompatibleAddress(%s) = %t, want = %t", test.addr, got, test.wantCompatible)
+			}
+		})
+	}
+}
+
+func TestIsV6Documentation(t *testing.T) {
+	tests := []struct {
+		name string
+		addr tcpip.Address
+		want bool
+	}{
+		{name: "documentation", addr: "\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01", want: true},
+		{name: "documentation upper bound", addr: "\x20\x01\x0d\xb8\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff\xff", want: true},
+		{name: "adjacent prefix", addr: "\x20\x01\x0d\xb9\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01", want: false},
+		{name: "global", addr: globalAddr, want: false},
+		{name: "IPv4 documentation", addr: "\xc0\x00\x02\x01", want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.IsV6Documentation(test.addr); got != test.want {
+				t.Errorf("got header.IsV6Documentation(%s) = %t, want = %t", test.addr, got, test.want)
+			}
+		})
+	}
+}
+
+func TestV6RouterAlertValue(t *testing.T) {
+	tests := []struct {
+		name      string
+		pkt       []byte
+		wantValue header.IPv6RouterAlertValue
+		wantOK    bool
+	}{
+		{
+			name: "MLD",
+			pkt: makeIPv6Packet(header.IPv6Fields{
+				TransportProtocol: header.ICMPv6ProtocolNumber,
+				ExtensionHeaders: header.IPv6ExtHdrSerializer{
+					header.IPv6SerializableHopByHopExtHdr{
+						&header.IPv6RouterAlertOption{Value: header.IPv6RouterAlertMLD},
+					},
+				},
+			}, make([]byte, header.ICMPv6HeaderSize+header.MLDMinimumSize)),
+			wantValue: header.IPv6RouterAlertMLD,
+			wantOK:    true,
+		},
+		{
+			name: "RSVP",
+			pkt: makeIPv6Packet(header.IPv6Fields{
+				TransportProtocol: header.UDPProtocolNumber,
+				ExtensionHeaders: header.IPv6ExtHdrSerializer{
+					header.IPv6SerializableHopByHopExtHdr{
+						&header.IPv6RouterAlertOption{Value: header.IPv6RouterAlertRSVP},
+					},
+				},
+			}, testUDPHeader),
+			wantValue: header.IPv6RouterAlertRSVP,
+			wantOK:    true,
+		},
+		{
+			name: "Hop-by-Hop without Router Alert",
+			pkt: makeIPv6Packet(header.IPv6Fields{
+				TransportProtocol: header.UDPProtocolNumber,
+				ExtensionHeaders: header.IPv6ExtHdrSerializer{
+					header.IPv6SerializableHopByHopExtHdr{},
+				},
+			}, testUDPHeader),
+		},
+		{
+			name: "no extension headers",
+			pkt: makeIPv6Packet(header.IPv6Fields{
+				TransportProtocol: header.UDPProtocolNumber,
+			}, testUDPHeader),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			value, ok := header.V6RouterAlertValue(header.IPv6(test.pkt))
+			if value != test.wantValue || ok != test.wantOK {
+				t.Errorf("got header.V6RouterAlertValue(_) = (%d, %t), want = (%d, %t)", value, ok, test.wantValue, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestHomeAddressOption(t *testing.T) {
+	// destinationOptions returns a Destination Options header followed by
+	// testUDPHeader, holding an option with the given type and data placed at
+	// offset 6 as required for the Home Address option by RFC 6275 section
+	// 6.3.
+	destinationOptions := func(optType uint8, data []byte) []byte {
+		// Next Header, Hdr Ext Len and a PadN option.
+		b := []byte{uint8(header.UDPProtocolNumber), 0, 1, 2, 0, 0, optType, uint8(len(data))}
+		b = append(b, data...)
+		if pad := (8 - len(b)%8) % 8; pad != 0 {
+			// Pad1 options.
+			b = append(b, make([]byte, pad)...)
+		}
+		b[1] = uint8(len(b)/8 - 1)
+		return append(b, testUDPHeader...)
+	}
+	makePacket := func(payload []byte) []byte {
+		return makeIPv6Packet(header.IPv6Fields{
+			TransportProtocol: tcpip.TransportProtocolNumber(header.IPv6DestinationOptionsExtHdrIdentifier),
+			SrcAddr:           uniqueLocalAddr1,
+			DstAddr:           uniqueLocalAddr2,
+		}, payload)
+	}
+
+	tests := []struct {
+		name     string
+		pkt      []byte
+		wantAddr tcpip.Address
+		wantOK   bool
+	}{
+		{
+			name:     "Home Address option",
+			pkt:      makePacket(destinationOptions(201, []byte(globalAddr))),
+			wantAddr: globalAddr,
+			wantOK:   true,
+		},
+		{
+			name: "Home Address option with short address",
+			pkt:  makePacket(destinationOptions(201, []byte(globalAddr)[:header.IPv4AddressSize])),
+		},
+		{
+			name: "Destination Options without Home Address option",
+			pkt:  makePacket(destinationOptions(0x1e, []byte(globalAddr))),
+		},
+		{
+			name: "Hop-by-Hop Options with Router Alert",
+			pkt: makeIPv6Packet(header.IPv6Fields{
+				TransportProtocol: header.UDPProtocolNumber,
+				ExtensionHeaders: header.IPv6ExtHdrSerializer{
+					header.IPv6SerializableHopByHopExtHdr{
+						&header.IPv6RouterAlertOption{Value: header.IPv6RouterAlertMLD},
+					},
+				},
+			}, testUDPHeader),
+		},
+		{
+			name: "no extension headers",
+			pkt: makeIPv6Packet(header.IPv6Fields{
+				TransportProtocol: header.UDPProtocolNumber,
+			}, testUDPHeader),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			addr, ok := header.HomeAddressOption(header.IPv6(test.pkt))
+			if addr != test.wantAddr || ok != test.wantOK {
+				t.Errorf("got header.HomeAddressOption(_) = (%s, %t), want = (%s, %t)", addr, ok, test.wantAddr, test.wantOK)
+			}
+		})
+	}
+}
+
+func TestIPv6IsJumbogram(t *testing.T) {
+	jumbogram := func(payloadLength uint16, optType uint8, jumboLength uint32) []byte {
+		b := make([]byte, header.IPv6MinimumSize+8)
+		header.IPv6(b).Encode(&header.IPv6Fields{
+			PayloadLength:     payloadLength,
+			TransportProtocol: tcpip.TransportProtocolNumber(header.IPv6HopByHopOptionsExtHdrIdentifier),
+			HopLimit:          64,
+			SrcAddr:           uniqueLocalAddr1,
+			DstAddr:           uniqueLocalAddr2,
+		})
+		hbh := b[header.IPv6MinimumSize:]
+		// Next Header, Hdr Ext Len, Option Type and Opt Data Len.
+		copy(hbh, []byte{uint8(header.UDPProtocolNumber), 0, optType, 4})
+		binary.BigEndian.PutUint32(hbh[4:], jumboLength)
+		return b
+	}
+
+	tests := []struct {
+		name       string
+		pkt        []byte
+		wantJumbo  bool
+		wantLength uint32
+	}{
+		{
+			name:       "valid jumbogram",
+			pkt:        jumbogram(0, 0xc2, 100000),
+			wantJumbo:  true,
+			wantLength: 100000,
+		},
+		{
+			name: "non-zero Payload Length",
+			pkt:  jumbogram(8, 0xc2, 100000),
+		},
+		{
+			name: "Jumbo Payload Length fits in Payload Length",
+			pkt:  jumbogram(0, 0xc2, 1000),
+		},
+		{
+			name: "other option",
+			pkt:  jumbogram(0, 0xc3, 100000),
+		},
+		{
+			name: "no Hop-by-Hop Options header",
+			pkt:  header.BuildUDPv6Packet(uniqueLocalAddr1, uniqueLocalAddr2, 1234, 53, nil, 64),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			isJumbo, length := header.IPv6(test.pkt).IsJumbogram()
+			if isJumbo != test.wantJumbo || length != test.wantLength {
+				t.Errorf("got IsJumbogram() = (%t, %d), want = (%t, %d)", isJumbo, length, test.wantJumbo, test.wantLength)
+			}
+		})
+	}
+}
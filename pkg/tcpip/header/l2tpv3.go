@@ -0,0 +1,152 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"encoding/binary"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// RFC 3931 section 4.1.1.1 defines the session header of an L2TPv3 data
+// message carried directly over IP as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                           Session ID                          |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|               Cookie (optional, maximum 64 bits)...
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// where a Session ID of zero introduces a control message instead. Section
+// 4.1.2.1 defines the session header over UDP, where the same fields follow a
+// word holding the T bit, set for control messages, and the version:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|T|x|x|x|x|x|x|x|x|x|x|x|  Ver  |             Reserved          |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// The length of the cookie is not carried in the header; it is configured per
+// session when the session is established.
+const (
+	l2tpv3SessionID = 0
+	l2tpv3Cookie    = 4
+
+	l2tpv3UDPFlags   = 0
+	l2tpv3UDPVersion = 1
+
+	l2tpv3UDPFlagControl   = 0x80
+	l2tpv3UDPVersionMask   = 0x0f
+	l2tpv3ControlSessionID = 0
+)
+
+const (
+	// L2TPv3ProtocolNumber is the protocol number carried by an IP header
+	// encapsulating L2TPv3, as per RFC 3931 section 4.1.1.
+	L2TPv3ProtocolNumber tcpip.TransportProtocolNumber = 115
+
+	// L2TPPort is the UDP port of L2TP, as per RFC 3931 section 4.1.2.
+	L2TPPort = 1701
+
+	// L2TPv3Version is the version carried by L2TPv3 over UDP.
+	L2TPv3Version = 3
+
+	// L2TPv3SessionIDSize is the size of the Session ID of an L2TPv3
+	// session header.
+	L2TPv3SessionIDSize = 4
+
+	// L2TPv3UDPHeaderSize is the size of the word preceding the session
+	// header of L2TPv3 over UDP.
+	L2TPv3UDPHeaderSize = 4
+
+	// L2TPv3MaximumCookieSize is the maximum size of the cookie of an
+	// L2TPv3 session header.
+	L2TPv3MaximumCookieSize = 8
+)
+
+// L2TPv3 represents the session header of an L2TPv3 data message, starting at
+// its Session ID, stored in a byte array. It is returned by ParseL2TPv3IP and
+// ParseL2TPv3UDP.
+type L2TPv3 []byte
+
+// ParseL2TPv3IP parses the L2TPv3 message held in ipPayload, the payload of
+// an IP packet carrying L2TPv3ProtocolNumber. The returned header aliases
+// ipPayload and is nil for control messages.
+//
+// ok is false if ipPayload is too short to hold a Session ID.
+func ParseL2TPv3IP(ipPayload []byte) (hdr L2TPv3, isControl bool, ok bool) {
+	if len(ipPayload) < L2TPv3SessionIDSize {
+		return nil, false, false
+	}
+	hdr = L2TPv3(ipPayload)
+	if hdr.SessionID() == l2tpv3ControlSessionID {
+		return nil, true, true
+	}
+	return hdr, false, true
+}
+
+// ParseL2TPv3UDP parses the L2TPv3 message held in udpPayload, the payload of
+// a UDP datagram sent to or from L2TPPort. The returned header aliases
+// udpPayload and is nil for control messages, as indicated by the T bit.
+//
+// ok is false if the version is not L2TPv3Version or if udpPayload is too
+// short to hold the session header of a data message.
+func ParseL2TPv3UDP(udpPayload []byte) (hdr L2TPv3, isControl bool, ok bool) {
+	if len(udpPayload) < L2TPv3UDPHeaderSize || udpPayload[l2tpv3UDPVersion]&l2tpv3UDPVersionMask != L2TPv3Version {
+		return nil, false, false
+	}
+	if udpPayload[l2tpv3UDPFlags]&l2tpv3UDPFlagControl != 0 {
+		return nil, true, true
+	}
+	if len(udpPayload) < L2TPv3UDPHeaderSize+L2TPv3SessionIDSize {
+		return nil, false, false
+	}
+	return L2TPv3(udpPayload[L2TPv3UDPHeaderSize:]), false, true
+}
+
+// SessionID returns the Session ID, identifying the session on the receiving
+// control connection endpoint.
+func (b L2TPv3) SessionID() uint32 {
+	return binary.BigEndian.Uint32(b[l2tpv3SessionID:])
+}
+
+// Cookie returns the cookie following the Session ID, given the cookieLen
+// configured for the session: 0, 4 or 8 bytes.
+//
+// The returned bool is false if cookieLen is not a valid cookie length or if
+// b is too short to hold the cookie.
+func (b L2TPv3) Cookie(cookieLen int) ([]byte, bool) {
+	switch cookieLen {
+	case 0, 4, L2TPv3MaximumCookieSize:
+	default:
+		return nil, false
+	}
+	if len(b) < l2tpv3Cookie+cookieLen {
+		return nil, false
+	}
+	return b[l2tpv3Cookie:][:cookieLen], true
+}
+
+// Payload returns the data following the session header, given the cookieLen
+// configured for the session. This is the L2-Specific Sublayer, if any,
+// followed by the tunnelled frame.
+//
+// The cookie must be valid; see Cookie.
+func (b L2TPv3) Payload(cookieLen int) []byte {
+	return b[l2tpv3Cookie+cookieLen:]
+}
@@ -0,0 +1,136 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestL2TPv3(t *testing.T) {
+	cookie := []byte{0xc0, 0x0c, 0x1e, 0x5e, 0x11, 0x22, 0x33, 0x44}
+	frame := []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+
+	tests := []struct {
+		name          string
+		parse         func([]byte) (header.L2TPv3, bool, bool)
+		buf           []byte
+		cookieLen     int
+		wantOK        bool
+		wantControl   bool
+		wantSessionID uint32
+	}{
+		{
+			name:  "UDP data message with 64-bit cookie",
+			parse: header.ParseL2TPv3UDP,
+			buf: append(append([]byte{
+				0x00, 0x03, 0x00, 0x00,
+				0x12, 0x34, 0x56, 0x78,
+			}, cookie...), frame...),
+			cookieLen:     8,
+			wantOK:        true,
+			wantSessionID: 0x12345678,
+		},
+		{
+			name:  "UDP data message with 32-bit cookie",
+			parse: header.ParseL2TPv3UDP,
+			buf: append(append([]byte{
+				0x00, 0x03, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x2a,
+			}, cookie[:4]...), frame...),
+			cookieLen:     4,
+			wantOK:        true,
+			wantSessionID: 42,
+		},
+		{
+			name:        "UDP control message",
+			parse:       header.ParseL2TPv3UDP,
+			buf:         []byte{0xc8, 0x03, 0x00, 0x0c, 0x00, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00},
+			wantOK:      true,
+			wantControl: true,
+		},
+		{
+			name:  "UDP L2TPv2",
+			parse: header.ParseL2TPv3UDP,
+			buf:   []byte{0x00, 0x02, 0x00, 0x00, 0x00, 0x00, 0x00, 0x2a},
+		},
+		{
+			name:  "UDP data message without session ID",
+			parse: header.ParseL2TPv3UDP,
+			buf:   []byte{0x00, 0x03, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name:          "IP data message",
+			parse:         header.ParseL2TPv3IP,
+			buf:           append([]byte{0x00, 0x00, 0x00, 0x2a}, frame...),
+			wantOK:        true,
+			wantSessionID: 42,
+		},
+		{
+			name:        "IP control message",
+			parse:       header.ParseL2TPv3IP,
+			buf:         []byte{0x00, 0x00, 0x00, 0x00, 0xc8, 0x03, 0x00, 0x0c},
+			wantOK:      true,
+			wantControl: true,
+		},
+		{
+			name:  "IP too short",
+			parse: header.ParseL2TPv3IP,
+			buf:   []byte{0x00, 0x00, 0x00},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			hdr, isControl, ok := test.parse(test.buf)
+			if ok != test.wantOK || isControl != test.wantControl {
+				t.Fatalf("got parse(_) = (_, %t, %t), want = (_, %t, %t)", isControl, ok, test.wantControl, test.wantOK)
+			}
+			if !ok || isControl {
+				if hdr != nil {
+					t.Errorf("got parse(_) = (%x, _, _), want = (nil, _, _)", hdr)
+				}
+				return
+			}
+			if got := hdr.SessionID(); got != test.wantSessionID {
+				t.Errorf("got SessionID() = %#x, want = %#x", got, test.wantSessionID)
+			}
+			got, ok := hdr.Cookie(test.cookieLen)
+			if !ok {
+				t.Fatalf("got Cookie(%d) = (_, false), want = (_, true)", test.cookieLen)
+			}
+			if want := cookie[:test.cookieLen]; !bytes.Equal(got, want) {
+				t.Errorf("got Cookie(%d) = %x, want = %x", test.cookieLen, got, want)
+			}
+			if got := hdr.Payload(test.cookieLen); !bytes.Equal(got, frame) {
+				t.Errorf("got Payload(%d) = %x, want = %x", test.cookieLen, got, frame)
+			}
+		})
+	}
+}
+
+func TestL2TPv3CookieInvalidLength(t *testing.T) {
+	hdr, _, ok := header.ParseL2TPv3IP([]byte{0x00, 0x00, 0x00, 0x2a, 0x01, 0x02, 0x03, 0x04})
+	if !ok {
+		t.Fatal("got ParseL2TPv3IP(_) = (_, _, false), want = (_, _, true)")
+	}
+	for _, cookieLen := range []int{2, 8, 12} {
+		if got, ok := hdr.Cookie(cookieLen); ok {
+			t.Errorf("got Cookie(%d) = (%x, true), want = (_, false)", cookieLen, got)
+		}
+	}
+}
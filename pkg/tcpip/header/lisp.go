@@ -0,0 +1,131 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import "encoding/binary"
+
+// RFC 9300 section 5.1 defines the LISP data-plane header that follows the
+// outer UDP header as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|N|L|E|V|I|R|K|K|            Nonce/Map-Version                  |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                 Instance ID/Locator-Status-Bits               |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+const (
+	lispFlags      = 0
+	lispNonce      = 0
+	lispInstanceID = 4
+
+	lispMapVersionBits = 12
+	lispMapVersionMask = 1<<lispMapVersionBits - 1
+	lispNonceMask      = 1<<24 - 1
+	lispInstanceIDBits = 8
+	lispLSBsMask       = 1<<lispInstanceIDBits - 1
+)
+
+const (
+	// LISPDataPort is the UDP destination port for LISP encapsulated data
+	// packets, as per RFC 9300 section 5.1.
+	LISPDataPort = 4341
+
+	// LISPHeaderSize is the size of the LISP data-plane header.
+	LISPHeaderSize = 8
+)
+
+// The flags carried in the first byte of the LISP data-plane header, as per
+// RFC 9300 section 5.3.
+const (
+	LISPFlagNonce             uint8 = 1 << 7
+	LISPFlagLocatorStatusBits uint8 = 1 << 6
+	LISPFlagEchoNonce         uint8 = 1 << 5
+	LISPFlagMapVersion        uint8 = 1 << 4
+	LISPFlagInstanceID        uint8 = 1 << 3
+)
+
+// LISP represents a LISP data-plane header stored in a byte array.
+//
+// The meaning of both 32-bit words of the header depends on the flags: the low
+// 24 bits of the first word hold either a nonce (N set) or a pair of
+// Map-Versions (V set), and the second word holds either an Instance ID
+// followed by 8 Locator-Status-Bits (I set) or 32 Locator-Status-Bits.
+type LISP []byte
+
+// IsValid performs basic validation on the LISP header.
+//
+// The N and V flags are mutually exclusive as they assign different meanings
+// to the same bits.
+func (b LISP) IsValid() bool {
+	if len(b) < LISPHeaderSize {
+		return false
+	}
+	flags := b.Flags()
+	return flags&LISPFlagNonce == 0 || flags&LISPFlagMapVersion == 0
+}
+
+// Flags returns the flags byte of the LISP header.
+func (b LISP) Flags() uint8 {
+	return b[lispFlags]
+}
+
+// Nonce returns the nonce held in the LISP header, if present.
+func (b LISP) Nonce() (uint32, bool) {
+	if b.Flags()&LISPFlagNonce == 0 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(b[lispNonce:]) & lispNonceMask, true
+}
+
+// MapVersions returns the Source and Dest Map-Version numbers held in the LISP
+// header, if present.
+func (b LISP) MapVersions() (src, dst uint16, ok bool) {
+	if b.Flags()&LISPFlagMapVersion == 0 {
+		return 0, 0, false
+	}
+	v := binary.BigEndian.Uint32(b[lispNonce:])
+	return uint16(v>>lispMapVersionBits) & lispMapVersionMask, uint16(v) & lispMapVersionMask, true
+}
+
+// InstanceID returns the 24-bit Instance ID held in the LISP header, if
+// present.
+func (b LISP) InstanceID() (uint32, bool) {
+	if b.Flags()&LISPFlagInstanceID == 0 {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(b[lispInstanceID:]) >> lispInstanceIDBits, true
+}
+
+// LocatorStatusBits returns the Locator-Status-Bits held in the LISP header,
+// if present.
+//
+// Only the low 8 bits are returned when an Instance ID is present.
+func (b LISP) LocatorStatusBits() (uint32, bool) {
+	flags := b.Flags()
+	if flags&LISPFlagLocatorStatusBits == 0 {
+		return 0, false
+	}
+	lsbs := binary.BigEndian.Uint32(b[lispInstanceID:])
+	if flags&LISPFlagInstanceID != 0 {
+		lsbs &= lispLSBsMask
+	}
+	return lsbs, true
+}
+
+// Payload returns the packet encapsulated by the LISP header.
+func (b LISP) Payload() []byte {
+	return b[LISPHeaderSize:]
+}
@@ -0,0 +1,106 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestLISP(t *testing.T) {
+	tests := []struct {
+		name           string
+		buf            []byte
+		wantValid      bool
+		wantNonce      uint32
+		wantNonceOK    bool
+		wantSrcVersion uint16
+		wantDstVersion uint16
+		wantVersionsOK bool
+		wantIID        uint32
+		wantIIDOK      bool
+		wantLSBs       uint32
+		wantLSBsOK     bool
+	}{
+		{
+			name:        "nonce and instance ID",
+			buf:         []byte{0x88, 0x12, 0x34, 0x56, 0x00, 0x0a, 0xbc, 0xff},
+			wantValid:   true,
+			wantNonce:   0x123456,
+			wantNonceOK: true,
+			wantIID:     0x000abc,
+			wantIIDOK:   true,
+		},
+		{
+			name:       "instance ID and locator status bits",
+			buf:        []byte{0x48, 0, 0, 0, 0xab, 0xcd, 0xef, 0x03},
+			wantValid:  true,
+			wantIID:    0xabcdef,
+			wantIIDOK:  true,
+			wantLSBs:   0x03,
+			wantLSBsOK: true,
+		},
+		{
+			name:       "locator status bits only",
+			buf:        []byte{0x40, 0, 0, 0, 0xab, 0xcd, 0xef, 0x03},
+			wantValid:  true,
+			wantLSBs:   0xabcdef03,
+			wantLSBsOK: true,
+		},
+		{
+			name:           "map versions",
+			buf:            []byte{0x10, 0x12, 0x34, 0x56, 0, 0, 0, 0},
+			wantValid:      true,
+			wantSrcVersion: 0x123,
+			wantDstVersion: 0x456,
+			wantVersionsOK: true,
+		},
+		{
+			name:      "nonce and map version",
+			buf:       []byte{0x90, 0, 0, 0, 0, 0, 0, 0},
+			wantValid: false,
+		},
+		{
+			name:      "too small",
+			buf:       []byte{0x88, 0, 0, 0, 0, 0, 0},
+			wantValid: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lisp := header.LISP(test.buf)
+			if got := lisp.IsValid(); got != test.wantValid {
+				t.Fatalf("got IsValid() = %t, want = %t", got, test.wantValid)
+			}
+			if !test.wantValid {
+				return
+			}
+			if nonce, ok := lisp.Nonce(); nonce != test.wantNonce || ok != test.wantNonceOK {
+				t.Errorf("got Nonce() = (0x%x, %t), want = (0x%x, %t)", nonce, ok, test.wantNonce, test.wantNonceOK)
+			}
+			if src, dst, ok := lisp.MapVersions(); src != test.wantSrcVersion || dst != test.wantDstVersion || ok != test.wantVersionsOK {
+				t.Errorf("got MapVersions() = (%d, %d, %t), want = (%d, %d, %t)", src, dst, ok, test.wantSrcVersion, test.wantDstVersion, test.wantVersionsOK)
+			}
+			if iid, ok := lisp.InstanceID(); iid != test.wantIID || ok != test.wantIIDOK {
+				t.Errorf("got InstanceID() = (0x%x, %t), want = (0x%x, %t)", iid, ok, test.wantIID, test.wantIIDOK)
+			}
+			if lsbs, ok := lisp.LocatorStatusBits(); lsbs != test.wantLSBs || ok != test.wantLSBsOK {
+				t.Errorf("got LocatorStatusBits() = (0x%x, %t), want = (0x%x, %t)", lsbs, ok, test.wantLSBs, test.wantLSBsOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"fmt"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// NATRewriteIPv4 rewrites the source and destination addresses of the IPv4
+// packet held in ipPacket to newSrc and newDst.
+//
+// The IPv4 header checksum is recalculated. If the packet holds the first (or
+// only) fragment of a TCP or UDP datagram, the transport checksum is updated
+// incrementally to account for the new pseudo-header, as per RFC 3022 section
+// 4.2; a zero UDP checksum indicates that no checksum was computed and is left
+// untouched.
+//
+// Returns false without modifying ipPacket if it does not hold a valid IPv4
+// packet.
+func NATRewriteIPv4(ipPacket []byte, newSrc, newDst tcpip.Address) bool {
+	if len(newSrc) != IPv4AddressSize || len(newDst) != IPv4AddressSize {
+		panic(fmt.Sprintf("got len(newSrc) = %d, len(newDst) = %d, want = %d", len(newSrc), len(newDst), IPv4AddressSize))
+	}
+
+	ipv4 := IPv4(ipPacket)
+	if !ipv4.IsValid(len(ipPacket)) {
+		return false
+	}
+	oldSrc := ipv4.SourceAddress()
+	oldDst := ipv4.DestinationAddress()
+
+	if ipv4.FragmentOffset() == 0 {
+		payload := ipv4.Payload()
+		switch ipv4.TransportProtocol() {
+		case TCPProtocolNumber:
+			if len(payload) >= TCPMinimumSize {
+				tcp := TCP(payload)
+				tcp.SetChecksum(^natUpdateChecksum(^tcp.Checksum(), oldSrc, oldDst, newSrc, newDst))
+			}
+		case UDPProtocolNumber:
+			if len(payload) >= UDPMinimumSize {
+				udp := UDP(payload)
+				if udp.Checksum() != 0 {
+					xsum := ^natUpdateChecksum(^udp.Checksum(), oldSrc, oldDst, newSrc, newDst)
+					// As per RFC 768, a computed checksum of zero is transmitted as all
+					// ones.
+					if xsum == 0 {
+						xsum = 0xffff
+					}
+					udp.SetChecksum(xsum)
+				}
+			}
+		}
+	}
+
+	ipv4.SetSourceAddress(newSrc)
+	ipv4.SetDestinationAddress(newDst)
+	ipv4.SetChecksum(0)
+	ipv4.SetChecksum(ChecksumToField(ipv4.CalculateChecksum()))
+	return true
+}
+
+// natUpdateChecksum updates the checksum xsum, which covers a pseudo-header
+// holding oldSrc and oldDst, to cover newSrc and newDst instead.
+func natUpdateChecksum(xsum uint16, oldSrc, oldDst, newSrc, newDst tcpip.Address) uint16 {
+	xsum = checksumUpdate2ByteAlignedAddress(xsum, oldSrc, newSrc)
+	return checksumUpdate2ByteAlignedAddress(xsum, oldDst, newDst)
+}
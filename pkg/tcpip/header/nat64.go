@@ -0,0 +1,121 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+// TranslateICMPv4ToV6 translates the type and code of an ICMPv4 message to
+// the type and code of the equivalent ICMPv6 message, as per RFC 7915 section
+// 4.2.
+//
+// It returns false if the message must be dropped by the translator instead of
+// being translated.
+//
+// Note, the translation of Parameter Problem messages (and Destination
+// Unreachable messages translated to Parameter Problem messages) also requires
+// the pointer to be translated; that is left to the caller.
+func TranslateICMPv4ToV6(typ, code uint8) (uint8, uint8, bool) {
+	switch ICMPv4Type(typ) {
+	case ICMPv4Echo:
+		return uint8(ICMPv6EchoRequest), 0, true
+	case ICMPv4EchoReply:
+		return uint8(ICMPv6EchoReply), 0, true
+	case ICMPv4DstUnreachable:
+		switch ICMPv4Code(code) {
+		case ICMPv4NetUnreachable,
+			ICMPv4HostUnreachable,
+			ICMPv4SourceRouteFailed,
+			ICMPv4DestinationNetworkUnknown,
+			ICMPv4DestinationHostUnknown,
+			ICMPv4SourceHostIsolated,
+			ICMPv4NetUnreachableForTos,
+			ICMPv4HostUnreachableForTos:
+			return uint8(ICMPv6DstUnreachable), uint8(ICMPv6NetworkUnreachable), true
+		case ICMPv4ProtoUnreachable:
+			return uint8(ICMPv6ParamProblem), uint8(ICMPv6UnknownHeader), true
+		case ICMPv4PortUnreachable:
+			return uint8(ICMPv6DstUnreachable), uint8(ICMPv6PortUnreachable), true
+		case ICMPv4FragmentationNeeded:
+			return uint8(ICMPv6PacketTooBig), 0, true
+		case ICMPv4NetProhibited,
+			ICMPv4HostProhibited,
+			ICMPv4AdminProhibited,
+			ICMPv4PrecedenceCutInEffect:
+			return uint8(ICMPv6DstUnreachable), uint8(ICMPv6Prohibited), true
+		default:
+			// Includes Host Precedence Violation (code 14).
+			return 0, 0, false
+		}
+	case ICMPv4TimeExceeded:
+		return uint8(ICMPv6TimeExceeded), code, true
+	case ICMPv4ParamProblem:
+		switch ICMPv4Code(code) {
+		case ICMPv4PointerIndicatesError, ICMPv4BadLength:
+			return uint8(ICMPv6ParamProblem), uint8(ICMPv6ErroneousHeader), true
+		default:
+			// Includes Missing a Required Option (code 1).
+			return 0, 0, false
+		}
+	default:
+		// Includes Information Request/Reply, Timestamp and Timestamp Reply,
+		// Address Mask Request/Reply, ICMP Router Advertisement and
+		// Solicitation, Redirect and Source Quench.
+		return 0, 0, false
+	}
+}
+
+// TranslateICMPv6ToV4 translates the type and code of an ICMPv6 message to
+// the type and code of the equivalent ICMPv4 message, as per RFC 7915 section
+// 5.2.
+//
+// It returns false if the message must be dropped by the translator instead of
+// being translated.
+//
+// Note, the translation of Parameter Problem messages also requires the
+// pointer to be translated; that is left to the caller.
+func TranslateICMPv6ToV4(typ, code uint8) (uint8, uint8, bool) {
+	switch ICMPv6Type(typ) {
+	case ICMPv6EchoRequest:
+		return uint8(ICMPv4Echo), 0, true
+	case ICMPv6EchoReply:
+		return uint8(ICMPv4EchoReply), 0, true
+	case ICMPv6DstUnreachable:
+		switch ICMPv6Code(code) {
+		case ICMPv6NetworkUnreachable, ICMPv6BeyondScope, ICMPv6AddressUnreachable:
+			return uint8(ICMPv4DstUnreachable), uint8(ICMPv4HostUnreachable), true
+		case ICMPv6Prohibited:
+			return uint8(ICMPv4DstUnreachable), uint8(ICMPv4HostProhibited), true
+		case ICMPv6PortUnreachable:
+			return uint8(ICMPv4DstUnreachable), uint8(ICMPv4PortUnreachable), true
+		default:
+			return 0, 0, false
+		}
+	case ICMPv6PacketTooBig:
+		return uint8(ICMPv4DstUnreachable), uint8(ICMPv4FragmentationNeeded), true
+	case ICMPv6TimeExceeded:
+		return uint8(ICMPv4TimeExceeded), code, true
+	case ICMPv6ParamProblem:
+		switch ICMPv6Code(code) {
+		case ICMPv6ErroneousHeader:
+			return uint8(ICMPv4ParamProblem), uint8(ICMPv4PointerIndicatesError), true
+		case ICMPv6UnknownHeader:
+			return uint8(ICMPv4DstUnreachable), uint8(ICMPv4ProtoUnreachable), true
+		default:
+			return 0, 0, false
+		}
+	default:
+		// Includes MLD and NDP messages as well as any other informational
+		// message.
+		return 0, 0, false
+	}
+}
@@ -0,0 +1,226 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestTranslateICMPv4ToV6(t *testing.T) {
+	tests := []struct {
+		name     string
+		typ      header.ICMPv4Type
+		code     header.ICMPv4Code
+		wantType header.ICMPv6Type
+		wantCode header.ICMPv6Code
+		wantOK   bool
+	}{
+		{
+			name:     "EchoRequest",
+			typ:      header.ICMPv4Echo,
+			wantType: header.ICMPv6EchoRequest,
+			wantOK:   true,
+		},
+		{
+			name:     "HostUnreachable",
+			typ:      header.ICMPv4DstUnreachable,
+			code:     header.ICMPv4HostUnreachable,
+			wantType: header.ICMPv6DstUnreachable,
+			wantCode: header.ICMPv6NetworkUnreachable,
+			wantOK:   true,
+		},
+		{
+			name:     "PortUnreachable",
+			typ:      header.ICMPv4DstUnreachable,
+			code:     header.ICMPv4PortUnreachable,
+			wantType: header.ICMPv6DstUnreachable,
+			wantCode: header.ICMPv6PortUnreachable,
+			wantOK:   true,
+		},
+		{
+			name:     "ProtocolUnreachable",
+			typ:      header.ICMPv4DstUnreachable,
+			code:     header.ICMPv4ProtoUnreachable,
+			wantType: header.ICMPv6ParamProblem,
+			wantCode: header.ICMPv6UnknownHeader,
+			wantOK:   true,
+		},
+		{
+			name:     "FragmentationNeeded",
+			typ:      header.ICMPv4DstUnreachable,
+			code:     header.ICMPv4FragmentationNeeded,
+			wantType: header.ICMPv6PacketTooBig,
+			wantOK:   true,
+		},
+		{
+			name:     "AdminProhibited",
+			typ:      header.ICMPv4DstUnreachable,
+			code:     header.ICMPv4AdminProhibited,
+			wantType: header.ICMPv6DstUnreachable,
+			wantCode: header.ICMPv6Prohibited,
+			wantOK:   true,
+		},
+		{
+			name: "HostPrecedenceViolation",
+			typ:  header.ICMPv4DstUnreachable,
+			code: header.ICMPv4HostPrecedenceViolation,
+		},
+		{
+			name:     "TTLExceeded",
+			typ:      header.ICMPv4TimeExceeded,
+			code:     header.ICMPv4TTLExceeded,
+			wantType: header.ICMPv6TimeExceeded,
+			wantCode: header.ICMPv6HopLimitExceeded,
+			wantOK:   true,
+		},
+		{
+			name:     "ReassemblyTimeout",
+			typ:      header.ICMPv4TimeExceeded,
+			code:     header.ICMPv4ReassemblyTimeout,
+			wantType: header.ICMPv6TimeExceeded,
+			wantCode: header.ICMPv6ReassemblyTimeout,
+			wantOK:   true,
+		},
+		{
+			name: "MissingRequiredOption",
+			typ:  header.ICMPv4ParamProblem,
+			code: header.ICMPv4MissingRequiredOption,
+		},
+		{
+			name: "Timestamp",
+			typ:  header.ICMPv4Timestamp,
+		},
+		{
+			name: "Redirect",
+			typ:  header.ICMPv4Redirect,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			typ, code, ok := header.TranslateICMPv4ToV6(uint8(test.typ), uint8(test.code))
+			if ok != test.wantOK {
+				t.Fatalf("got header.TranslateICMPv4ToV6(%d, %d) = (_, _, %t), want = (_, _, %t)", test.typ, test.code, ok, test.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got, want := header.ICMPv6Type(typ), test.wantType; got != want {
+				t.Errorf("got type = %d, want = %d", got, want)
+			}
+			if got, want := header.ICMPv6Code(code), test.wantCode; got != want {
+				t.Errorf("got code = %d, want = %d", got, want)
+			}
+		})
+	}
+}
+
+func TestTranslateICMPv6ToV4(t *testing.T) {
+	tests := []struct {
+		name     string
+		typ      header.ICMPv6Type
+		code     header.ICMPv6Code
+		wantType header.ICMPv4Type
+		wantCode header.ICMPv4Code
+		wantOK   bool
+	}{
+		{
+			name:     "EchoReply",
+			typ:      header.ICMPv6EchoReply,
+			wantType: header.ICMPv4EchoReply,
+			wantOK:   true,
+		},
+		{
+			name:     "NoRoute",
+			typ:      header.ICMPv6DstUnreachable,
+			code:     header.ICMPv6NetworkUnreachable,
+			wantType: header.ICMPv4DstUnreachable,
+			wantCode: header.ICMPv4HostUnreachable,
+			wantOK:   true,
+		},
+		{
+			name:     "PortUnreachable",
+			typ:      header.ICMPv6DstUnreachable,
+			code:     header.ICMPv6PortUnreachable,
+			wantType: header.ICMPv4DstUnreachable,
+			wantCode: header.ICMPv4PortUnreachable,
+			wantOK:   true,
+		},
+		{
+			name:     "Prohibited",
+			typ:      header.ICMPv6DstUnreachable,
+			code:     header.ICMPv6Prohibited,
+			wantType: header.ICMPv4DstUnreachable,
+			wantCode: header.ICMPv4HostProhibited,
+			wantOK:   true,
+		},
+		{
+			name:     "PacketTooBig",
+			typ:      header.ICMPv6PacketTooBig,
+			wantType: header.ICMPv4DstUnreachable,
+			wantCode: header.ICMPv4FragmentationNeeded,
+			wantOK:   true,
+		},
+		{
+			name:     "HopLimitExceeded",
+			typ:      header.ICMPv6TimeExceeded,
+			code:     header.ICMPv6HopLimitExceeded,
+			wantType: header.ICMPv4TimeExceeded,
+			wantCode: header.ICMPv4TTLExceeded,
+			wantOK:   true,
+		},
+		{
+			name:     "UnknownNextHeader",
+			typ:      header.ICMPv6ParamProblem,
+			code:     header.ICMPv6UnknownHeader,
+			wantType: header.ICMPv4DstUnreachable,
+			wantCode: header.ICMPv4ProtoUnreachable,
+			wantOK:   true,
+		},
+		{
+			name: "UnknownOption",
+			typ:  header.ICMPv6ParamProblem,
+			code: header.ICMPv6UnknownOption,
+		},
+		{
+			name: "NeighborSolicit",
+			typ:  header.ICMPv6NeighborSolicit,
+		},
+		{
+			name: "MulticastListenerQuery",
+			typ:  header.ICMPv6MulticastListenerQuery,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			typ, code, ok := header.TranslateICMPv6ToV4(uint8(test.typ), uint8(test.code))
+			if ok != test.wantOK {
+				t.Fatalf("got header.TranslateICMPv6ToV4(%d, %d) = (_, _, %t), want = (_, _, %t)", test.typ, test.code, ok, test.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got, want := header.ICMPv4Type(typ), test.wantType; got != want {
+				t.Errorf("got type = %d, want = %d", got, want)
+			}
+			if got, want := header.ICMPv4Code(code), test.wantCode; got != want {
+				t.Errorf("got code = %d, want = %d", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestNATRewriteIPv4(t *testing.T) {
+	const (
+		newSrc = tcpip.Address("\xc0\xa8\x01\x64")
+		newDst = tcpip.Address("\xcb\x00\x71\x07")
+	)
+	data := []byte{1, 2, 3, 4, 5}
+
+	makeTCP := func() []byte {
+		b := make([]byte, header.TCPMinimumSize+len(data))
+		tcp := header.TCP(b)
+		tcp.Encode(&header.TCPFields{
+			SrcPort:    1234,
+			DstPort:    80,
+			SeqNum:     1,
+			AckNum:     2,
+			DataOffset: header.TCPMinimumSize,
+			Flags:      header.TCPFlagAck,
+			WindowSize: 1000,
+		})
+		copy(tcp.Payload(), data)
+		header.FillTCPChecksum(tcp[:header.TCPMinimumSize], testIPv4SrcAddr, testIPv4DstAddr, header.IPv4ProtocolNumber, buffer.View(data).ToVectorisedView())
+		return b
+	}
+	makeUDP := func(withChecksum bool) []byte {
+		b := make([]byte, header.UDPMinimumSize+len(data))
+		udp := header.UDP(b)
+		udp.Encode(&header.UDPFields{
+			SrcPort: 1234,
+			DstPort: 53,
+			Length:  uint16(len(b)),
+		})
+		copy(udp.Payload(), data)
+		if withChecksum {
+			header.FillUDPChecksum(udp[:header.UDPMinimumSize], testIPv4SrcAddr, testIPv4DstAddr, header.IPv4ProtocolNumber, buffer.View(data).ToVectorisedView())
+		}
+		return b
+	}
+
+	tests := []struct {
+		name          string
+		proto         tcpip.TransportProtocolNumber
+		payload       []byte
+		checkChecksum func(*testing.T, []byte)
+	}{
+		{
+			name:    "TCP",
+			proto:   header.TCPProtocolNumber,
+			payload: makeTCP(),
+			checkChecksum: func(t *testing.T, payload []byte) {
+				if !header.TCP(payload).IsChecksumValid(newSrc, newDst, header.Checksum(data, 0), uint16(len(data))) {
+					t.Error("got invalid TCP checksum after rewrite")
+				}
+			},
+		},
+		{
+			name:    "UDP",
+			proto:   header.UDPProtocolNumber,
+			payload: makeUDP(true /* withChecksum */),
+			checkChecksum: func(t *testing.T, payload []byte) {
+				if !header.UDP(payload).IsChecksumValid(newSrc, newDst, header.Checksum(data, 0)) {
+					t.Error("got invalid UDP checksum after rewrite")
+				}
+			},
+		},
+		{
+			name:    "UDP without checksum",
+			proto:   header.UDPProtocolNumber,
+			payload: makeUDP(false /* withChecksum */),
+			checkChecksum: func(t *testing.T, payload []byte) {
+				if got := header.UDP(payload).Checksum(); got != 0 {
+					t.Errorf("got UDP Checksum() = 0x%04x, want = 0", got)
+				}
+			},
+		},
+		{
+			name:    "ICMP",
+			proto:   header.ICMPv4ProtocolNumber,
+			payload: []byte{8, 0, 0xf7, 0xff, 0, 0, 0, 0},
+			checkChecksum: func(t *testing.T, payload []byte) {
+				if want := []byte{8, 0, 0xf7, 0xff, 0, 0, 0, 0}; !bytes.Equal(payload, want) {
+					t.Errorf("got ICMP message = %x, want = %x", payload, want)
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pkt := makeIPv4Packet(header.IPv4Fields{Protocol: uint8(test.proto)}, test.payload)
+			if !header.NATRewriteIPv4(pkt, newSrc, newDst) {
+				t.Fatal("got NATRewriteIPv4(...) = false, want = true")
+			}
+
+			ipv4 := header.IPv4(pkt)
+			if got := ipv4.SourceAddress(); got != newSrc {
+				t.Errorf("got SourceAddress() = %s, want = %s", got, newSrc)
+			}
+			if got := ipv4.DestinationAddress(); got != newDst {
+				t.Errorf("got DestinationAddress() = %s, want = %s", got, newDst)
+			}
+			if got := ipv4.CalculateChecksum(); got != 0xffff {
+				t.Errorf("got IPv4 CalculateChecksum() = 0x%04x, want = 0xffff", got)
+			}
+			test.checkChecksum(t, ipv4.Payload())
+		})
+	}
+}
+
+func TestNATRewriteIPv4Invalid(t *testing.T) {
+	pkt := makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber)}, testUDPHeader)
+	pkt = pkt[:len(pkt)-1]
+	orig := append([]byte(nil), pkt...)
+	if header.NATRewriteIPv4(pkt, testIPv4DstAddr, testIPv4SrcAddr) {
+		t.Error("got NATRewriteIPv4(...) = true, want = false")
+	}
+	if !bytes.Equal(pkt, orig) {
+		t.Errorf("got packet = %x, want unmodified = %x", pkt, orig)
+	}
+}
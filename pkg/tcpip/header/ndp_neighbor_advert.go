@@ -14,12 +14,32 @@
 
 package header
 
-import "gvisor.dev/gvisor/pkg/tcpip"
+import (
+	"fmt"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
 
 // NDPNeighborAdvert is an NDP Neighbor Advertisement message. It will
 // only contain the body of an ICMPv6 packet.
 //
 // See RFC 4861 section 4.4 for more details.
+//
+// As per RFC 4861 section 7.2.5, the Solicited (S) and Override (O) flags
+// determine how an advertisement updates an existing neighbor cache entry:
+//
+//	Entry state  S  O  Link-layer address   Result
+//	INCOMPLETE   0  -  supplied             record address, STALE
+//	INCOMPLETE   1  -  supplied             record address, REACHABLE
+//	any other    -  0  differs from cached  REACHABLE becomes STALE, address
+//	                                        is not updated
+//	any other    0  1  differs from cached  update address, STALE
+//	any other    1  1  differs from cached  update address, REACHABLE
+//	any other    1  -  same or not supplied REACHABLE
+//	any other    0  -  same or not supplied unchanged
+//
+// In every case where the entry is updated, the Router (R) flag updates the
+// entry's IsRouter flag.
 type NDPNeighborAdvert []byte
 
 const (
@@ -108,3 +128,51 @@ func (b NDPNeighborAdvert) SetOverrideFlag(f bool) {
 func (b NDPNeighborAdvert) Options() NDPOptions {
 	return NDPOptions(b[ndpNAOptionsOffset:])
 }
+
+// BuildNeighborAdvert returns an IPv6 packet carrying an NDP Neighbor
+// Advertisement for target, sent from target to dst in response to a Neighbor
+// Solicitation sent from dst. The Router, Solicited and Override flags are set
+// as given and a Target Link-Layer Address option is included with srcMAC.
+//
+// If dst is the unspecified address, the solicitation was a Duplicate Address
+// Detection probe and, as per RFC 4861 section 7.2.4, the advertisement is
+// sent to the all-nodes multicast address with the Solicited flag cleared.
+func BuildNeighborAdvert(srcMAC tcpip.LinkAddress, target tcpip.Address, solicited, override, router bool, dst tcpip.Address) []byte {
+	if len(target) != IPv6AddressSize {
+		panic(fmt.Sprintf("got len(target) = %d, want = %d", len(target), IPv6AddressSize))
+	}
+	if len(dst) != IPv6AddressSize {
+		panic(fmt.Sprintf("got len(dst) = %d, want = %d", len(dst), IPv6AddressSize))
+	}
+
+	if dst == IPv6Any {
+		dst = IPv6AllNodesMulticastAddress
+		solicited = false
+	}
+	opts := NDPOptionsSerializer{NDPTargetLinkLayerAddressOption(srcMAC)}
+
+	icmpLen := ICMPv6NeighborAdvertMinimumSize + opts.Length()
+	b := make([]byte, IPv6MinimumSize+icmpLen)
+	IPv6(b).Encode(&IPv6Fields{
+		PayloadLength:     uint16(icmpLen),
+		TransportProtocol: ICMPv6ProtocolNumber,
+		HopLimit:          NDPHopLimit,
+		SrcAddr:           target,
+		DstAddr:           dst,
+	})
+
+	pkt := ICMPv6(b[IPv6MinimumSize:])
+	pkt.SetType(ICMPv6NeighborAdvert)
+	na := NDPNeighborAdvert(pkt.MessageBody())
+	na.SetRouterFlag(router)
+	na.SetSolicitedFlag(solicited)
+	na.SetOverrideFlag(override)
+	na.SetTargetAddress(target)
+	na.Options().Serialize(opts)
+	pkt.SetChecksum(ICMPv6Checksum(ICMPv6ChecksumParams{
+		Header: pkt,
+		Src:    target,
+		Dst:    dst,
+	}))
+	return b
+}
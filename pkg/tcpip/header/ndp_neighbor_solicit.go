@@ -14,7 +14,11 @@
 
 package header
 
-import "gvisor.dev/gvisor/pkg/tcpip"
+import (
+	"fmt"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
 
 // NDPNeighborSolicit is an NDP Neighbor Solicitation message. It will only
 // contain the body of an ICMPv6 packet.
@@ -50,3 +54,47 @@ func (b NDPNeighborSolicit) SetTargetAddress(addr tcpip.Address) {
 func (b NDPNeighborSolicit) Options() NDPOptions {
 	return NDPOptions(b[ndpNSOptionsOffset:])
 }
+
+// BuildNeighborSolicit returns an IPv6 packet carrying an NDP Neighbor
+// Solicitation for target, sent from src to the solicited-node multicast
+// address of target.
+//
+// If src is the unspecified address, the packet is a Duplicate Address
+// Detection probe and, as per RFC 4861 section 4.3, must not include a Source
+// Link-Layer Address option. Otherwise, the option is included with srcMAC.
+func BuildNeighborSolicit(srcMAC tcpip.LinkAddress, src, target tcpip.Address) []byte {
+	if len(src) != IPv6AddressSize {
+		panic(fmt.Sprintf("got len(src) = %d, want = %d", len(src), IPv6AddressSize))
+	}
+	if len(target) != IPv6AddressSize {
+		panic(fmt.Sprintf("got len(target) = %d, want = %d", len(target), IPv6AddressSize))
+	}
+
+	var opts NDPOptionsSerializer
+	if src != IPv6Any {
+		opts = NDPOptionsSerializer{NDPSourceLinkLayerAddressOption(srcMAC)}
+	}
+	dst := SolicitedNodeAddr(target)
+
+	icmpLen := ICMPv6NeighborSolicitMinimumSize + opts.Length()
+	b := make([]byte, IPv6MinimumSize+icmpLen)
+	IPv6(b).Encode(&IPv6Fields{
+		PayloadLength:     uint16(icmpLen),
+		TransportProtocol: ICMPv6ProtocolNumber,
+		HopLimit:          NDPHopLimit,
+		SrcAddr:           src,
+		DstAddr:           dst,
+	})
+
+	pkt := ICMPv6(b[IPv6MinimumSize:])
+	pkt.SetType(ICMPv6NeighborSolicit)
+	ns := NDPNeighborSolicit(pkt.MessageBody())
+	ns.SetTargetAddress(target)
+	ns.Options().Serialize(opts)
+	pkt.SetChecksum(ICMPv6Checksum(ICMPv6ChecksumParams{
+		Header: pkt,
+		Src:    src,
+		Dst:    dst,
+	}))
+	return b
+}
@@ -42,6 +42,14 @@ const (
 	// option, as per RFC 4861 section 4.6.2.
 	ndpPrefixInformationType ndpOptionIdentifier = 3
 
+	// ndpRedirectedHeaderOptionType is the type of the Redirected Header
+	// option, as per RFC 4861 section 4.6.3.
+	ndpRedirectedHeaderOptionType ndpOptionIdentifier = 4
+
+	// ndpMTUOptionType is the type of the MTU option, as per RFC 4861
+	// section 4.6.4.
+	ndpMTUOptionType ndpOptionIdentifier = 5
+
 	// ndpNonceOptionType is the type of the Nonce option, as per
 	// RFC 3971 section 5.3.2.
 	ndpNonceOptionType ndpOptionIdentifier = 14
@@ -110,6 +118,19 @@ const (
 	// within an NDPPrefixInformation.
 	ndpPrefixInformationPrefixOffset = 14
 
+	// ndpRedirectedHeaderReservedLength is the length of the Reserved field
+	// at the start of an NDPRedirectedHeader, before the original packet.
+	ndpRedirectedHeaderReservedLength = 6
+
+	// ndpMTUOptionLength is the expected length, in bytes, of the body of
+	// an NDP MTU option, as per RFC 4861 section 4.6.4 which specifies that
+	// the Length field is 1.
+	ndpMTUOptionLength = 6
+
+	// ndpMTUOptionMTUOffset is the start of the 4-byte MTU field within an
+	// NDPMTUOption, after 2 reserved bytes.
+	ndpMTUOptionMTUOffset = 2
+
 	// ndpRecursiveDNSServerLifetimeOffset is the start of the 4-byte
 	// Lifetime field within an NDPRecursiveDNSServer.
 	ndpRecursiveDNSServerLifetimeOffset = 2
@@ -189,44 +210,11 @@ func (i *NDPOptionIterator) Next() (NDPOption, bool, error) {
 			return nil, true, nil
 		}
 
-		// Get the Type field.
-		temp, err := i.opts.ReadByte()
-		if err != nil {
-			if err != io.EOF {
-				// ReadByte should only ever return nil or io.EOF.
-				panic(fmt.Sprintf("unexpected error when reading the option's Type field: %s", err))
-			}
-
-			// We use io.ErrUnexpectedEOF as exhausting the buffer is unexpected once
-			// we start parsing an option; we expect the buffer to contain enough
-			// bytes for the whole option.
-			return nil, true, fmt.Errorf("unexpectedly exhausted buffer when reading the option's Type field: %w", io.ErrUnexpectedEOF)
-		}
-		kind := ndpOptionIdentifier(temp)
-
-		// Get the Length field.
-		length, err := i.opts.ReadByte()
+		kind, body, err := readNDPOption(i.opts)
 		if err != nil {
-			if err != io.EOF {
-				panic(fmt.Sprintf("unexpected error when reading the option's Length field for %s: %s", kind, err))
-			}
-
-			return nil, true, fmt.Errorf("unexpectedly exhausted buffer when reading the option's Length field for %s: %w", kind, io.ErrUnexpectedEOF)
-		}
-
-		// This would indicate an erroneous NDP option as the Length field should
-		// never be 0.
-		if length == 0 {
-			return nil, true, fmt.Errorf("zero valued Length field for %s: %w", kind, ErrNDPOptMalformedHeader)
-		}
-
-		// Get the body.
-		numBytes := int(length) * lengthByteUnits
-		numBodyBytes := numBytes - 2
-		body := i.opts.Next(numBodyBytes)
-		if len(body) < numBodyBytes {
-			return nil, true, fmt.Errorf("unexpectedly exhausted buffer when reading the option's Body for %s: %w", kind, io.ErrUnexpectedEOF)
+			return nil, true, err
 		}
+		numBodyBytes := len(body)
 
 		switch kind {
 		case ndpSourceLinkLayerAddressOptionType:
@@ -248,6 +236,18 @@ func (i *NDPOptionIterator) Next() (NDPOption, bool, error) {
 
 			return NDPPrefixInformation(body), false, nil
 
+		case ndpRedirectedHeaderOptionType:
+			return NDPRedirectedHeader(body), false, nil
+
+		case ndpMTUOptionType:
+			// Make sure the length of an MTU option body is
+			// ndpMTUOptionLength, as per RFC 4861 section 4.6.4.
+			if numBodyBytes != ndpMTUOptionLength {
+				return nil, true, fmt.Errorf("got %d bytes for NDP MTU option's body, expected %d bytes: %w", numBodyBytes, ndpMTUOptionLength, ErrNDPOptMalformedBody)
+			}
+
+			return NDPMTUOption(body), false, nil
+
 		case ndpRecursiveDNSServerOptionType:
 			opt := NDPRecursiveDNSServer(body)
 			if err := opt.checkAddresses(); err != nil {
@@ -276,6 +276,53 @@ func (i *NDPOptionIterator) Next() (NDPOption, bool, error) {
 	}
 }
 
+// readNDPOption reads the next NDP option from opts, returning its type and
+// body.
+//
+// opts must not be empty.
+func readNDPOption(opts *bytes.Buffer) (ndpOptionIdentifier, []byte, error) {
+	// Get the Type field.
+	temp, err := opts.ReadByte()
+	if err != nil {
+		if err != io.EOF {
+			// ReadByte should only ever return nil or io.EOF.
+			panic(fmt.Sprintf("unexpected error when reading the option's Type field: %s", err))
+		}
+
+		// We use io.ErrUnexpectedEOF as exhausting the buffer is unexpected once
+		// we start parsing an option; we expect the buffer to contain enough
+		// bytes for the whole option.
+		return 0, nil, fmt.Errorf("unexpectedly exhausted buffer when reading the option's Type field: %w", io.ErrUnexpectedEOF)
+	}
+	kind := ndpOptionIdentifier(temp)
+
+	// Get the Length field.
+	length, err := opts.ReadByte()
+	if err != nil {
+		if err != io.EOF {
+			panic(fmt.Sprintf("unexpected error when reading the option's Length field for %s: %s", kind, err))
+		}
+
+		return 0, nil, fmt.Errorf("unexpectedly exhausted buffer when reading the option's Length field for %s: %w", kind, io.ErrUnexpectedEOF)
+	}
+
+	// This would indicate an erroneous NDP option as the Length field should
+	// never be 0.
+	if length == 0 {
+		return 0, nil, fmt.Errorf("zero valued Length field for %s: %w", kind, ErrNDPOptMalformedHeader)
+	}
+
+	// Get the body.
+	numBytes := int(length) * lengthByteUnits
+	numBodyBytes := numBytes - 2
+	body := opts.Next(numBodyBytes)
+	if len(body) < numBodyBytes {
+		return 0, nil, fmt.Errorf("unexpectedly exhausted buffer when reading the option's Body for %s: %w", kind, io.ErrUnexpectedEOF)
+	}
+
+	return kind, body, nil
+}
+
 // NDPOptions is a buffer of NDP options as defined by RFC 4861 section 4.6.
 type NDPOptions []byte
 
@@ -305,6 +352,51 @@ func (b NDPOptions) Iter(check bool) (NDPOptionIterator, error) {
 	return it, nil
 }
 
+// NDPRawOption is an NDP option of any type, as defined by RFC 4861 section
+// 4.6.
+type NDPRawOption struct {
+	// Type is the option's Type field.
+	Type uint8
+
+	// Body is the option's data following the Type and Length fields.
+	Body []byte
+}
+
+// NDPRawOptionIterator is an iterator over the options of an NDPOptions that
+// yields every option as an NDPRawOption, leaving the interpretation of the
+// body to the caller.
+//
+// Unlike NDPOptionIterator, unrecognized options are not skipped and option
+// bodies are not validated.
+//
+// The same restrictions on modifying the backing NDPOptions as with
+// NDPOptionIterator apply.
+type NDPRawOptionIterator struct {
+	opts *bytes.Buffer
+}
+
+// Next returns the next option in the backing NDPOptions, or true if we are
+// done, or false if an error occured.
+//
+// An option with a zero valued Length field results in an error wrapping
+// ErrNDPOptMalformedHeader.
+func (i *NDPRawOptionIterator) Next() (NDPRawOption, bool, error) {
+	if i.opts.Len() == 0 {
+		return NDPRawOption{}, true, nil
+	}
+
+	kind, body, err := readNDPOption(i.opts)
+	if err != nil {
+		return NDPRawOption{}, true, err
+	}
+	return NDPRawOption{Type: uint8(kind), Body: body}, false, nil
+}
+
+// RawIter returns an NDPRawOptionIterator over the options in b.
+func (b NDPOptions) RawIter() NDPRawOptionIterator {
+	return NDPRawOptionIterator{opts: bytes.NewBuffer(b)}
+}
+
 // Serialize serializes the provided list of NDP options into b.
 //
 // Note, b must be of sufficient size to hold all the options in s. See
@@ -654,6 +746,118 @@ func (o NDPPrefixInformation) Subnet() tcpip.Subnet {
 	return addrWithPrefix.Subnet()
 }
 
+// BuildPrefixInfoOption returns a serialized NDP Prefix Information option,
+// including its Type and Length fields, advertising prefix/prefixLen with the
+// given flags and lifetimes (in seconds), as per RFC 4861 section 4.6.2.
+//
+// The bits of prefix after the first prefixLen bits are zeroed, as required
+// by RFC 4861.
+func BuildPrefixInfoOption(prefix tcpip.Address, prefixLen uint8, onLink, autonomous bool, validLifetime, preferredLifetime uint32) []byte {
+	if len(prefix) != IPv6AddressSize {
+		panic(fmt.Sprintf("got len(prefix) = %d, want = %d", len(prefix), IPv6AddressSize))
+	}
+	if prefixLen > IPv6AddressSize*8 {
+		panic(fmt.Sprintf("got prefixLen = %d, want <= %d", prefixLen, IPv6AddressSize*8))
+	}
+
+	pi := NDPPrefixInformation(make([]byte, ndpPrefixInformationLength))
+	pi[ndpPrefixInformationPrefixLengthOffset] = prefixLen
+	if onLink {
+		pi[ndpPrefixInformationFlagsOffset] |= ndpPrefixInformationOnLinkFlagMask
+	}
+	if autonomous {
+		pi[ndpPrefixInformationFlagsOffset] |= ndpPrefixInformationAutoAddrConfFlagMask
+	}
+	binary.BigEndian.PutUint32(pi[ndpPrefixInformationValidLifetimeOffset:], validLifetime)
+	binary.BigEndian.PutUint32(pi[ndpPrefixInformationPreferredLifetimeOffset:], preferredLifetime)
+	subnet := tcpip.AddressWithPrefix{Address: prefix, PrefixLen: int(prefixLen)}.Subnet()
+	copy(pi[ndpPrefixInformationPrefixOffset:], subnet.ID())
+
+	opts := NDPOptionsSerializer{pi}
+	b := make([]byte, opts.Length())
+	NDPOptions(b).Serialize(opts)
+	return b
+}
+
+// NDPRedirectedHeader is the NDP Redirected Header option as defined by RFC
+// 4861 section 4.6.3.
+//
+// It holds 6 reserved bytes followed by as much of the packet that triggered
+// the Redirect as fits without the Redirect exceeding the minimum IPv6 MTU.
+type NDPRedirectedHeader []byte
+
+// kind implements NDPOption.
+func (NDPRedirectedHeader) kind() ndpOptionIdentifier {
+	return ndpRedirectedHeaderOptionType
+}
+
+// length implements NDPOption.
+func (o NDPRedirectedHeader) length() int {
+	return len(o)
+}
+
+// serializeInto implements NDPOption.
+func (o NDPRedirectedHeader) serializeInto(b []byte) int {
+	used := copy(b, o)
+
+	// Zero out the Reserved field.
+	reserved := b[:ndpRedirectedHeaderReservedLength]
+	for i := range reserved {
+		reserved[i] = 0
+	}
+
+	return used
+}
+
+// String implements fmt.Stringer.
+func (o NDPRedirectedHeader) String() string {
+	return fmt.Sprintf("%T(%x)", o, o.Datagram())
+}
+
+// Datagram returns the original IP packet embedded in the option, which may
+// have been truncated by the sender and padded to a multiple of 8 bytes.
+func (o NDPRedirectedHeader) Datagram() []byte {
+	return o[ndpRedirectedHeaderReservedLength:]
+}
+
+// NDPMTUOption is the NDP MTU option, as defined by RFC 4861 section 4.6.4.
+//
+// It is sent in Router Advertisements on links with a variable MTU so that
+// all nodes use the same MTU.
+type NDPMTUOption []byte
+
+// kind implements NDPOption.
+func (NDPMTUOption) kind() ndpOptionIdentifier {
+	return ndpMTUOptionType
+}
+
+// length implements NDPOption.
+func (o NDPMTUOption) length() int {
+	return len(o)
+}
+
+// serializeInto implements NDPOption.
+func (o NDPMTUOption) serializeInto(b []byte) int {
+	used := copy(b, o)
+
+	// Zero out the Reserved field.
+	for i := 0; i < ndpMTUOptionMTUOffset; i++ {
+		b[i] = 0
+	}
+
+	return used
+}
+
+// String implements fmt.Stringer.
+func (o NDPMTUOption) String() string {
+	return fmt.Sprintf("%T(%d)", o, o.MTU())
+}
+
+// MTU returns the recommended MTU for the link.
+func (o NDPMTUOption) MTU() uint32 {
+	return binary.BigEndian.Uint32(o[ndpMTUOptionMTUOffset:])
+}
+
 // NDPRecursiveDNSServer is the NDP Recursive DNS Server option, as defined by
 // RFC 8106 section 5.1.
 //
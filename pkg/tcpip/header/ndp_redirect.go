@@ -0,0 +1,66 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import "gvisor.dev/gvisor/pkg/tcpip"
+
+// NDPRedirect is an NDP Redirect message. It will only contain the body of an
+// ICMPv6 packet.
+//
+// See RFC 4861 section 4.5 for more details.
+type NDPRedirect []byte
+
+const (
+	// NDPRedirectMinimumSize is the minimum size of a valid NDP Redirect
+	// message (body of an ICMPv6 packet).
+	NDPRedirectMinimumSize = 36
+
+	// ndpRedirectTargetAddressOffset is the start of the Target Address
+	// field within an NDPRedirect.
+	ndpRedirectTargetAddressOffset = 4
+
+	// ndpRedirectDestinationAddressOffset is the start of the Destination
+	// Address field within an NDPRedirect.
+	ndpRedirectDestinationAddressOffset = ndpRedirectTargetAddressOffset + IPv6AddressSize
+
+	// ndpRedirectOptionsOffset is the start of the NDP options in an
+	// NDPRedirect.
+	ndpRedirectOptionsOffset = ndpRedirectDestinationAddressOffset + IPv6AddressSize
+)
+
+// TargetAddress returns the value within the Target Address field.
+func (b NDPRedirect) TargetAddress() tcpip.Address {
+	return tcpip.Address(b[ndpRedirectTargetAddressOffset:][:IPv6AddressSize])
+}
+
+// SetTargetAddress sets the value within the Target Address field.
+func (b NDPRedirect) SetTargetAddress(addr tcpip.Address) {
+	copy(b[ndpRedirectTargetAddressOffset:][:IPv6AddressSize], addr)
+}
+
+// DestinationAddress returns the value within the Destination Address field.
+func (b NDPRedirect) DestinationAddress() tcpip.Address {
+	return tcpip.Address(b[ndpRedirectDestinationAddressOffset:][:IPv6AddressSize])
+}
+
+// SetDestinationAddress sets the value within the Destination Address field.
+func (b NDPRedirect) SetDestinationAddress(addr tcpip.Address) {
+	copy(b[ndpRedirectDestinationAddressOffset:][:IPv6AddressSize], addr)
+}
+
+// Options returns an NDPOptions of the the options body.
+func (b NDPRedirect) Options() NDPOptions {
+	return NDPOptions(b[ndpRedirectOptionsOffset:])
+}
@@ -110,3 +110,24 @@ func (b NDPRouterAdvert) RetransTimer() time.Duration {
 func (b NDPRouterAdvert) Options() NDPOptions {
 	return NDPOptions(b[ndpRAOptionsOffset:])
 }
+
+// MTUOption returns the MTU advertised in the first MTU option of the Router
+// Advertisement, as per RFC 4861 section 4.6.4.
+//
+// The returned bool is false if the options are malformed or do not include
+// an MTU option.
+func (b NDPRouterAdvert) MTUOption() (uint32, bool) {
+	it, err := b.Options().Iter(true)
+	if err != nil {
+		return 0, false
+	}
+	for {
+		opt, done, err := it.Next()
+		if err != nil || done {
+			return 0, false
+		}
+		if mtu, ok := opt.(NDPMTUOption); ok {
+			return mtu.MTU(), true
+		}
+	}
+}
@@ -124,6 +124,55 @@ func TestNDPNeighborAdvert(t *testing.T) {
 	}
 }
 
+// TestNDPNeighborAdvertFlags tests that every combination of the Router,
+// Solicited and Override flags is set and read back independently.
+func TestNDPNeighborAdvertFlags(t *testing.T) {
+	for _, router := range []bool{false, true} {
+		for _, solicited := range []bool{false, true} {
+			for _, override := range []bool{false, true} {
+				t.Run(fmt.Sprintf("R=%t S=%t O=%t", router, solicited, override), func(t *testing.T) {
+					b := make([]byte, NDPNAMinimumSize)
+					// Set the reserved bits to make sure they are preserved.
+					b[ndpNAFlagsOffset] = 0x1f
+					b[ndpNAFlagsOffset+1] = 0xff
+
+					na := NDPNeighborAdvert(b)
+					na.SetRouterFlag(router)
+					na.SetSolicitedFlag(solicited)
+					na.SetOverrideFlag(override)
+
+					if got := na.RouterFlag(); got != router {
+						t.Errorf("got RouterFlag = %t, want = %t", got, router)
+					}
+					if got := na.SolicitedFlag(); got != solicited {
+						t.Errorf("got SolicitedFlag = %t, want = %t", got, solicited)
+					}
+					if got := na.OverrideFlag(); got != override {
+						t.Errorf("got OverrideFlag = %t, want = %t", got, override)
+					}
+
+					want := byte(0x1f)
+					if router {
+						want |= ndpNARouterFlagMask
+					}
+					if solicited {
+						want |= ndpNASolicitedFlagMask
+					}
+					if override {
+						want |= ndpNAOverrideFlagMask
+					}
+					if got := b[ndpNAFlagsOffset]; got != want {
+						t.Errorf("got flags byte = %#x, want = %#x", got, want)
+					}
+					if got := b[ndpNAFlagsOffset+1]; got != 0xff {
+						t.Errorf("got reserved byte = %#x, want = 0xff", got)
+					}
+				})
+			}
+		}
+	}
+}
+
 func TestNDPRouterAdvert(t *testing.T) {
 	b := []byte{
 		64, 128, 1, 2,
@@ -158,6 +207,62 @@ func TestNDPRouterAdvert(t *testing.T) {
 	}
 }
 
+func TestNDPRouterAdvertMTUOption(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    []byte
+		wantMTU uint32
+		wantOK  bool
+	}{
+		{
+			name: "MTU 1480",
+			opts: []byte{
+				// Source Link-Layer Address option.
+				1, 1, 2, 3, 4, 5, 6, 7,
+				// MTU option with non-zero reserved bytes.
+				5, 1, 0xff, 0xff, 0, 0, 0x05, 0xc8,
+			},
+			wantMTU: 1480,
+			wantOK:  true,
+		},
+		{
+			name: "no MTU option",
+			opts: []byte{1, 1, 2, 3, 4, 5, 6, 7},
+		},
+		{
+			name: "malformed MTU option",
+			opts: []byte{
+				5, 2, 0, 0, 0, 0, 0x05, 0xc8,
+				0, 0, 0, 0, 0, 0, 0, 0,
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ra := NDPRouterAdvert(append(make([]byte, ndpRAOptionsOffset), test.opts...))
+			mtu, ok := ra.MTUOption()
+			if mtu != test.wantMTU || ok != test.wantOK {
+				t.Errorf("got ra.MTUOption() = (%d, %t), want = (%d, %t)", mtu, ok, test.wantMTU, test.wantOK)
+			}
+		})
+	}
+
+	t.Run("serialize", func(t *testing.T) {
+		opts := NDPOptionsSerializer{
+			NDPMTUOption([]byte{0xff, 0xff, 0, 0, 0x05, 0xc8}),
+		}
+		b := make([]byte, opts.Length())
+		NDPOptions(b).Serialize(opts)
+		if want := []byte{5, 1, 0, 0, 0, 0, 0x05, 0xc8}; !bytes.Equal(b, want) {
+			t.Errorf("got serialized MTU option = %x, want = %x", b, want)
+		}
+		if got, want := NDPMTUOption(b[2:]).String(), "header.NDPMTUOption(1480)"; got != want {
+			t.Errorf("got String() = %q, want = %q", got, want)
+		}
+	})
+}
+
 // TestNDPSourceLinkLayerAddressOptionEthernetAddress tests getting the
 // Ethernet address from an NDPSourceLinkLayerAddressOption.
 func TestNDPSourceLinkLayerAddressOptionEthernetAddress(t *testing.T) {
@@ -1450,3 +1555,379 @@ func TestNDPOptionsIter(t *testing.T) {
 		t.Errorf("got Next = (%x, _, _), want = (nil, _, _)", next)
 	}
 }
+
+func TestNDPOptionsRawIter(t *testing.T) {
+	srcLinkAddr := []byte{
+		// Source Link-Layer Address.
+		1, 1, 1, 2, 3, 4, 5, 6,
+	}
+	unknown := []byte{
+		// 255 is an unrecognized type. Unlike with Iter, it should not be
+		// skipped.
+		255, 1, 1, 2, 3, 4, 5, 6,
+	}
+	prefixInfo := []byte{
+		// Prefix information.
+		3, 4, 43, 64,
+		1, 2, 3, 4,
+		5, 6, 7, 8,
+		0, 0, 0, 0,
+		9, 10, 11, 12,
+		13, 14, 15, 16,
+		17, 18, 19, 20,
+		21, 22, 23, 24,
+	}
+	zeroLength := []byte{
+		// Target Link-Layer Address with a zero valued Length field.
+		2, 0, 7, 8, 9, 10, 11, 12,
+	}
+
+	tests := []struct {
+		name    string
+		buf     []byte
+		want    []NDPRawOption
+		wantErr error
+	}{
+		{
+			name: "Valid",
+			buf:  append(append(append([]byte(nil), srcLinkAddr...), unknown...), prefixInfo...),
+			want: []NDPRawOption{
+				{Type: uint8(ndpSourceLinkLayerAddressOptionType), Body: srcLinkAddr[2:]},
+				{Type: 255, Body: unknown[2:]},
+				{Type: uint8(ndpPrefixInformationType), Body: prefixInfo[2:]},
+			},
+		},
+		{
+			name: "ZeroLength",
+			buf:  append(append([]byte(nil), srcLinkAddr...), zeroLength...),
+			want: []NDPRawOption{
+				{Type: uint8(ndpSourceLinkLayerAddressOptionType), Body: srcLinkAddr[2:]},
+			},
+			wantErr: ErrNDPOptMalformedHeader,
+		},
+		{
+			name:    "TruncatedBody",
+			buf:     prefixInfo[:len(prefixInfo)-1],
+			wantErr: io.ErrUnexpectedEOF,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			it := NDPOptions(test.buf).RawIter()
+			var got []NDPRawOption
+			for {
+				opt, done, err := it.Next()
+				if done {
+					if !errors.Is(err, test.wantErr) {
+						t.Fatalf("got Next = (_, true, %v), want = (_, true, %v)", err, test.wantErr)
+					}
+					break
+				}
+				if err != nil {
+					t.Fatalf("got Next = (_, false, %s), want = (_, false, nil)", err)
+				}
+				got = append(got, opt)
+			}
+			if diff := cmp.Diff(test.want, got); diff != "" {
+				t.Errorf("options mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestBuildPrefixInfoOption(t *testing.T) {
+	const (
+		prefixLen         = 48
+		validLifetime     = 86400
+		preferredLifetime = 14400
+	)
+	prefix := tcpip.Address("\x20\x01\x0d\xb8\x00\x01\xff\xff\x00\x00\x00\x00\x00\x00\x00\x01")
+	wantPrefix := tcpip.Address("\x20\x01\x0d\xb8\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00")
+
+	for _, test := range []struct {
+		name       string
+		onLink     bool
+		autonomous bool
+	}{
+		{name: "no flags"},
+		{name: "on-link", onLink: true},
+		{name: "autonomous", autonomous: true},
+		{name: "on-link and autonomous", onLink: true, autonomous: true},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			b := BuildPrefixInfoOption(prefix, prefixLen, test.onLink, test.autonomous, validLifetime, preferredLifetime)
+			if got, want := len(b), 32; got != want {
+				t.Fatalf("got len(b) = %d, want = %d", got, want)
+			}
+
+			it, err := NDPOptions(b).Iter(true)
+			if err != nil {
+				t.Fatalf("got Iter = (_, %s), want = (_, nil)", err)
+			}
+			opt, done, err := it.Next()
+			if err != nil || done {
+				t.Fatalf("got Next = (_, %t, %v), want = (_, false, nil)", done, err)
+			}
+			pi, ok := opt.(NDPPrefixInformation)
+			if !ok {
+				t.Fatalf("got opt = %T, want = NDPPrefixInformation", opt)
+			}
+			if got := pi.PrefixLength(); got != prefixLen {
+				t.Errorf("got pi.PrefixLength() = %d, want = %d", got, prefixLen)
+			}
+			if got := pi.OnLinkFlag(); got != test.onLink {
+				t.Errorf("got pi.OnLinkFlag() = %t, want = %t", got, test.onLink)
+			}
+			if got := pi.AutonomousAddressConfigurationFlag(); got != test.autonomous {
+				t.Errorf("got pi.AutonomousAddressConfigurationFlag() = %t, want = %t", got, test.autonomous)
+			}
+			if got, want := pi.ValidLifetime(), validLifetime*time.Second; got != want {
+				t.Errorf("got pi.ValidLifetime() = %s, want = %s", got, want)
+			}
+			if got, want := pi.PreferredLifetime(), preferredLifetime*time.Second; got != want {
+				t.Errorf("got pi.PreferredLifetime() = %s, want = %s", got, want)
+			}
+			if got := pi.Prefix(); got != wantPrefix {
+				t.Errorf("got pi.Prefix() = %s, want = %s", got, wantPrefix)
+			}
+
+			if _, done, err := it.Next(); err != nil || !done {
+				t.Errorf("got Next = (_, %t, %v), want = (_, true, nil)", done, err)
+			}
+		})
+	}
+}
+
+func TestBuildNeighborSolicit(t *testing.T) {
+	const (
+		srcMAC = tcpip.LinkAddress("\x02\x03\x04\x05\x06\x07")
+		src    = tcpip.Address("\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+		target = tcpip.Address("\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xab\xcd\xef")
+	)
+	wantDst := SolicitedNodeAddr(target)
+
+	for _, test := range []struct {
+		name       string
+		src        tcpip.Address
+		wantOpts   []byte
+		wantLength int
+	}{
+		{
+			name:       "DAD",
+			src:        IPv6Any,
+			wantLength: IPv6MinimumSize + ICMPv6NeighborSolicitMinimumSize,
+		},
+		{
+			name:       "resolution",
+			src:        src,
+			wantOpts:   []byte{1, 1, 2, 3, 4, 5, 6, 7},
+			wantLength: IPv6MinimumSize + ICMPv6NeighborSolicitMinimumSize + 8,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			b := BuildNeighborSolicit(srcMAC, test.src, target)
+			if len(b) != test.wantLength {
+				t.Fatalf("got len(b) = %d, want = %d", len(b), test.wantLength)
+			}
+
+			ip := IPv6(b)
+			if !ip.IsValid(len(b)) {
+				t.Fatal("got ip.IsValid(_) = false, want = true")
+			}
+			if got := ip.SourceAddress(); got != test.src {
+				t.Errorf("got ip.SourceAddress() = %s, want = %s", got, test.src)
+			}
+			if got := ip.DestinationAddress(); got != wantDst {
+				t.Errorf("got ip.DestinationAddress() = %s, want = %s", got, wantDst)
+			}
+			if got := ip.HopLimit(); got != NDPHopLimit {
+				t.Errorf("got ip.HopLimit() = %d, want = %d", got, NDPHopLimit)
+			}
+			if got := ip.TransportProtocol(); got != ICMPv6ProtocolNumber {
+				t.Errorf("got ip.TransportProtocol() = %d, want = %d", got, ICMPv6ProtocolNumber)
+			}
+
+			pkt := ICMPv6(ip.Payload())
+			if got := pkt.Type(); got != ICMPv6NeighborSolicit {
+				t.Errorf("got pkt.Type() = %d, want = %d", got, ICMPv6NeighborSolicit)
+			}
+			if got, want := pkt.Checksum(), ICMPv6Checksum(ICMPv6ChecksumParams{Header: pkt, Src: test.src, Dst: wantDst}); got != want {
+				t.Errorf("got pkt.Checksum() = %d, want = %d", got, want)
+			}
+			ns := NDPNeighborSolicit(pkt.MessageBody())
+			if got := ns.TargetAddress(); got != target {
+				t.Errorf("got ns.TargetAddress() = %s, want = %s", got, target)
+			}
+			if got := []byte(ns.Options()); !bytes.Equal(got, test.wantOpts) {
+				t.Errorf("got ns.Options() = %x, want = %x", got, test.wantOpts)
+			}
+		})
+	}
+}
+
+func TestBuildNeighborAdvert(t *testing.T) {
+	const (
+		srcMAC = tcpip.LinkAddress("\x02\x03\x04\x05\x06\x07")
+		target = tcpip.Address("\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xab\xcd\xef")
+		dst    = tcpip.Address("\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+	)
+
+	for _, test := range []struct {
+		name          string
+		dst           tcpip.Address
+		router        bool
+		wantDst       tcpip.Address
+		wantSolicited bool
+	}{
+		{
+			name:          "solicited response",
+			dst:           dst,
+			wantDst:       dst,
+			wantSolicited: true,
+		},
+		{
+			name:          "solicited response from router",
+			dst:           dst,
+			router:        true,
+			wantDst:       dst,
+			wantSolicited: true,
+		},
+		{
+			name:          "DAD response",
+			dst:           IPv6Any,
+			wantDst:       IPv6AllNodesMulticastAddress,
+			wantSolicited: false,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			b := BuildNeighborAdvert(srcMAC, target, true /* solicited */, true /* override */, test.router, test.dst)
+			if got, want := len(b), IPv6MinimumSize+ICMPv6NeighborAdvertMinimumSize+8; got != want {
+				t.Fatalf("got len(b) = %d, want = %d", got, want)
+			}
+
+			ip := IPv6(b)
+			if !ip.IsValid(len(b)) {
+				t.Fatal("got ip.IsValid(_) = false, want = true")
+			}
+			if got := ip.SourceAddress(); got != target {
+				t.Errorf("got ip.SourceAddress() = %s, want = %s", got, target)
+			}
+			if got := ip.DestinationAddress(); got != test.wantDst {
+				t.Errorf("got ip.DestinationAddress() = %s, want = %s", got, test.wantDst)
+			}
+			if got := ip.HopLimit(); got != NDPHopLimit {
+				t.Errorf("got ip.HopLimit() = %d, want = %d", got, NDPHopLimit)
+			}
+			if got := ip.TransportProtocol(); got != ICMPv6ProtocolNumber {
+				t.Errorf("got ip.TransportProtocol() = %d, want = %d", got, ICMPv6ProtocolNumber)
+			}
+
+			pkt := ICMPv6(ip.Payload())
+			if got := pkt.Type(); got != ICMPv6NeighborAdvert {
+				t.Errorf("got pkt.Type() = %d, want = %d", got, ICMPv6NeighborAdvert)
+			}
+			if got, want := pkt.Checksum(), ICMPv6Checksum(ICMPv6ChecksumParams{Header: pkt, Src: target, Dst: test.wantDst}); got != want {
+				t.Errorf("got pkt.Checksum() = %d, want = %d", got, want)
+			}
+			na := NDPNeighborAdvert(pkt.MessageBody())
+			if got := na.TargetAddress(); got != target {
+				t.Errorf("got na.TargetAddress() = %s, want = %s", got, target)
+			}
+			if got := na.RouterFlag(); got != test.router {
+				t.Errorf("got na.RouterFlag() = %t, want = %t", got, test.router)
+			}
+			if got := na.SolicitedFlag(); got != test.wantSolicited {
+				t.Errorf("got na.SolicitedFlag() = %t, want = %t", got, test.wantSolicited)
+			}
+			if !na.OverrideFlag() {
+				t.Error("got na.OverrideFlag() = false, want = true")
+			}
+			if got, want := []byte(na.Options()), []byte{2, 1, 2, 3, 4, 5, 6, 7}; !bytes.Equal(got, want) {
+				t.Errorf("got na.Options() = %x, want = %x", got, want)
+			}
+		})
+	}
+}
+
+func TestNDPRedirectedHeader(t *testing.T) {
+	const (
+		target = tcpip.Address("\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+		src    = tcpip.Address("\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+		dst    = tcpip.Address("\x20\x01\x0d\xb8\x00\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02")
+	)
+
+	// Build the TCP-over-IPv6 packet that triggered the redirect.
+	original := make([]byte, IPv6MinimumSize+TCPMinimumSize)
+	IPv6(original).Encode(&IPv6Fields{
+		PayloadLength:     TCPMinimumSize,
+		TransportProtocol: TCPProtocolNumber,
+		HopLimit:          64,
+		SrcAddr:           src,
+		DstAddr:           dst,
+	})
+	TCP(original[IPv6MinimumSize:]).Encode(&TCPFields{
+		SrcPort:    1234,
+		DstPort:    80,
+		SeqNum:     1000,
+		DataOffset: TCPMinimumSize,
+		Flags:      TCPFlagSyn,
+	})
+
+	opts := NDPOptionsSerializer{
+		NDPRedirectedHeader(append(make([]byte, ndpRedirectedHeaderReservedLength), original...)),
+	}
+	icmp := ICMPv6(make([]byte, ICMPv6RedirectMinimumSize+opts.Length()))
+	icmp.SetType(ICMPv6RedirectMsg)
+	rd := NDPRedirect(icmp.MessageBody())
+	rd.SetTargetAddress(target)
+	rd.SetDestinationAddress(dst)
+	rd.Options().Serialize(opts)
+
+	// Parse the Redirect.
+	rd = NDPRedirect(icmp.MessageBody())
+	if got := rd.TargetAddress(); got != target {
+		t.Errorf("got rd.TargetAddress() = %s, want = %s", got, target)
+	}
+	if got := rd.DestinationAddress(); got != dst {
+		t.Errorf("got rd.DestinationAddress() = %s, want = %s", got, dst)
+	}
+	it, err := rd.Options().Iter(true)
+	if err != nil {
+		t.Fatalf("got Iter = (_, %s), want = (_, nil)", err)
+	}
+	opt, done, err := it.Next()
+	if err != nil || done {
+		t.Fatalf("got Next = (_, %t, %v), want = (_, false, nil)", done, err)
+	}
+	if got := opt.kind(); got != ndpRedirectedHeaderOptionType {
+		t.Errorf("got kind() = %s, want = %s", got, ndpRedirectedHeaderOptionType)
+	}
+	rh, ok := opt.(NDPRedirectedHeader)
+	if !ok {
+		t.Fatalf("got opt = %T, want = NDPRedirectedHeader", opt)
+	}
+
+	// The embedded datagram is padded to a multiple of 8 bytes.
+	datagram := rh.Datagram()
+	if got, want := len(datagram), 64; got != want {
+		t.Fatalf("got len(rh.Datagram()) = %d, want = %d", got, want)
+	}
+	if !bytes.Equal(datagram[:len(original)], original) {
+		t.Errorf("got rh.Datagram() = %x, want prefix = %x", datagram, original)
+	}
+	ip := IPv6(datagram)
+	if got := ip.DestinationAddress(); got != dst {
+		t.Errorf("got ip.DestinationAddress() = %s, want = %s", got, dst)
+	}
+	if got := ip.TransportProtocol(); got != TCPProtocolNumber {
+		t.Errorf("got ip.TransportProtocol() = %d, want = %d", got, TCPProtocolNumber)
+	}
+	if got := TCP(ip.Payload()).DestinationPort(); got != 80 {
+		t.Errorf("got DestinationPort() = %d, want = 80", got)
+	}
+
+	if _, done, err := it.Next(); err != nil || !done {
+		t.Errorf("got Next = (_, %t, %v), want = (_, true, nil)", done, err)
+	}
+}
@@ -25,25 +25,27 @@ func _() {
 	_ = x[ndpSourceLinkLayerAddressOptionType-1]
 	_ = x[ndpTargetLinkLayerAddressOptionType-2]
 	_ = x[ndpPrefixInformationType-3]
+	_ = x[ndpRedirectedHeaderOptionType-4]
+	_ = x[ndpMTUOptionType-5]
 	_ = x[ndpNonceOptionType-14]
 	_ = x[ndpRecursiveDNSServerOptionType-25]
 	_ = x[ndpDNSSearchListOptionType-31]
 }
 
 const (
-	_ndpOptionIdentifier_name_0 = "ndpSourceLinkLayerAddressOptionTypendpTargetLinkLayerAddressOptionTypendpPrefixInformationType"
+	_ndpOptionIdentifier_name_0 = "ndpSourceLinkLayerAddressOptionTypendpTargetLinkLayerAddressOptionTypendpPrefixInformationTypendpRedirectedHeaderOptionTypendpMTUOptionType"
 	_ndpOptionIdentifier_name_1 = "ndpNonceOptionType"
 	_ndpOptionIdentifier_name_2 = "ndpRecursiveDNSServerOptionType"
 	_ndpOptionIdentifier_name_3 = "ndpDNSSearchListOptionType"
 )
 
 var (
-	_ndpOptionIdentifier_index_0 = [...]uint8{0, 35, 70, 94}
+	_ndpOptionIdentifier_index_0 = [...]uint8{0, 35, 70, 94, 123, 139}
 )
 
 func (i ndpOptionIdentifier) String() string {
 	switch {
-	case 1 <= i && i <= 3:
+	case 1 <= i && i <= 5:
 		i -= 1
 		return _ndpOptionIdentifier_name_0[_ndpOptionIdentifier_index_0[i]:_ndpOptionIdentifier_index_0[i+1]]
 	case i == 14:
@@ -0,0 +1,43 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build go1.18
+
+package header
+
+import (
+	"net/netip"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+// ToNetipAddr returns addr as a netip.Addr.
+//
+// ok is false if addr is neither an IPv4 nor an IPv6 address. An IPv4-mapped
+// IPv6 address is returned as an IPv6 address; use netip.Addr.Unmap to obtain
+// the IPv4 address.
+func ToNetipAddr(addr tcpip.Address) (netip.Addr, bool) {
+	return netip.AddrFromSlice([]byte(addr))
+}
+
+// FromNetipAddr returns a as a tcpip.Address.
+//
+// The zone of an IPv6 address is dropped as tcpip.Address cannot hold it. The
+// zero netip.Addr is returned as the empty tcpip.Address.
+func FromNetipAddr(a netip.Addr) tcpip.Address {
+	if !a.IsValid() {
+		return ""
+	}
+	return tcpip.Address(a.AsSlice())
+}
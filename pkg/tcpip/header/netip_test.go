@@ -0,0 +1,82 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build go1.18
+
+package header_test
+
+import (
+	"net/netip"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestNetipAddrRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		addr tcpip.Address
+		want netip.Addr
+	}{
+		{
+			name: "IPv4",
+			addr: "\x0a\x00\x00\x01",
+			want: netip.MustParseAddr("10.0.0.1"),
+		},
+		{
+			name: "IPv4-mapped",
+			addr: "\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\xff\xff\x0a\x00\x00\x01",
+			want: netip.MustParseAddr("::ffff:10.0.0.1"),
+		},
+		{
+			name: "IPv6",
+			addr: "\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01",
+			want: netip.MustParseAddr("2001:db8::1"),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := header.ToNetipAddr(test.addr)
+			if !ok {
+				t.Fatalf("got header.ToNetipAddr(%s) = (_, false), want = (_, true)", test.addr)
+			}
+			if got != test.want {
+				t.Errorf("got header.ToNetipAddr(%s) = (%s, _), want = (%s, _)", test.addr, got, test.want)
+			}
+			if got := header.FromNetipAddr(got); got != test.addr {
+				t.Errorf("got header.FromNetipAddr(_) = %s, want = %s", got, test.addr)
+			}
+		})
+	}
+}
+
+func TestToNetipAddrInvalid(t *testing.T) {
+	for _, addr := range []tcpip.Address{"", "\x0a\x00\x00"} {
+		if got, ok := header.ToNetipAddr(addr); ok {
+			t.Errorf("got header.ToNetipAddr(%x) = (%s, true), want = (_, false)", []byte(addr), got)
+		}
+	}
+}
+
+func TestFromNetipAddrZone(t *testing.T) {
+	want := tcpip.Address("\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+	if got := header.FromNetipAddr(netip.MustParseAddr("fe80::1%eth0")); got != want {
+		t.Errorf("got header.FromNetipAddr(fe80::1%%eth0) = %s, want = %s", got, want)
+	}
+	if got := header.FromNetipAddr(netip.Addr{}); got != "" {
+		t.Errorf("got header.FromNetipAddr(netip.Addr{}) = %s, want = empty", got)
+	}
+}
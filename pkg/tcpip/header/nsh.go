@@ -0,0 +1,231 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import "encoding/binary"
+
+// RFC 8300 section 2.2 defines the Network Service Header (NSH) as a Base
+// Header and a Service Path Header, followed by Context Headers:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|Ver|O|U|    TTL    |   Length  |U|U|U|U|MD Type| Next Protocol |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|          Service Path Identifier (SPI)        | Service Index |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	~                Context Header(s)                              ~
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// The Length field is the total length of the NSH in 4-byte words. With MD
+// Type 1 (section 2.4), the Context Headers are fixed at 16 bytes. With MD
+// Type 2 (section 2.5), they are zero or more variable-length TLVs:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|          Metadata Class       |      Type     |U|    Length   |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                   Variable-Length Metadata                    |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// where Length is the length of the metadata in bytes, excluding the padding
+// to a 4-byte boundary.
+const (
+	nshVerOTTL       = 0
+	nshTTLLength     = 1
+	nshMDType        = 2
+	nshNextProtocol  = 3
+	nshServicePath   = 4
+	nshContextHeader = 8
+
+	nshVersionShift    = 6
+	nshFlagOAM         = 1 << 5
+	nshTTLMask         = 0x3f
+	nshLengthMask      = 0x3f
+	nshMDTypeMask      = 0x0f
+	nshSPIShift        = 8
+	nshLengthUnitBytes = 4
+
+	nshMD2TLVClass      = 0
+	nshMD2TLVType       = 2
+	nshMD2TLVLength     = 3
+	nshMD2TLVLengthMask = 0x7f
+	nshMD2TLVHeaderSize = 4
+
+	// nshMD1Length is the value of the Length field with MD Type 1, as per
+	// RFC 8300 section 2.4.
+	nshMD1Length = 6
+)
+
+const (
+	// NSHMinimumSize is the size of the Base Header and the Service Path
+	// Header.
+	NSHMinimumSize = 8
+
+	// NSHMD1ContextSize is the size of the fixed Context Header with MD
+	// Type 1.
+	NSHMD1ContextSize = 16
+
+	// NSHVersion is the version of the NSH described by RFC 8300.
+	NSHVersion = 0
+)
+
+// The MD Types of the NSH, as per RFC 8300 section 2.2.
+const (
+	NSHMDType1 uint8 = 1
+	NSHMDType2 uint8 = 2
+)
+
+// The values of the Next Protocol field, as per RFC 8300 section 11.2.5.
+const (
+	NSHNextProtocolIPv4     uint8 = 1
+	NSHNextProtocolIPv6     uint8 = 2
+	NSHNextProtocolEthernet uint8 = 3
+	NSHNextProtocolNSH      uint8 = 4
+	NSHNextProtocolMPLS     uint8 = 5
+)
+
+// NSHMetadata is a Context Header TLV carried by an NSH with MD Type 2.
+type NSHMetadata struct {
+	// Class is the Metadata Class, the scope of Type.
+	Class uint16
+
+	// Type is the type of the metadata within Class.
+	Type uint8
+
+	// Value is the metadata, without padding.
+	Value []byte
+}
+
+// NSH represents a Network Service Header stored in a byte array.
+type NSH []byte
+
+// IsValid performs basic validation on the NSH.
+//
+// The version must be NSHVersion, the Length field must be consistent with the
+// MD Type and fit in b, and the Context Header TLVs of MD Type 2 must exactly
+// fill the Context Headers. Headers of other MD Types are not valid.
+func (b NSH) IsValid() bool {
+	if len(b) < NSHMinimumSize || b.Version() != NSHVersion {
+		return false
+	}
+	length := b.Length()
+	if length < NSHMinimumSize || length > len(b) {
+		return false
+	}
+	switch b.MDType() {
+	case NSHMDType1:
+		return length == nshMD1Length*nshLengthUnitBytes
+	case NSHMDType2:
+		_, ok := b.MD2Context()
+		return ok
+	default:
+		return false
+	}
+}
+
+// Version returns the version of the NSH.
+func (b NSH) Version() uint8 {
+	return b[nshVerOTTL] >> nshVersionShift
+}
+
+// OAM returns true iff the O bit is set, indicating an OAM packet.
+func (b NSH) OAM() bool {
+	return b[nshVerOTTL]&nshFlagOAM != 0
+}
+
+// TTL returns the 6-bit Time To Live field.
+func (b NSH) TTL() uint8 {
+	return uint8(binary.BigEndian.Uint16(b[nshVerOTTL:])>>6) & nshTTLMask
+}
+
+// Length returns the total length of the NSH in bytes.
+func (b NSH) Length() int {
+	return int(b[nshTTLLength]&nshLengthMask) * nshLengthUnitBytes
+}
+
+// MDType returns the MD Type, which determines the format of the Context
+// Headers.
+func (b NSH) MDType() uint8 {
+	return b[nshMDType] & nshMDTypeMask
+}
+
+// NextProtocol returns the protocol of the packet following the NSH, one of
+// the NSHNextProtocol* values.
+func (b NSH) NextProtocol() uint8 {
+	return b[nshNextProtocol]
+}
+
+// SPI returns the 24-bit Service Path Identifier.
+func (b NSH) SPI() uint32 {
+	return binary.BigEndian.Uint32(b[nshServicePath:]) >> nshSPIShift
+}
+
+// ServiceIndex returns the Service Index, the location within the service
+// path.
+func (b NSH) ServiceIndex() uint8 {
+	return b[nshServicePath+3]
+}
+
+// MD1Context returns the fixed-size Context Header of an NSH with MD Type 1.
+//
+// The returned bool is false if the MD Type is not 1 or if b is too short.
+func (b NSH) MD1Context() ([]byte, bool) {
+	if b.MDType() != NSHMDType1 || len(b) < nshContextHeader+NSHMD1ContextSize {
+		return nil, false
+	}
+	return b[nshContextHeader:][:NSHMD1ContextSize], true
+}
+
+// MD2Context returns the Context Header TLVs of an NSH with MD Type 2. The
+// values alias b.
+//
+// The returned bool is false if the MD Type is not 2, if the Length field does
+// not fit in b or if a TLV, including its padding, does not fit within the
+// Context Headers.
+func (b NSH) MD2Context() ([]NSHMetadata, bool) {
+	length := b.Length()
+	if b.MDType() != NSHMDType2 || length < NSHMinimumSize || length > len(b) {
+		return nil, false
+	}
+	var tlvs []NSHMetadata
+	ctx := b[nshContextHeader:length]
+	for len(ctx) != 0 {
+		if len(ctx) < nshMD2TLVHeaderSize {
+			return nil, false
+		}
+		valueLen := int(ctx[nshMD2TLVLength] & nshMD2TLVLengthMask)
+		// The metadata is padded to a 4-byte boundary.
+		paddedLen := (valueLen + nshLengthUnitBytes - 1) &^ (nshLengthUnitBytes - 1)
+		if nshMD2TLVHeaderSize+paddedLen > len(ctx) {
+			return nil, false
+		}
+		tlvs = append(tlvs, NSHMetadata{
+			Class: binary.BigEndian.Uint16(ctx[nshMD2TLVClass:]),
+			Type:  ctx[nshMD2TLVType],
+			Value: ctx[nshMD2TLVHeaderSize:][:valueLen],
+		})
+		ctx = ctx[nshMD2TLVHeaderSize+paddedLen:]
+	}
+	return tlvs, true
+}
+
+// Payload returns the packet following the NSH.
+//
+// b must be valid; see IsValid.
+func (b NSH) Payload() []byte {
+	return b[b.Length():]
+}
@@ -0,0 +1,186 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestNSH(t *testing.T) {
+	md1Context := []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12, 13, 14, 15, 16}
+
+	tests := []struct {
+		name             string
+		buf              []byte
+		wantValid        bool
+		wantOAM          bool
+		wantTTL          uint8
+		wantMDType       uint8
+		wantNextProtocol uint8
+		wantSPI          uint32
+		wantSI           uint8
+		wantMD1Context   []byte
+		wantMD2Context   []header.NSHMetadata
+		wantPayloadLen   int
+	}{
+		{
+			name: "MD Type 1",
+			buf: append(append([]byte{
+				0x0f, 0xc6, 0x01, 0x01,
+				0x12, 0x34, 0x56, 0xff,
+			}, md1Context...), 0x45),
+			wantValid:        true,
+			wantTTL:          63,
+			wantMDType:       header.NSHMDType1,
+			wantNextProtocol: header.NSHNextProtocolIPv4,
+			wantSPI:          0x123456,
+			wantSI:           255,
+			wantMD1Context:   md1Context,
+			wantPayloadLen:   1,
+		},
+		{
+			name: "MD Type 2 with TLVs",
+			buf: []byte{
+				0x2f, 0xc5, 0x02, 0x03,
+				0x00, 0x00, 0x2a, 0x01,
+				// TLV with 3 bytes of metadata and 1 byte of padding.
+				0x01, 0x02, 0x03, 0x03,
+				'a', 'b', 'c', 0,
+				// TLV without metadata.
+				0xff, 0xff, 0x04, 0x00,
+			},
+			wantValid:        true,
+			wantOAM:          true,
+			wantTTL:          63,
+			wantMDType:       header.NSHMDType2,
+			wantNextProtocol: header.NSHNextProtocolEthernet,
+			wantSPI:          42,
+			wantSI:           1,
+			wantMD2Context: []header.NSHMetadata{
+				{Class: 0x0102, Type: 3, Value: []byte("abc")},
+				{Class: 0xffff, Type: 4, Value: []byte{}},
+			},
+		},
+		{
+			name: "MD Type 2 without TLVs",
+			buf: []byte{
+				0x00, 0x82, 0x02, 0x02,
+				0x00, 0x00, 0x01, 0x02,
+				0x60,
+			},
+			wantValid:        true,
+			wantTTL:          2,
+			wantMDType:       header.NSHMDType2,
+			wantNextProtocol: header.NSHNextProtocolIPv6,
+			wantSPI:          1,
+			wantSI:           2,
+			wantPayloadLen:   1,
+		},
+		{
+			name: "MD Type 2 TLV overruns length",
+			buf: []byte{
+				0x00, 0x83, 0x02, 0x02,
+				0x00, 0x00, 0x01, 0x02,
+				0x01, 0x02, 0x03, 0x01,
+				'a', 0, 0, 0,
+			},
+		},
+		{
+			name: "MD Type 1 with wrong length",
+			buf: append(append([]byte{
+				0x0f, 0xc5, 0x01, 0x01,
+				0x12, 0x34, 0x56, 0xff,
+			}, md1Context...), 0x45),
+		},
+		{
+			name: "length exceeds buffer",
+			buf: append([]byte{
+				0x0f, 0xc6, 0x01, 0x01,
+				0x12, 0x34, 0x56, 0xff,
+			}, md1Context[:15]...),
+		},
+		{
+			name: "non-zero version",
+			buf: append([]byte{
+				0x4f, 0xc6, 0x01, 0x01,
+				0x12, 0x34, 0x56, 0xff,
+			}, md1Context...),
+		},
+		{
+			name: "unknown MD Type",
+			buf: []byte{
+				0x00, 0x82, 0x0f, 0x02,
+				0x00, 0x00, 0x01, 0x02,
+			},
+		},
+		{
+			name: "too small",
+			buf:  []byte{0x00, 0x82, 0x02, 0x02, 0x00, 0x00, 0x01},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nsh := header.NSH(test.buf)
+			if got := nsh.IsValid(); got != test.wantValid {
+				t.Fatalf("got IsValid() = %t, want = %t", got, test.wantValid)
+			}
+			if !test.wantValid {
+				return
+			}
+			if got := nsh.Version(); got != header.NSHVersion {
+				t.Errorf("got Version() = %d, want = %d", got, header.NSHVersion)
+			}
+			if got := nsh.OAM(); got != test.wantOAM {
+				t.Errorf("got OAM() = %t, want = %t", got, test.wantOAM)
+			}
+			if got := nsh.TTL(); got != test.wantTTL {
+				t.Errorf("got TTL() = %d, want = %d", got, test.wantTTL)
+			}
+			if got := nsh.MDType(); got != test.wantMDType {
+				t.Errorf("got MDType() = %d, want = %d", got, test.wantMDType)
+			}
+			if got := nsh.NextProtocol(); got != test.wantNextProtocol {
+				t.Errorf("got NextProtocol() = %d, want = %d", got, test.wantNextProtocol)
+			}
+			if got := nsh.SPI(); got != test.wantSPI {
+				t.Errorf("got SPI() = 0x%x, want = 0x%x", got, test.wantSPI)
+			}
+			if got := nsh.ServiceIndex(); got != test.wantSI {
+				t.Errorf("got ServiceIndex() = %d, want = %d", got, test.wantSI)
+			}
+			md1, ok := nsh.MD1Context()
+			if want := test.wantMDType == header.NSHMDType1; ok != want {
+				t.Errorf("got MD1Context() = (_, %t), want = (_, %t)", ok, want)
+			}
+			if diff := cmp.Diff(test.wantMD1Context, md1); diff != "" {
+				t.Errorf("MD1Context() mismatch (-want +got):\n%s", diff)
+			}
+			md2, ok := nsh.MD2Context()
+			if want := test.wantMDType == header.NSHMDType2; ok != want {
+				t.Errorf("got MD2Context() = (_, %t), want = (_, %t)", ok, want)
+			}
+			if diff := cmp.Diff(test.wantMD2Context, md2); diff != "" {
+				t.Errorf("MD2Context() mismatch (-want +got):\n%s", diff)
+			}
+			if got := len(nsh.Payload()); got != test.wantPayloadLen {
+				t.Errorf("got len(Payload()) = %d, want = %d", got, test.wantPayloadLen)
+			}
+		})
+	}
+}
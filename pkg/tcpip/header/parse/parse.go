@@ -121,6 +121,13 @@ traverseExtensions:
 			nextHdr = tcpip.TransportProtocolNumber(extHdr.Identifier)
 			break traverseExtensions
 
+		case header.IPv6Mobility:
+			// The Mobility header is always the last header in the chain and is
+			// treated as the payload, as per RFC 6275 section 6.1.1.
+			extensionsSize = int(it.HeaderOffset()) - header.IPv6MinimumSize
+			nextHdr = tcpip.TransportProtocolNumber(header.IPv6MobilityExtHdrIdentifier)
+			break traverseExtensions
+
 		default:
 			// Any other extension is a no-op, keep looping until we find the payload.
 		}
@@ -0,0 +1,211 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import "encoding/binary"
+
+// RFC 3550 section 5.1 defines the RTP fixed header as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|V=2|P|X|  CC   |M|     PT      |       sequence number         |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                           timestamp                           |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|           synchronization source (SSRC) identifier            |
+//	+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+=+
+//	|            contributing source (CSRC) identifiers             |
+//	|                             ....                              |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// If the X bit is set, the CSRC list is followed by a header extension as per
+// RFC 3550 section 5.3.1:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|      defined by profile       |           length              |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                        header extension                       |
+//	|                             ....                              |
+const (
+	rtpFlags     = 0
+	rtpMarkerPT  = 1
+	rtpSeqNum    = 2
+	rtpTimestamp = 4
+	rtpSSRC      = 8
+	rtpCSRCList  = 12
+
+	rtpVersionShift  = 6
+	rtpPaddingFlag   = 1 << 5
+	rtpExtFlag       = 1 << 4
+	rtpCSRCCountMask = 0xf
+	rtpMarkerFlag    = 1 << 7
+	rtpPTMask        = 0x7f
+
+	rtpCSRCSize      = 4
+	rtpExtHdrSize    = 4
+	rtpExtHdrProfile = 0
+	rtpExtHdrLength  = 2
+	rtpExtWordSize   = 4
+)
+
+const (
+	// RTPMinimumSize is the size of the RTP fixed header.
+	RTPMinimumSize = 12
+
+	// RTPVersion is the only RTP version defined, as per RFC 3550 section 5.1.
+	RTPVersion = 2
+)
+
+// RTP represents an RTP packet stored in a byte array, typically the payload
+// of a UDP datagram.
+//
+// Most of the methods of RTP access to the underlying slice without checking
+// the boundaries and could panic because of 'index out of range'. Always call
+// IsValid() to validate an instance of RTP before using other methods.
+type RTP []byte
+
+// IsValid performs basic validation on the RTP packet.
+//
+// It checks the version and that the CSRC list, the header extension and the
+// padding described by the header all fit in b without overlapping.
+func (b RTP) IsValid() bool {
+	if len(b) < RTPMinimumSize || b.Version() != RTPVersion {
+		return false
+	}
+	if b.HasExtension() && len(b) < rtpCSRCList+b.CSRCCount()*rtpCSRCSize+rtpExtHdrSize {
+		return false
+	}
+	hdrLen := b.HeaderLength()
+	if len(b) < hdrLen {
+		return false
+	}
+	if b.HasPadding() {
+		// The last octet of the padding holds the padding length, itself
+		// included.
+		pad := int(b[len(b)-1])
+		return pad != 0 && len(b)-hdrLen >= pad
+	}
+	return true
+}
+
+// Version returns the version of the RTP packet.
+func (b RTP) Version() uint8 {
+	return b[rtpFlags] >> rtpVersionShift
+}
+
+// HasPadding returns true iff the padding (P) bit is set.
+func (b RTP) HasPadding() bool {
+	return b[rtpFlags]&rtpPaddingFlag != 0
+}
+
+// HasExtension returns true iff the extension (X) bit is set, indicating that
+// the CSRC list is followed by a header extension.
+func (b RTP) HasExtension() bool {
+	return b[rtpFlags]&rtpExtFlag != 0
+}
+
+// CSRCCount returns the number of CSRC identifiers that follow the fixed
+// header.
+func (b RTP) CSRCCount() int {
+	return int(b[rtpFlags] & rtpCSRCCountMask)
+}
+
+// Marker returns true iff the marker (M) bit is set.
+func (b RTP) Marker() bool {
+	return b[rtpMarkerPT]&rtpMarkerFlag != 0
+}
+
+// PayloadType returns the payload type (PT) field.
+func (b RTP) PayloadType() uint8 {
+	return b[rtpMarkerPT] & rtpPTMask
+}
+
+// SequenceNumber returns the sequence number field.
+func (b RTP) SequenceNumber() uint16 {
+	return binary.BigEndian.Uint16(b[rtpSeqNum:])
+}
+
+// Timestamp returns the timestamp field.
+func (b RTP) Timestamp() uint32 {
+	return binary.BigEndian.Uint32(b[rtpTimestamp:])
+}
+
+// SSRC returns the synchronization source identifier.
+func (b RTP) SSRC() uint32 {
+	return binary.BigEndian.Uint32(b[rtpSSRC:])
+}
+
+// CSRC returns the i-th contributing source identifier.
+//
+// i must be less than CSRCCount().
+func (b RTP) CSRC(i int) uint32 {
+	return binary.BigEndian.Uint32(b[rtpCSRCList+i*rtpCSRCSize:])
+}
+
+// Extension returns the profile-defined identifier and the data of the header
+// extension, if present.
+func (b RTP) Extension() (profile uint16, data []byte, ok bool) {
+	if !b.HasExtension() {
+		return 0, nil, false
+	}
+	ext := b[rtpCSRCList+b.CSRCCount()*rtpCSRCSize:]
+	dataLen := int(binary.BigEndian.Uint16(ext[rtpExtHdrLength:])) * rtpExtWordSize
+	return binary.BigEndian.Uint16(ext[rtpExtHdrProfile:]), ext[rtpExtHdrSize:][:dataLen], true
+}
+
+// HeaderLength returns the length of the RTP header, including the CSRC list
+// and the header extension.
+func (b RTP) HeaderLength() int {
+	hdrLen := rtpCSRCList + b.CSRCCount()*rtpCSRCSize
+	if b.HasExtension() {
+		hdrLen += rtpExtHdrSize + int(binary.BigEndian.Uint16(b[hdrLen+rtpExtHdrLength:]))*rtpExtWordSize
+	}
+	return hdrLen
+}
+
+// Payload returns the payload of the RTP packet, excluding any padding.
+func (b RTP) Payload() []byte {
+	end := len(b)
+	if b.HasPadding() {
+		end -= int(b[end-1])
+	}
+	return b[b.HeaderLength():end]
+}
+
+// rtcpMinPacketType and rtcpMaxPacketType bound the RTCP packet types that may
+// be carried alongside RTP on the same port, as per RFC 5761 section 4.
+const (
+	rtcpMinimumSize   = 4
+	rtcpPacketTypeIdx = 1
+	rtcpMinPacketType = 192
+	rtcpMaxPacketType = 223
+)
+
+// IsRTCP returns true iff udpPayload holds an RTCP packet rather than an RTP
+// packet, for RTP and RTCP multiplexed on a single port.
+//
+// As per RFC 5761 section 4, the second octet of the packet is an RTCP packet
+// type in the range 192-223 for RTCP, whereas for RTP it holds the marker bit
+// and a payload type that must avoid that range.
+func IsRTCP(udpPayload []byte) bool {
+	if len(udpPayload) < rtcpMinimumSize || RTP(udpPayload).Version() != RTPVersion {
+		return false
+	}
+	pt := udpPayload[rtcpPacketTypeIdx]
+	return pt >= rtcpMinPacketType && pt <= rtcpMaxPacketType
+}
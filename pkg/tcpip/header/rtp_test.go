@@ -0,0 +1,233 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestRTP(t *testing.T) {
+	tests := []struct {
+		name          string
+		buf           []byte
+		wantMarker    bool
+		wantPT        uint8
+		wantSeq       uint16
+		wantTimestamp uint32
+		wantSSRC      uint32
+		wantCSRCs     []uint32
+		wantExtOK     bool
+		wantProfile   uint16
+		wantExtData   []byte
+		wantPayload   []byte
+	}{
+		{
+			name: "basic",
+			buf: []byte{
+				0x80, 0x60, 0x12, 0x34,
+				0x00, 0x01, 0x02, 0x03,
+				0xde, 0xad, 0xbe, 0xef,
+				1, 2, 3, 4,
+			},
+			wantPT:        96,
+			wantSeq:       0x1234,
+			wantTimestamp: 0x00010203,
+			wantSSRC:      0xdeadbeef,
+			wantPayload:   []byte{1, 2, 3, 4},
+		},
+		{
+			name: "two CSRCs with marker",
+			buf: []byte{
+				0x82, 0x80, 0x00, 0x01,
+				0x00, 0x00, 0x00, 0x10,
+				0x11, 0x11, 0x11, 0x11,
+				0x22, 0x22, 0x22, 0x22,
+				0x33, 0x33, 0x33, 0x33,
+				5, 6,
+			},
+			wantMarker:    true,
+			wantPT:        0,
+			wantSeq:       1,
+			wantTimestamp: 0x10,
+			wantSSRC:      0x11111111,
+			wantCSRCs:     []uint32{0x22222222, 0x33333333},
+			wantPayload:   []byte{5, 6},
+		},
+		{
+			name: "extension and padding",
+			buf: []byte{
+				0xb1, 0x08, 0xff, 0xff,
+				0x00, 0x00, 0x00, 0x00,
+				0x11, 0x11, 0x11, 0x11,
+				0x22, 0x22, 0x22, 0x22,
+				0xbe, 0xde, 0x00, 0x01,
+				0xaa, 0xbb, 0xcc, 0xdd,
+				7, 8, 9,
+				0, 0, 3,
+			},
+			wantPT:      8,
+			wantSeq:     0xffff,
+			wantSSRC:    0x11111111,
+			wantCSRCs:   []uint32{0x22222222},
+			wantExtOK:   true,
+			wantProfile: 0xbede,
+			wantExtData: []byte{0xaa, 0xbb, 0xcc, 0xdd},
+			wantPayload: []byte{7, 8, 9},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rtp := header.RTP(test.buf)
+			if !rtp.IsValid() {
+				t.Fatal("got rtp.IsValid() = false, want = true")
+			}
+			if got := rtp.Version(); got != header.RTPVersion {
+				t.Errorf("got rtp.Version() = %d, want = %d", got, header.RTPVersion)
+			}
+			if got := rtp.Marker(); got != test.wantMarker {
+				t.Errorf("got rtp.Marker() = %t, want = %t", got, test.wantMarker)
+			}
+			if got := rtp.PayloadType(); got != test.wantPT {
+				t.Errorf("got rtp.PayloadType() = %d, want = %d", got, test.wantPT)
+			}
+			if got := rtp.SequenceNumber(); got != test.wantSeq {
+				t.Errorf("got rtp.SequenceNumber() = %d, want = %d", got, test.wantSeq)
+			}
+			if got := rtp.Timestamp(); got != test.wantTimestamp {
+				t.Errorf("got rtp.Timestamp() = %d, want = %d", got, test.wantTimestamp)
+			}
+			if got := rtp.SSRC(); got != test.wantSSRC {
+				t.Errorf("got rtp.SSRC() = %#x, want = %#x", got, test.wantSSRC)
+			}
+			var csrcs []uint32
+			for i := 0; i < rtp.CSRCCount(); i++ {
+				csrcs = append(csrcs, rtp.CSRC(i))
+			}
+			if diff := cmp.Diff(test.wantCSRCs, csrcs); diff != "" {
+				t.Errorf("CSRC mismatch (-want +got):\n%s", diff)
+			}
+			profile, data, ok := rtp.Extension()
+			if ok != test.wantExtOK || profile != test.wantProfile || !bytes.Equal(data, test.wantExtData) {
+				t.Errorf("got rtp.Extension() = (%#x, %x, %t), want = (%#x, %x, %t)", profile, data, ok, test.wantProfile, test.wantExtData, test.wantExtOK)
+			}
+			if got := rtp.Payload(); !bytes.Equal(got, test.wantPayload) {
+				t.Errorf("got rtp.Payload() = %x, want = %x", got, test.wantPayload)
+			}
+		})
+	}
+}
+
+func TestRTPIsValid(t *testing.T) {
+	tests := []struct {
+		name string
+		buf  []byte
+	}{
+		{
+			name: "too small",
+			buf:  []byte{0x80, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name: "bad version",
+			buf:  []byte{0x40, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0},
+		},
+		{
+			name: "truncated CSRC list",
+			buf:  []byte{0x82, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 1, 1, 1},
+		},
+		{
+			name: "truncated extension header",
+			buf:  []byte{0x90, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xbe, 0xde},
+		},
+		{
+			name: "truncated extension data",
+			buf:  []byte{0x90, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0xbe, 0xde, 0, 2, 1, 2, 3, 4},
+		},
+		{
+			name: "zero padding length",
+			buf:  []byte{0xa0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0},
+		},
+		{
+			name: "padding longer than payload",
+			buf:  []byte{0xa0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 3},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if header.RTP(test.buf).IsValid() {
+				t.Error("got IsValid() = true, want = false")
+			}
+		})
+	}
+}
+
+func TestIsRTCP(t *testing.T) {
+	tests := []struct {
+		name string
+		buf  []byte
+		want bool
+	}{
+		{
+			name: "RTP",
+			buf:  []byte{0x80, 0x60, 0x12, 0x34, 0, 0, 0, 0, 0xde, 0xad, 0xbe, 0xef},
+			want: false,
+		},
+		{
+			name: "RTP with marker",
+			buf:  []byte{0x80, 0xe0, 0x12, 0x34, 0, 0, 0, 0, 0xde, 0xad, 0xbe, 0xef},
+			want: false,
+		},
+		{
+			name: "RTCP sender report",
+			buf: []byte{
+				0x80, 200, 0x00, 0x06,
+				0xde, 0xad, 0xbe, 0xef,
+				0, 0, 0, 0, 0, 0, 0, 0,
+				0, 0, 0, 0,
+				0, 0, 0, 0,
+				0, 0, 0, 0,
+			},
+			want: true,
+		},
+		{
+			name: "RTCP receiver report",
+			buf:  []byte{0x80, 201, 0x00, 0x01, 0xde, 0xad, 0xbe, 0xef},
+			want: true,
+		},
+		{
+			name: "bad version",
+			buf:  []byte{0x40, 200, 0x00, 0x01, 0xde, 0xad, 0xbe, 0xef},
+			want: false,
+		},
+		{
+			name: "too small",
+			buf:  []byte{0x80, 200},
+			want: false,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.IsRTCP(test.buf); got != test.want {
+				t.Errorf("got header.IsRTCP(%x) = %t, want = %t", test.buf, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,454 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/buffer"
+)
+
+// RFC 4960 section 3.3.1 defines the SCTP DATA chunk as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|   Type = 0    | Reserved|U|B|E|    Length                     |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                              TSN                              |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|      Stream Identifier S      |   Stream Sequence Number n    |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                  Payload Protocol Identifier                  |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	\                                                               \
+//	/                 User Data (seq n of Stream S)                 /
+//	\                                                               \
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+const (
+	sctpChunkType        = 0
+	sctpChunkFlags       = 1
+	sctpChunkLength      = 2
+	sctpDataTSN          = 4
+	sctpDataStreamID     = 8
+	sctpDataStreamSeqNum = 10
+	sctpDataPPID         = 12
+)
+
+const (
+	// SCTPDataChunkType is the chunk type of a DATA chunk.
+	SCTPDataChunkType = 0
+
+	// SCTPDataChunkMinimumSize is the size of a DATA chunk carrying no user
+	// data.
+	SCTPDataChunkMinimumSize = 16
+)
+
+// The flags of a DATA chunk, as per RFC 4960 section 3.3.1.
+const (
+	// SCTPDataFlagEnding is set on the last fragment of a user message.
+	SCTPDataFlagEnding uint8 = 1 << 0
+
+	// SCTPDataFlagBeginning is set on the first fragment of a user message.
+	SCTPDataFlagBeginning uint8 = 1 << 1
+
+	// SCTPDataFlagUnordered is set when the user message is unordered, in which
+	// case the stream sequence number is ignored.
+	SCTPDataFlagUnordered uint8 = 1 << 2
+)
+
+// SCTPDataChunk represents an SCTP DATA chunk stored in a byte array.
+//
+// Most of the methods of SCTPDataChunk access to the underlying slice without
+// checking the boundaries and could panic because of 'index out of range'.
+// Always call IsValid() to validate an instance of SCTPDataChunk before using
+// other methods.
+type SCTPDataChunk []byte
+
+// IsValid performs basic validation on the DATA chunk.
+//
+// As per RFC 4960 section 6.2, a DATA chunk must carry user data.
+func (b SCTPDataChunk) IsValid() bool {
+	if len(b) < SCTPDataChunkMinimumSize || b[sctpChunkType] != SCTPDataChunkType {
+		return false
+	}
+	length := int(b.Length())
+	return length > SCTPDataChunkMinimumSize && length <= len(b)
+}
+
+// Flags returns the chunk flags of the DATA chunk.
+func (b SCTPDataChunk) Flags() uint8 {
+	return b[sctpChunkFlags]
+}
+
+// Length returns the length of the DATA chunk, including the chunk header but
+// excluding any padding.
+func (b SCTPDataChunk) Length() uint16 {
+	return binary.BigEndian.Uint16(b[sctpChunkLength:])
+}
+
+// Unordered returns true iff the U flag is set.
+func (b SCTPDataChunk) Unordered() bool {
+	return b.Flags()&SCTPDataFlagUnordered != 0
+}
+
+// Beginning returns true iff the B flag is set, i.e. the chunk holds the first
+// fragment of a user message.
+func (b SCTPDataChunk) Beginning() bool {
+	return b.Flags()&SCTPDataFlagBeginning != 0
+}
+
+// Ending returns true iff the E flag is set, i.e. the chunk holds the last
+// fragment of a user message.
+func (b SCTPDataChunk) Ending() bool {
+	return b.Flags()&SCTPDataFlagEnding != 0
+}
+
+// TSN returns the transmission sequence number of the DATA chunk.
+func (b SCTPDataChunk) TSN() uint32 {
+	return binary.BigEndian.Uint32(b[sctpDataTSN:])
+}
+
+// StreamID returns the stream identifier of the DATA chunk.
+func (b SCTPDataChunk) StreamID() uint16 {
+	return binary.BigEndian.Uint16(b[sctpDataStreamID:])
+}
+
+// StreamSequenceNumber returns the stream sequence number of the DATA chunk.
+func (b SCTPDataChunk) StreamSequenceNumber() uint16 {
+	return binary.BigEndian.Uint16(b[sctpDataStreamSeqNum:])
+}
+
+// PayloadProtocolID returns the payload protocol identifier of the DATA chunk.
+func (b SCTPDataChunk) PayloadProtocolID() uint32 {
+	return binary.BigEndian.Uint32(b[sctpDataPPID:])
+}
+
+// UserData returns the user data carried by the DATA chunk, excluding any
+// padding.
+func (b SCTPDataChunk) UserData() []byte {
+	return b[SCTPDataChunkMinimumSize:b.Length()]
+}
+
+// RFC 4960 section 3.3.8 defines the SCTP SHUTDOWN chunk as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|   Type = 7    | Chunk  Flags  |      Length = 8               |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                      Cumulative TSN Ack                       |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// Section 3.3.7 defines the SCTP ABORT chunk as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|   Type = 6    |Reserved     |T|           Length              |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	\                                                               \
+//	/                   zero or more Error Causes                   /
+//	\                                                               \
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// where section 3.3.10 defines each error cause as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|           Cause Code          |       Cause Length            |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	/                    Cause-Specific Information                 /
+//	\                                                               \
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// Cause Length includes the cause header but not the padding to a 4-byte
+// boundary.
+const (
+	sctpShutdownCumulativeTSNAck = 4
+
+	sctpErrorCauseCode   = 0
+	sctpErrorCauseLength = 2
+)
+
+const (
+	// SCTPChunkHeaderSize is the size of the type, flags and length fields
+	// common to all chunks.
+	SCTPChunkHeaderSize = 4
+
+	// SCTPAbortChunkType is the chunk type of an ABORT chunk.
+	SCTPAbortChunkType = 6
+
+	// SCTPShutdownChunkType is the chunk type of a SHUTDOWN chunk.
+	SCTPShutdownChunkType = 7
+
+	// SCTPShutdownChunkSize is the size of a SHUTDOWN chunk.
+	SCTPShutdownChunkSize = 8
+
+	// SCTPErrorCauseHeaderSize is the size of the code and length fields of
+	// an error cause.
+	SCTPErrorCauseHeaderSize = 4
+)
+
+// SCTPAbortFlagTagReflected is the T flag of an ABORT chunk, as per RFC 4960
+// section 3.3.7.
+const SCTPAbortFlagTagReflected uint8 = 1 << 0
+
+// ErrMalformedSCTPErrorCause indicates that an error cause of an ABORT chunk
+// is malformed.
+var ErrMalformedSCTPErrorCause = errors.New("malformed SCTP error cause")
+
+// SCTPShutdownChunk represents an SCTP SHUTDOWN chunk stored in a byte array.
+//
+// Always call IsValid() to validate an instance of SCTPShutdownChunk before
+// using other methods.
+type SCTPShutdownChunk []byte
+
+// IsValid performs basic validation on the SHUTDOWN chunk.
+func (b SCTPShutdownChunk) IsValid() bool {
+	if len(b) < SCTPShutdownChunkSize || b[sctpChunkType] != SCTPShutdownChunkType {
+		return false
+	}
+	return b.Length() == SCTPShutdownChunkSize
+}
+
+// Length returns the length of the SHUTDOWN chunk.
+func (b SCTPShutdownChunk) Length() uint16 {
+	return binary.BigEndian.Uint16(b[sctpChunkLength:])
+}
+
+// CumulativeTSNAck returns the TSN of the last DATA chunk received in sequence
+// before a gap.
+func (b SCTPShutdownChunk) CumulativeTSNAck() uint32 {
+	return binary.BigEndian.Uint32(b[sctpShutdownCumulativeTSNAck:])
+}
+
+// SCTPAbortChunk represents an SCTP ABORT chunk stored in a byte array.
+//
+// Always call IsValid() to validate an instance of SCTPAbortChunk before using
+// other methods.
+type SCTPAbortChunk []byte
+
+// IsValid performs basic validation on the ABORT chunk.
+//
+// The error causes are validated as they are iterated over; see ErrorCauses.
+func (b SCTPAbortChunk) IsValid() bool {
+	if len(b) < SCTPChunkHeaderSize || b[sctpChunkType] != SCTPAbortChunkType {
+		return false
+	}
+	length := int(b.Length())
+	return length >= SCTPChunkHeaderSize && length <= len(b)
+}
+
+// Flags returns the chunk flags of the ABORT chunk.
+func (b SCTPAbortChunk) Flags() uint8 {
+	return b[sctpChunkFlags]
+}
+
+// Length returns the length of the ABORT chunk, including the chunk header but
+// excluding any padding.
+func (b SCTPAbortChunk) Length() uint16 {
+	return binary.BigEndian.Uint16(b[sctpChunkLength:])
+}
+
+// TagReflected returns true iff the T flag is set, i.e. the sender reflected
+// the Verification Tag of the packet it is aborting rather than using the one
+// expected by its peer, as allowed by RFC 4960 section 8.5.1.
+func (b SCTPAbortChunk) TagReflected() bool {
+	return b.Flags()&SCTPAbortFlagTagReflected != 0
+}
+
+// ErrorCauses returns an iterator over the error causes of the ABORT chunk.
+func (b SCTPAbortChunk) ErrorCauses() SCTPErrorCauseIterator {
+	return SCTPErrorCauseIterator{causes: b[SCTPChunkHeaderSize:b.Length()]}
+}
+
+// SCTPErrorCause is an error cause of an ABORT chunk.
+type SCTPErrorCause struct {
+	// Code identifies the type of error.
+	Code uint16
+
+	// Info is the cause-specific information, excluding any padding. It
+	// aliases the chunk.
+	Info []byte
+}
+
+// SCTPErrorCauseIterator is an iterator over the error causes of an ABORT
+// chunk.
+type SCTPErrorCauseIterator struct {
+	causes []byte
+}
+
+// Next returns the next error cause.
+//
+// done is true when there are no more error causes. An error wrapping
+// ErrMalformedSCTPErrorCause is returned if the error cause is malformed, in
+// which case the iterator must not be used anymore.
+func (i *SCTPErrorCauseIterator) Next() (cause SCTPErrorCause, done bool, err error) {
+	if len(i.causes) == 0 {
+		return SCTPErrorCause{}, true, nil
+	}
+	if len(i.causes) < SCTPErrorCauseHeaderSize {
+		return SCTPErrorCause{}, true, fmt.Errorf("got %d bytes for error cause header, want at least %d: %w", len(i.causes), SCTPErrorCauseHeaderSize, ErrMalformedSCTPErrorCause)
+	}
+	length := int(binary.BigEndian.Uint16(i.causes[sctpErrorCauseLength:]))
+	if length < SCTPErrorCauseHeaderSize {
+		return SCTPErrorCause{}, true, fmt.Errorf("got error cause length = %d, want >= %d: %w", length, SCTPErrorCauseHeaderSize, ErrMalformedSCTPErrorCause)
+	}
+	if length > len(i.causes) {
+		return SCTPErrorCause{}, true, fmt.Errorf("got error cause length = %d, want <= %d: %w", length, len(i.causes), ErrMalformedSCTPErrorCause)
+	}
+	cause = SCTPErrorCause{
+		Code: binary.BigEndian.Uint16(i.causes[sctpErrorCauseCode:]),
+		Info: i.causes[SCTPErrorCauseHeaderSize:length],
+	}
+	// The padding of the last error cause is not included in the chunk
+	// length.
+	paddedLen := (length + 3) &^ 3
+	if paddedLen > len(i.causes) {
+		paddedLen = len(i.causes)
+	}
+	i.causes = i.causes[paddedLen:]
+	return cause, false, nil
+}
+
+// RFC 4960 section 3.1 defines the SCTP common header as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|     Source Port Number        |     Destination Port Number   |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                      Verification Tag                         |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                           Checksum                            |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// The checksum is the CRC32c of the whole packet, computed with the checksum
+// field set to zero, as per RFC 4960 section 6.8 and appendix B.
+const (
+	sctpSrcPort         = 0
+	sctpDstPort         = 2
+	sctpVerificationTag = 4
+	sctpChecksum        = 8
+	sctpChecksumSize    = 4
+)
+
+const (
+	// SCTPCommonHeaderSize is the size of the SCTP common header.
+	SCTPCommonHeaderSize = 12
+
+	// SCTPUDPEncapsulationPort is the registered UDP port for SCTP
+	// encapsulated in UDP as per RFC 6951.
+	SCTPUDPEncapsulationPort = 9899
+)
+
+// ErrSCTPChecksumMismatch indicates that the checksum field of an SCTP common
+// header does not match the CRC32c of the packet.
+var ErrSCTPChecksumMismatch = errors.New("SCTP checksum mismatch")
+
+var sctpCRC32cTable = crc32.MakeTable(crc32.Castagnoli)
+
+// SCTP represents an SCTP packet, starting with its common header, stored in a
+// byte array.
+type SCTP []byte
+
+// IsValid returns true iff b is large enough to hold the common header.
+func (b SCTP) IsValid() bool {
+	return len(b) >= SCTPCommonHeaderSize
+}
+
+// SourcePort returns the source port of the SCTP packet.
+func (b SCTP) SourcePort() uint16 {
+	return binary.BigEndian.Uint16(b[sctpSrcPort:])
+}
+
+// DestinationPort returns the destination port of the SCTP packet.
+func (b SCTP) DestinationPort() uint16 {
+	return binary.BigEndian.Uint16(b[sctpDstPort:])
+}
+
+// VerificationTag returns the verification tag of the SCTP packet.
+func (b SCTP) VerificationTag() uint32 {
+	return binary.BigEndian.Uint32(b[sctpVerificationTag:])
+}
+
+// Checksum returns the checksum field of the SCTP packet.
+//
+// Unlike the other fields, the CRC32c is stored in little-endian byte order,
+// as per RFC 4960 appendix B.
+func (b SCTP) Checksum() uint32 {
+	return binary.LittleEndian.Uint32(b[sctpChecksum:])
+}
+
+// SetChecksum sets the checksum field of the SCTP packet.
+func (b SCTP) SetChecksum(checksum uint32) {
+	binary.LittleEndian.PutUint32(b[sctpChecksum:], checksum)
+}
+
+// CalculateChecksum returns the CRC32c of the SCTP packet, computed as if the
+// checksum field were zero without modifying b.
+func (b SCTP) CalculateChecksum() uint32 {
+	var zero [sctpChecksumSize]byte
+	crc := crc32.Update(0, sctpCRC32cTable, b[:sctpChecksum])
+	crc = crc32.Update(crc, sctpCRC32cTable, zero[:])
+	return crc32.Update(crc, sctpCRC32cTable, b[sctpChecksum+sctpChecksumSize:])
+}
+
+// IsChecksumValid returns true iff the checksum field of the SCTP packet
+// matches its CRC32c.
+func (b SCTP) IsChecksumValid() bool {
+	return b.Checksum() == b.CalculateChecksum()
+}
+
+// FillSCTPOverUDPChecksum calculates the checksum of the UDP datagram b, whose
+// payload is an SCTP packet encapsulated as per RFC 6951, and writes it into
+// b's checksum field. The length field of b must already be set.
+//
+// The UDP checksum covers the SCTP packet including its CRC32c, which must
+// therefore be computed first; it is left untouched.
+func FillSCTPOverUDPChecksum(b UDP, src, dst tcpip.Address, netProto tcpip.NetworkProtocolNumber) {
+	FillUDPChecksum(b[:UDPMinimumSize], src, dst, netProto, buffer.View(b.Payload()).ToVectorisedView())
+}
+
+// ValidateSCTPOverUDP checks both the checksum of the UDP datagram b, carried
+// by a netProto packet sent from src to dst, and the CRC32c of the SCTP packet
+// it encapsulates as per RFC 6951.
+//
+// The UDP checksum is checked first, as by UDP.ValidateChecksum with zero
+// checksums over IPv6 disallowed. The SCTP CRC32c is always checked, even when
+// the UDP checksum field is zero, since it is the only end-to-end check of
+// the SCTP packet.
+func ValidateSCTPOverUDP(b UDP, src, dst tcpip.Address, netProto tcpip.NetworkProtocolNumber) error {
+	if len(b) < UDPMinimumSize+SCTPCommonHeaderSize {
+		return fmt.Errorf("got %d bytes, want at least %d: %w", len(b), UDPMinimumSize+SCTPCommonHeaderSize, io.ErrUnexpectedEOF)
+	}
+	if length := int(b.Length()); length != len(b) {
+		return fmt.Errorf("got UDP length = %d, want = %d: %w", length, len(b), ErrUDPLengthMismatch)
+	}
+	if err := b.ValidateChecksum(src, dst, netProto, Checksum(b.Payload(), 0), false /* allowZeroV6 */); err != nil {
+		return err
+	}
+	if sctp := SCTP(b.Payload()); !sctp.IsChecksumValid() {
+		return fmt.Errorf("got SCTP checksum = %#08x, want = %#08x: %w", sctp.Checksum(), sctp.CalculateChecksum(), ErrSCTPChecksumMismatch)
+	}
+	return nil
+}
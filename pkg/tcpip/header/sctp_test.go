@@ -0,0 +1,500 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestSCTPDataChunk(t *testing.T) {
+	tests := []struct {
+		name          string
+		buf           []byte
+		wantValid     bool
+		wantUnordered bool
+		wantBeginning bool
+		wantEnding    bool
+		wantTSN       uint32
+		wantStreamID  uint16
+		wantSSN       uint16
+		wantPPID      uint32
+		wantUserData  []byte
+	}{
+		{
+			name: "complete message",
+			buf: []byte{
+				0x00, 0x03, 0x00, 0x13,
+				0x00, 0x00, 0x10, 0x00,
+				0x00, 0x02, 0x00, 0x05,
+				0x00, 0x00, 0x00, 0x33,
+				1, 2, 3,
+				0,
+			},
+			wantValid:     true,
+			wantBeginning: true,
+			wantEnding:    true,
+			wantTSN:       0x1000,
+			wantStreamID:  2,
+			wantSSN:       5,
+			wantPPID:      0x33,
+			wantUserData:  []byte{1, 2, 3},
+		},
+		{
+			name: "unordered middle fragment",
+			buf: []byte{
+				0x00, 0x04, 0x00, 0x14,
+				0xff, 0xff, 0xff, 0xff,
+				0x00, 0x07, 0x00, 0x00,
+				0x00, 0x00, 0x00, 0x00,
+				4, 5, 6, 7,
+			},
+			wantValid:     true,
+			wantUnordered: true,
+			wantTSN:       0xffffffff,
+			wantStreamID:  7,
+			wantUserData:  []byte{4, 5, 6, 7},
+		},
+		{
+			name: "no user data",
+			buf: []byte{
+				0x00, 0x03, 0x00, 0x10,
+				0x00, 0x00, 0x10, 0x00,
+				0x00, 0x02, 0x00, 0x05,
+				0x00, 0x00, 0x00, 0x33,
+			},
+		},
+		{
+			name: "length exceeds buffer",
+			buf: []byte{
+				0x00, 0x03, 0x00, 0x15,
+				0x00, 0x00, 0x10, 0x00,
+				0x00, 0x02, 0x00, 0x05,
+				0x00, 0x00, 0x00, 0x33,
+				1, 2, 3, 4,
+			},
+		},
+		{
+			name: "not a DATA chunk",
+			buf: []byte{
+				0x01, 0x00, 0x00, 0x14,
+				0x00, 0x00, 0x10, 0x00,
+				0x00, 0x02, 0x00, 0x05,
+				0x00, 0x00, 0x00, 0x33,
+				1, 2, 3, 4,
+			},
+		},
+		{
+			name: "too small",
+			buf:  []byte{0x00, 0x03, 0x00, 0x14},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chunk := header.SCTPDataChunk(test.buf)
+			if got := chunk.IsValid(); got != test.wantValid {
+				t.Fatalf("got chunk.IsValid() = %t, want = %t", got, test.wantValid)
+			}
+			if !test.wantValid {
+				return
+			}
+
+			if got := chunk.Unordered(); got != test.wantUnordered {
+				t.Errorf("got chunk.Unordered() = %t, want = %t", got, test.wantUnordered)
+			}
+			if got := chunk.Beginning(); got != test.wantBeginning {
+				t.Errorf("got chunk.Beginning() = %t, want = %t", got, test.wantBeginning)
+			}
+			if got := chunk.Ending(); got != test.wantEnding {
+				t.Errorf("got chunk.Ending() = %t, want = %t", got, test.wantEnding)
+			}
+			if got := chunk.TSN(); got != test.wantTSN {
+				t.Errorf("got chunk.TSN() = %d, want = %d", got, test.wantTSN)
+			}
+			if got := chunk.StreamID(); got != test.wantStreamID {
+				t.Errorf("got chunk.StreamID() = %d, want = %d", got, test.wantStreamID)
+			}
+			if got := chunk.StreamSequenceNumber(); got != test.wantSSN {
+				t.Errorf("got chunk.StreamSequenceNumber() = %d, want = %d", got, test.wantSSN)
+			}
+			if got := chunk.PayloadProtocolID(); got != test.wantPPID {
+				t.Errorf("got chunk.PayloadProtocolID() = %d, want = %d", got, test.wantPPID)
+			}
+			if got := chunk.UserData(); !bytes.Equal(got, test.wantUserData) {
+				t.Errorf("got chunk.UserData() = %x, want = %x", got, test.wantUserData)
+			}
+		})
+	}
+}
+
+func TestSCTPShutdownChunk(t *testing.T) {
+	tests := []struct {
+		name                 string
+		buf                  []byte
+		wantValid            bool
+		wantCumulativeTSNAck uint32
+	}{
+		{
+			name:                 "valid",
+			buf:                  []byte{0x07, 0x00, 0x00, 0x08, 0x00, 0x00, 0x10, 0x00},
+			wantValid:            true,
+			wantCumulativeTSNAck: 0x1000,
+		},
+		{
+			name: "wrong length",
+			buf:  []byte{0x07, 0x00, 0x00, 0x0c, 0x00, 0x00, 0x10, 0x00, 0x00, 0x00, 0x00, 0x00},
+		},
+		{
+			name: "not a SHUTDOWN chunk",
+			buf:  []byte{0x08, 0x00, 0x00, 0x08, 0x00, 0x00, 0x10, 0x00},
+		},
+		{
+			name: "too small",
+			buf:  []byte{0x07, 0x00, 0x00, 0x08, 0x00, 0x00, 0x10},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chunk := header.SCTPShutdownChunk(test.buf)
+			if got := chunk.IsValid(); got != test.wantValid {
+				t.Fatalf("got chunk.IsValid() = %t, want = %t", got, test.wantValid)
+			}
+			if !test.wantValid {
+				return
+			}
+
+			if got := chunk.CumulativeTSNAck(); got != test.wantCumulativeTSNAck {
+				t.Errorf("got chunk.CumulativeTSNAck() = %d, want = %d", got, test.wantCumulativeTSNAck)
+			}
+		})
+	}
+}
+
+func TestSCTPAbortChunk(t *testing.T) {
+	tests := []struct {
+		name             string
+		buf              []byte
+		wantValid        bool
+		wantTagReflected bool
+		wantCauses       []header.SCTPErrorCause
+		wantErr          error
+	}{
+		{
+			name: "one error cause",
+			buf: []byte{
+				0x06, 0x01, 0x00, 0x0b,
+				// User-Initiated Abort with a 3-byte reason and 1 byte of
+				// padding that is not part of the chunk length.
+				0x00, 0x0c, 0x00, 0x07,
+				'b', 'y', 'e',
+				0,
+			},
+			wantValid:        true,
+			wantTagReflected: true,
+			wantCauses: []header.SCTPErrorCause{
+				{Code: 12, Info: []byte("bye")},
+			},
+		},
+		{
+			name: "two error causes",
+			buf: []byte{
+				0x06, 0x00, 0x00, 0x14,
+				0x00, 0x0d, 0x00, 0x05,
+				'x',
+				0, 0, 0,
+				0x00, 0x0c, 0x00, 0x08,
+				'a', 'b', 'c', 'd',
+			},
+			wantValid: true,
+			wantCauses: []header.SCTPErrorCause{
+				{Code: 13, Info: []byte("x")},
+				{Code: 12, Info: []byte("abcd")},
+			},
+		},
+		{
+			name:      "no error causes",
+			buf:       []byte{0x06, 0x00, 0x00, 0x04},
+			wantValid: true,
+		},
+		{
+			name: "error cause length exceeds chunk",
+			buf: []byte{
+				0x06, 0x00, 0x00, 0x08,
+				0x00, 0x0c, 0x00, 0x08,
+				'a', 'b', 'c', 'd',
+			},
+			wantValid: true,
+			wantErr:   header.ErrMalformedSCTPErrorCause,
+		},
+		{
+			name: "error cause length too small",
+			buf: []byte{
+				0x06, 0x00, 0x00, 0x08,
+				0x00, 0x0c, 0x00, 0x02,
+			},
+			wantValid: true,
+			wantErr:   header.ErrMalformedSCTPErrorCause,
+		},
+		{
+			name: "truncated error cause header",
+			buf: []byte{
+				0x06, 0x00, 0x00, 0x06,
+				0x00, 0x0c,
+			},
+			wantValid: true,
+			wantErr:   header.ErrMalformedSCTPErrorCause,
+		},
+		{
+			name: "length exceeds buffer",
+			buf:  []byte{0x06, 0x00, 0x00, 0x0c, 0x00, 0x0c, 0x00, 0x04, 0x00},
+		},
+		{
+			name: "not an ABORT chunk",
+			buf:  []byte{0x07, 0x00, 0x00, 0x04},
+		},
+		{
+			name: "too small",
+			buf:  []byte{0x06, 0x00, 0x00},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			chunk := header.SCTPAbortChunk(test.buf)
+			if got := chunk.IsValid(); got != test.wantValid {
+				t.Fatalf("got chunk.IsValid() = %t, want = %t", got, test.wantValid)
+			}
+			if !test.wantValid {
+				return
+			}
+
+			if got := chunk.TagReflected(); got != test.wantTagReflected {
+				t.Errorf("got chunk.TagReflected() = %t, want = %t", got, test.wantTagReflected)
+			}
+			var causes []header.SCTPErrorCause
+			it := chunk.ErrorCauses()
+			for {
+				cause, done, err := it.Next()
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("got it.Next() = (_, _, %v), want = (_, _, %v)", err, test.wantErr)
+				}
+				if done {
+					break
+				}
+				causes = append(causes, cause)
+			}
+			if diff := cmp.Diff(test.wantCauses, causes); diff != "" {
+				t.Errorf("error causes mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestSCTPChecksum(t *testing.T) {
+	// RFC 3720 appendix B.4 gives the CRC32c of 32 zero bytes, stored in
+	// little-endian byte order.
+	pkt := header.SCTP(make([]byte, 32))
+	const want = 0x8a9136aa
+	if got := pkt.CalculateChecksum(); got != want {
+		t.Fatalf("got pkt.CalculateChecksum() = %#08x, want = %#08x", got, want)
+	}
+	pkt.SetChecksum(want)
+	if got, want := []byte(pkt[8:12]), []byte{0xaa, 0x36, 0x91, 0x8a}; !bytes.Equal(got, want) {
+		t.Errorf("got checksum field = %x, want = %x", got, want)
+	}
+	// The checksum field is treated as zero.
+	if got := pkt.CalculateChecksum(); got != want {
+		t.Errorf("got pkt.CalculateChecksum() = %#08x after setting the checksum, want = %#08x", got, want)
+	}
+	if !pkt.IsChecksumValid() {
+		t.Error("got pkt.IsChecksumValid() = false, want = true")
+	}
+}
+
+func TestValidateSCTPOverUDP(t *testing.T) {
+	// makeSCTP returns an SCTP packet carrying a DATA chunk, with a valid
+	// CRC32c.
+	makeSCTP := func() []byte {
+		b := []byte{
+			0x13, 0x88, 0x13, 0x89,
+			0xde, 0xad, 0xbe, 0xef,
+			0x00, 0x00, 0x00, 0x00,
+			0x00, 0x03, 0x00, 0x14,
+			0x00, 0x00, 0x10, 0x00,
+			0x00, 0x02, 0x00, 0x05,
+			0x00, 0x00, 0x00, 0x33,
+			1, 2, 3, 4,
+		}
+		sctp := header.SCTP(b)
+		sctp.SetChecksum(sctp.CalculateChecksum())
+		return b
+	}
+	// makeUDP returns a UDP datagram encapsulating an SCTP packet over
+	// netProto, with the given modification applied to the SCTP packet after
+	// its CRC32c was computed and before the UDP checksum is computed.
+	makeUDP := func(netProto tcpip.NetworkProtocolNumber, modify func(header.SCTP)) header.UDP {
+		sctp := makeSCTP()
+		if modify != nil {
+			modify(sctp)
+		}
+		var pkt []byte
+		switch netProto {
+		case header.IPv4ProtocolNumber:
+			pkt = header.IPv4(header.BuildUDPv4Packet(testIPv4SrcAddr, testIPv4DstAddr, header.SCTPUDPEncapsulationPort, header.SCTPUDPEncapsulationPort, sctp, 64)).Payload()
+		case header.IPv6ProtocolNumber:
+			pkt = header.IPv6(header.BuildUDPv6Packet(uniqueLocalAddr1, uniqueLocalAddr2, header.SCTPUDPEncapsulationPort, header.SCTPUDPEncapsulationPort, sctp, 64)).Payload()
+		}
+		return header.UDP(pkt)
+	}
+	addrs := func(netProto tcpip.NetworkProtocolNumber) (tcpip.Address, tcpip.Address) {
+		if netProto == header.IPv4ProtocolNumber {
+			return testIPv4SrcAddr, testIPv4DstAddr
+		}
+		return uniqueLocalAddr1, uniqueLocalAddr2
+	}
+
+	tests := []struct {
+		name     string
+		netProto tcpip.NetworkProtocolNumber
+		udp      func(tcpip.NetworkProtocolNumber) header.UDP
+		wantErr  error
+	}{
+		{
+			name:     "valid over IPv4",
+			netProto: header.IPv4ProtocolNumber,
+			udp: func(netProto tcpip.NetworkProtocolNumber) header.UDP {
+				return makeUDP(netProto, nil)
+			},
+		},
+		{
+			name:     "valid over IPv6",
+			netProto: header.IPv6ProtocolNumber,
+			udp: func(netProto tcpip.NetworkProtocolNumber) header.UDP {
+				return makeUDP(netProto, nil)
+			},
+		},
+		{
+			name:     "zero UDP checksum over IPv4",
+			netProto: header.IPv4ProtocolNumber,
+			udp: func(netProto tcpip.NetworkProtocolNumber) header.UDP {
+				udp := makeUDP(netProto, nil)
+				udp.SetChecksum(0)
+				return udp
+			},
+		},
+		{
+			name:     "zero UDP checksum over IPv6",
+			netProto: header.IPv6ProtocolNumber,
+			udp: func(netProto tcpip.NetworkProtocolNumber) header.UDP {
+				udp := makeUDP(netProto, nil)
+				udp.SetChecksum(0)
+				return udp
+			},
+			wantErr: header.ErrUDPZeroChecksumV6,
+		},
+		{
+			name:     "bad UDP checksum",
+			netProto: header.IPv4ProtocolNumber,
+			udp: func(netProto tcpip.NetworkProtocolNumber) header.UDP {
+				udp := makeUDP(netProto, nil)
+				udp.Payload()[len(udp.Payload())-1]++
+				return udp
+			},
+			wantErr: header.ErrUDPChecksumMismatch,
+		},
+		{
+			name:     "bad SCTP checksum with valid UDP checksum",
+			netProto: header.IPv6ProtocolNumber,
+			udp: func(netProto tcpip.NetworkProtocolNumber) header.UDP {
+				return makeUDP(netProto, func(sctp header.SCTP) {
+					sctp[len(sctp)-1]++
+				})
+			},
+			wantErr: header.ErrSCTPChecksumMismatch,
+		},
+		{
+			name:     "bad SCTP checksum with zero UDP checksum",
+			netProto: header.IPv4ProtocolNumber,
+			udp: func(netProto tcpip.NetworkProtocolNumber) header.UDP {
+				udp := makeUDP(netProto, func(sctp header.SCTP) {
+					sctp[len(sctp)-1]++
+				})
+				udp.SetChecksum(0)
+				return udp
+			},
+			wantErr: header.ErrSCTPChecksumMismatch,
+		},
+		{
+			name:     "truncated SCTP common header",
+			netProto: header.IPv4ProtocolNumber,
+			udp: func(netProto tcpip.NetworkProtocolNumber) header.UDP {
+				return makeUDP(netProto, nil)[:header.UDPMinimumSize+header.SCTPCommonHeaderSize-1]
+			},
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name:     "UDP length mismatch",
+			netProto: header.IPv4ProtocolNumber,
+			udp: func(netProto tcpip.NetworkProtocolNumber) header.UDP {
+				udp := makeUDP(netProto, nil)
+				return udp[:len(udp)-1]
+			},
+			wantErr: header.ErrUDPLengthMismatch,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			src, dst := addrs(test.netProto)
+			if err := header.ValidateSCTPOverUDP(test.udp(test.netProto), src, dst, test.netProto); !errors.Is(err, test.wantErr) {
+				t.Errorf("got header.ValidateSCTPOverUDP(_, %s, %s, %d) = %v, want = %v", src, dst, test.netProto, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestFillSCTPOverUDPChecksum(t *testing.T) {
+	sctp := header.SCTP(make([]byte, header.SCTPCommonHeaderSize+4))
+	sctp[header.SCTPCommonHeaderSize] = 1
+	sctp.SetChecksum(sctp.CalculateChecksum())
+	wantSCTPChecksum := sctp.Checksum()
+
+	udp := header.UDP(make([]byte, header.UDPMinimumSize+len(sctp)))
+	udp.Encode(&header.UDPFields{
+		SrcPort: header.SCTPUDPEncapsulationPort,
+		DstPort: header.SCTPUDPEncapsulationPort,
+		Length:  uint16(len(udp)),
+	})
+	copy(udp.Payload(), sctp)
+	header.FillSCTPOverUDPChecksum(udp, uniqueLocalAddr1, uniqueLocalAddr2, header.IPv6ProtocolNumber)
+
+	if got := header.SCTP(udp.Payload()).Checksum(); got != wantSCTPChecksum {
+		t.Errorf("got SCTP checksum = %#08x, want = %#08x", got, wantSCTPChecksum)
+	}
+	if !udp.IsChecksumValid(uniqueLocalAddr1, uniqueLocalAddr2, header.Checksum(udp.Payload(), 0)) {
+		t.Error("got udp.IsChecksumValid(...) = false, want = true")
+	}
+	if err := header.ValidateSCTPOverUDP(udp, uniqueLocalAddr1, uniqueLocalAddr2, header.IPv6ProtocolNumber); err != nil {
+		t.Errorf("got header.ValidateSCTPOverUDP(...) = %s, want = nil", err)
+	}
+}
@@ -0,0 +1,93 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import "encoding/binary"
+
+// RFC 8489 section 5 defines the header of a STUN message as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|0 0|     STUN Message Type     |         Message Length        |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                         Magic Cookie                          |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                                                               |
+//	|                     Transaction ID (96 bits)                  |
+//	|                                                               |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+const (
+	stunMessageType   = 0
+	stunMessageLength = 2
+	stunMagicCookie   = 4
+	stunTransactionID = 8
+
+	// stunMessageTypeReservedMask is the mask of the two most significant
+	// bits of the message type, which must be zero.
+	stunMessageTypeReservedMask = 0xc000
+
+	// stunAttributeAlignment is the alignment of STUN attributes; the message
+	// length is always a multiple of it.
+	stunAttributeAlignment = 4
+)
+
+const (
+	// STUNPort is the default UDP port for STUN, as per RFC 8489 section 9.
+	STUNPort = 3478
+
+	// STUNMagicCookie is the fixed value of the Magic Cookie field.
+	STUNMagicCookie = 0x2112A442
+
+	// STUNHeaderSize is the size of the STUN message header.
+	STUNHeaderSize = 20
+
+	// STUNTransactionIDSize is the size of the Transaction ID field.
+	STUNTransactionIDSize = 12
+
+	// STUNBindingRequest is the message type of a Binding request, as per RFC
+	// 8489 section 18.2.
+	STUNBindingRequest = 0x0001
+
+	// STUNBindingSuccessResponse is the message type of a Binding success
+	// response, as per RFC 8489 section 18.2.
+	STUNBindingSuccessResponse = 0x0101
+)
+
+// STUNMessage parses the header of the STUN message held in udpPayload.
+//
+// ok is false if udpPayload does not hold a STUN message, that is if it is too
+// short, the two most significant bits are not zero, the Magic Cookie does not
+// match STUNMagicCookie or the Message Length is not a multiple of 4 that fits
+// in udpPayload.
+func STUNMessage(udpPayload []byte) (msgType uint16, txnID [STUNTransactionIDSize]byte, ok bool) {
+	if len(udpPayload) < STUNHeaderSize {
+		return 0, txnID, false
+	}
+	msgType = binary.BigEndian.Uint16(udpPayload[stunMessageType:])
+	if msgType&stunMessageTypeReservedMask != 0 {
+		return 0, txnID, false
+	}
+	if binary.BigEndian.Uint32(udpPayload[stunMagicCookie:]) != STUNMagicCookie {
+		return 0, txnID, false
+	}
+	length := int(binary.BigEndian.Uint16(udpPayload[stunMessageLength:]))
+	if length%stunAttributeAlignment != 0 || STUNHeaderSize+length > len(udpPayload) {
+		return 0, txnID, false
+	}
+
+	copy(txnID[:], udpPayload[stunTransactionID:])
+	return msgType, txnID, true
+}
@@ -0,0 +1,111 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestSTUNMessage(t *testing.T) {
+	txnID := [header.STUNTransactionIDSize]byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11, 12}
+	bindingRequest := func() []byte {
+		return append([]byte{
+			// Message Type = Binding Request.
+			0x00, 0x01,
+			// Message Length.
+			0x00, 0x08,
+			// Magic Cookie.
+			0x21, 0x12, 0xa4, 0x42,
+		}, append(txnID[:],
+			// SOFTWARE attribute.
+			0x80, 0x22, 0x00, 0x04, 'g', 'v', 'i', 's',
+		)...)
+	}
+
+	tests := []struct {
+		name        string
+		mutate      func([]byte) []byte
+		wantMsgType uint16
+		wantOK      bool
+	}{
+		{
+			name:        "BindingRequest",
+			mutate:      func(b []byte) []byte { return b },
+			wantMsgType: header.STUNBindingRequest,
+			wantOK:      true,
+		},
+		{
+			name:   "Truncated",
+			mutate: func(b []byte) []byte { return b[:header.STUNHeaderSize-1] },
+		},
+		{
+			name: "HighBitsSet",
+			mutate: func(b []byte) []byte {
+				b[0] |= 0x40
+				return b
+			},
+		},
+		{
+			name: "BadMagicCookie",
+			mutate: func(b []byte) []byte {
+				b[4] = 0
+				return b
+			},
+		},
+		{
+			name: "UnalignedLength",
+			mutate: func(b []byte) []byte {
+				b[3] = 7
+				return b
+			},
+		},
+		{
+			name: "LengthTooLarge",
+			mutate: func(b []byte) []byte {
+				b[3] = 12
+				return b
+			},
+		},
+		{
+			name: "DNSQuery",
+			mutate: func([]byte) []byte {
+				return []byte{
+					0x12, 0x34, 0x01, 0x00, 0x00, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00,
+					7, 'e', 'x', 'a', 'm', 'p', 'l', 'e', 3, 'c', 'o', 'm', 0, 0x00, 0x01, 0x00, 0x01,
+				}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			msgType, gotTxnID, ok := header.STUNMessage(test.mutate(bindingRequest()))
+			if ok != test.wantOK {
+				t.Fatalf("got header.STUNMessage(_) ok = %t, want = %t", ok, test.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if msgType != test.wantMsgType {
+				t.Errorf("got msgType = 0x%04x, want = 0x%04x", msgType, test.wantMsgType)
+			}
+			if gotTxnID != txnID {
+				t.Errorf("got txnID = %x, want = %x", gotTxnID, txnID)
+			}
+		})
+	}
+}
@@ -16,9 +16,13 @@ package header
 
 import (
 	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
 
 	"github.com/google/btree"
 	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/buffer"
 	"gvisor.dev/gvisor/pkg/tcpip/seqnum"
 )
 
@@ -140,6 +144,12 @@ type TCPSynOptions struct {
 	// TSEcr is the value of the TSEcr field in the timestamp option.
 	TSEcr uint32
 
+	// NonZeroTSEcr is set by ParseSynOptions when the timestamp option of a
+	// SYN segment without the ACK flag carries a non-zero TSEcr. The TSEcr
+	// field of such a segment must be zero as there is nothing to echo yet,
+	// as per RFC 7323 section 3.2; its value is ignored.
+	NonZeroTSEcr bool
+
 	// SACKPermitted is true if the SACK option was provided in the SYN/SYN-ACK.
 	SACKPermitted bool
 }
@@ -166,6 +176,18 @@ func (r SACKBlock) Contains(b SACKBlock) bool {
 	return r.Start.LessThanEq(b.Start) && b.End.LessThanEq(r.End)
 }
 
+// IsDSACK returns true iff firstBlock, the first SACK block of a segment
+// acknowledging up to cumAck, reports a duplicate segment (D-SACK), i.e. it
+// covers data that is already cumulatively acknowledged, as per RFC 2883
+// section 4.
+//
+// A D-SACK block may also be reported above cumAck when it is contained in
+// the second SACK block of the segment; callers can detect this case with
+// SACKBlock.Contains.
+func IsDSACK(firstBlock SACKBlock, cumAck seqnum.Value) bool {
+	return firstBlock.End.LessThanEq(cumAck)
+}
+
 // TCPOptions are used to parse and cache the TCP segment options for a non
 // syn/syn-ack segment.
 //
@@ -247,11 +269,25 @@ func (b TCP) Payload() []byte {
 	return b[b.DataOffset():]
 }
 
+// HasData returns true iff the tcp packet carries data beyond its header.
+func (b TCP) HasData() bool {
+	return len(b) > int(b.DataOffset())
+}
+
 // Flags returns the flags field of the tcp header.
 func (b TCP) Flags() TCPFlags {
 	return TCPFlags(b[TCPFlagsOffset])
 }
 
+// FlagCount returns the number of control bits set in the flags field of the
+// tcp header, including the ECE and CWR bits defined by RFC 3168.
+//
+// It allows scoring anomalous combinations, such as the segments sent by null
+// scans, which have no flags set, and XMAS scans, which have most of them set.
+func (b TCP) FlagCount() int {
+	return bits.OnesCount8(b[TCPFlagsOffset])
+}
+
 // WindowSize returns the "window size" field of the tcp header.
 func (b TCP) WindowSize() uint16 {
 	return binary.BigEndian.Uint16(b[TCPWinSizeOffset:])
@@ -321,6 +357,40 @@ func (b TCP) CalculateChecksum(partialChecksum uint16) uint16 {
 	return Checksum(b[:b.DataOffset()], partialChecksum)
 }
 
+// IsChecksumValid returns true iff the TCP header's checksum is valid.
+//
+// payloadChecksum and payloadLength are the checksum and length of the
+// segment's payload.
+func (b TCP) IsChecksumValid(src, dst tcpip.Address, payloadChecksum, payloadLength uint16) bool {
+	xsum := PseudoHeaderChecksum(TCPProtocolNumber, src, dst, uint16(b.DataOffset())+payloadLength)
+	xsum = ChecksumCombine(xsum, payloadChecksum)
+	return b.CalculateChecksum(xsum) == 0xffff
+}
+
+// HasChecksum returns true iff the checksum field of the TCP header is
+// non-zero.
+//
+// Unlike UDP, TCP has no way to omit the checksum, so a zero field usually
+// means the sender never filled it in (e.g. a segment that still expects
+// checksum offload). Note that zero is nevertheless a legitimate checksum
+// value, so this is a quick sanity check and not a replacement for
+// IsChecksumValid.
+func (b TCP) HasChecksum() bool {
+	return b.Checksum() != 0
+}
+
+// FillTCPChecksum calculates the checksum of the TCP segment made of the
+// header b followed by payload and writes it into b's checksum field.
+//
+// The data offset field of b must already be set.
+func FillTCPChecksum(b TCP, src, dst tcpip.Address, netProto tcpip.NetworkProtocolNumber, payload buffer.VectorisedView) {
+	checkPseudoHeaderAddresses(src, dst, netProto)
+	b.SetChecksum(0)
+	xsum := PseudoHeaderChecksum(TCPProtocolNumber, src, dst, uint16(int(b.DataOffset())+payload.Size()))
+	xsum = ChecksumVV(payload, xsum)
+	b.SetChecksum(ChecksumToField(b.CalculateChecksum(xsum)))
+}
+
 // Options returns a slice that holds the unparsed TCP options in the segment.
 func (b TCP) Options() []byte {
 	return b[TCPMinimumSize:b.DataOffset()]
@@ -333,6 +403,82 @@ func (b TCP) ParsedOptions() TCPOptions {
 	return ParseTCPOptions(b.Options())
 }
 
+// OptionsConsistent returns true iff the options of the segment exactly fill
+// the region between the fixed header and the data offset.
+//
+// Walking the options must neither overrun the data offset nor stop short of
+// it: as per RFC 793 section 3.1, any bytes following an End of Option List
+// option are padding and must be zero. Padding with NOP options is also
+// accepted. Stacks leaving garbage in the padding can be told apart this way.
+func (b TCP) OptionsConsistent() bool {
+	offset := int(b.DataOffset())
+	if offset < TCPMinimumSize || offset > len(b) {
+		return false
+	}
+	opts := b.Options()
+	limit := len(opts)
+	for i := 0; i < limit; {
+		switch opts[i] {
+		case TCPOptionEOL:
+			for _, pad := range opts[i+1:] {
+				if pad != 0 {
+					return false
+				}
+			}
+			return true
+		case TCPOptionNOP:
+			i++
+		default:
+			if i+2 > limit {
+				return false
+			}
+			l := int(opts[i+1])
+			if l < 2 || i+l > limit {
+				return false
+			}
+			i += l
+		}
+	}
+	return true
+}
+
+// IsTimestampAligned returns true if the segment carries a timestamp option
+// laid out as recommended by RFC 7323 appendix A, that is preceded by two
+// TCPOptionNOP (or anything else of the same length) so that the option
+// block starts on a 4-byte boundary and the TSval and TSecr fields are 32-bit
+// aligned.
+//
+// It returns false if the segment has no well-formed timestamp option.
+func (b TCP) IsTimestampAligned() bool {
+	opts := b.Options()
+	limit := len(opts)
+	for i := 0; i < limit; {
+		switch opts[i] {
+		case TCPOptionEOL:
+			return false
+		case TCPOptionNOP:
+			i++
+		case TCPOptionTS:
+			if i+TCPOptionTSLength > limit || opts[i+1] != TCPOptionTSLength {
+				return false
+			}
+			// The options start right after the fixed header, which is a
+			// multiple of 4 bytes long.
+			return i%4 == 2
+		default:
+			if i+2 > limit {
+				return false
+			}
+			l := int(opts[i+1])
+			if l < 2 || i+l > limit {
+				return false
+			}
+			i += l
+		}
+	}
+	return false
+}
+
 func (b TCP) encodeSubset(seq, ack uint32, flags TCPFlags, rcvwnd uint16) {
 	binary.BigEndian.PutUint32(b[TCPSeqNumOffset:], seq)
 	binary.BigEndian.PutUint32(b[TCPAckNumOffset:], ack)
@@ -370,7 +516,7 @@ func (b TCP) EncodePartial(partialChecksum, length uint16, seqnum, acknum uint32
 	checksum = Checksum(b[TCPWinSizeOffset:TCPWinSizeOffset+2], checksum)
 
 	// Encode the checksum.
-	b.SetChecksum(^checksum)
+	b.SetChecksum(ChecksumToField(checksum))
 }
 
 // ParseSynOptions parses the options received in a SYN segment and returns the
@@ -422,10 +568,13 @@ func ParseSynOptions(opts []byte, isAck bool) TCPSynOptions {
 				return synOpts
 			}
 			synOpts.TSVal = binary.BigEndian.Uint32(opts[i+2:])
+			tsEcr := binary.BigEndian.Uint32(opts[i+6:])
 			if isAck {
 				// If the segment is a SYN-ACK then store the Timestamp Echo Reply
 				// in the segment.
-				synOpts.TSEcr = binary.BigEndian.Uint32(opts[i+6:])
+				synOpts.TSEcr = tsEcr
+			} else {
+				synOpts.NonZeroTSEcr = tsEcr != 0
 			}
 			synOpts.TS = true
 			i += 10
@@ -633,3 +782,283 @@ func Acceptable(segSeq seqnum.Value, segLen seqnum.Size, rcvNxt, rcvAcc seqnum.V
 	// as Linux.
 	return rcvNxt.LessThan(segSeq.Add(segLen)) && segSeq.LessThanEq(rcvAcc)
 }
+
+// SegmentAcceptable checks if a segment that starts at segSeq and occupies
+// segLen sequence numbers is acceptable for a receive window that starts at
+// rcvNxt and spans rcvWnd sequence numbers, strictly following the four cases
+// of the table on page 69 of RFC 793:
+//
+//	Segment Length  Receive Window  Test
+//	0               0               SEG.SEQ = RCV.NXT
+//	0               >0              RCV.NXT =< SEG.SEQ < RCV.NXT+RCV.WND
+//	>0              0               not acceptable
+//	>0              >0              RCV.NXT =< SEG.SEQ < RCV.NXT+RCV.WND or
+//	                                RCV.NXT =< SEG.SEQ+SEG.LEN-1 < RCV.NXT+RCV.WND
+//
+// Unlike Acceptable, it does not mimic Linux's more lenient behavior at the
+// right edge of the window.
+func SegmentAcceptable(segSeq seqnum.Value, segLen seqnum.Size, rcvNxt seqnum.Value, rcvWnd seqnum.Size) bool {
+	switch {
+	case segLen == 0 && rcvWnd == 0:
+		return segSeq == rcvNxt
+	case segLen == 0:
+		return segSeq.InWindow(rcvNxt, rcvWnd)
+	case rcvWnd == 0:
+		return false
+	default:
+		return segSeq.InWindow(rcvNxt, rcvWnd) || segSeq.Add(segLen-1).InWindow(rcvNxt, rcvWnd)
+	}
+}
+
+// RSTAcceptable checks if the RST segment seg may be accepted when the receive
+// window starts at rcvNxt and spans rcvWnd sequence numbers, as per RFC 5961
+// section 3.2.
+//
+// accept is true iff the sequence number of seg exactly matches rcvNxt, in
+// which case the connection should be reset. challenge is true iff the
+// sequence number is within the receive window but does not match rcvNxt, in
+// which case a challenge ACK should be sent instead. If neither is true, seg
+// should be silently dropped.
+func RSTAcceptable(seg TCP, rcvNxt seqnum.Value, rcvWnd seqnum.Size) (accept bool, challenge bool) {
+	if seg.Flags()&TCPFlagRst == 0 {
+		return false, false
+	}
+	segSeq := seqnum.Value(seg.SequenceNumber())
+	if segSeq == rcvNxt {
+		return true, false
+	}
+	return false, segSeq.InWindow(rcvNxt, rcvWnd)
+}
+
+// NeedsChallengeACK checks if seg, received on a synchronized connection with
+// the given send state, should be answered with a challenge ACK, as per
+// RFC 5961. sndUna and sndNxt are the oldest unacknowledged and the next
+// sequence numbers to send, and maxSndWnd is the largest window the peer has
+// ever advertised (MAX.SND.WND).
+//
+// That is the case when seg is a SYN segment, regardless of its sequence
+// number (section 4.2), or when the acknowledgement number of seg lies outside
+// of the acceptable range [SND.UNA - MAX.SND.WND, SND.NXT] (section 5.2), which
+// covers both acknowledgements of data that has not been sent yet and
+// acknowledgements that are too old. RST segments are not covered; see
+// RSTAcceptable.
+func NeedsChallengeACK(seg TCP, sndUna, sndNxt seqnum.Value, maxSndWnd seqnum.Size) bool {
+	flags := seg.Flags()
+	if flags&TCPFlagRst != 0 {
+		return false
+	}
+	if flags&TCPFlagSyn != 0 {
+		return true
+	}
+	if flags&TCPFlagAck == 0 {
+		return false
+	}
+	ack := seqnum.Value(seg.AckNumber())
+	return !ack.InRange(sndUna-seqnum.Value(maxSndWnd), sndNxt.Add(1))
+}
+
+// AcksNewData returns true iff seg acknowledges data that was sent but not
+// yet acknowledged, i.e. its acknowledgement number lies in (sndUna, sndNxt],
+// as per RFC 793 page 72.
+func AcksNewData(seg TCP, sndUna, sndNxt seqnum.Value) bool {
+	if seg.Flags()&TCPFlagAck == 0 {
+		return false
+	}
+	ack := seqnum.Value(seg.AckNumber())
+	return sndUna.LessThan(ack) && ack.LessThanEq(sndNxt)
+}
+
+// IsDuplicateAck returns true iff seg, which carries payloadLen bytes of data,
+// is a duplicate acknowledgement given lastAck and lastWindow, the
+// acknowledgement number and raw window field of the last acknowledgement
+// received, as per RFC 5681 section 2: it carries no data, has neither SYN
+// nor FIN set, and repeats both lastAck and lastWindow.
+//
+// An acknowledgement reporting a D-SACK block only signals a duplicate
+// segment at the receiver, as per RFC 2883 section 4, and is not counted.
+//
+// The caller remains responsible for checking that data is outstanding.
+func IsDuplicateAck(seg TCP, payloadLen int, lastAck seqnum.Value, lastWindow uint16) bool {
+	flags := seg.Flags()
+	if flags&TCPFlagAck == 0 || flags&(TCPFlagSyn|TCPFlagFin) != 0 || payloadLen != 0 {
+		return false
+	}
+	if seqnum.Value(seg.AckNumber()) != lastAck || seg.WindowSize() != lastWindow {
+		return false
+	}
+	if blocks := seg.ParsedOptions().SACKBlocks; len(blocks) != 0 && IsDSACK(blocks[0], lastAck) {
+		return false
+	}
+	return true
+}
+
+// BuildTCPFin returns a FIN|ACK segment with no payload sent from srcPort on
+// src to dstPort on dst, with its checksum computed over the pseudo-header of
+// netProto.
+func BuildTCPFin(src, dst tcpip.Address, netProto tcpip.NetworkProtocolNumber, srcPort, dstPort uint16, seq, ack uint32, window uint16) []byte {
+	return buildTCPControl(src, dst, netProto, srcPort, dstPort, seq, ack, TCPFlagFin|TCPFlagAck, window)
+}
+
+// BuildTCPAck returns a segment with no payload sent from src to dst that
+// acknowledges seg, a segment received from dst, as computed by NextAck.
+//
+// The ports of seg are swapped and the returned segment's checksum is
+// computed over the pseudo-header of netProto.
+func BuildTCPAck(seg TCP, src, dst tcpip.Address, netProto tcpip.NetworkProtocolNumber, seq uint32, window uint16) []byte {
+	return buildTCPControl(src, dst, netProto, seg.DestinationPort(), seg.SourcePort(), seq, NextAck(seg, len(seg.Payload())), TCPFlagAck, window)
+}
+
+// BuildTCPSYNv4 returns an IPv4 packet sent from src to dst with a TTL of ttl,
+// carrying a SYN segment sent from srcPort to dstPort with the initial
+// sequence number isn, an MSS option advertising mss and the largest
+// unscaled window. Both the IPv4 header and TCP checksums are computed.
+func BuildTCPSYNv4(src, dst tcpip.Address, srcPort, dstPort uint16, isn uint32, mss uint16, ttl uint8) []byte {
+	const tcpLen = TCPMinimumSize + TCPOptionMSSLength
+	b := make([]byte, IPv4MinimumSize+tcpLen)
+	ip := IPv4(b)
+	ip.Encode(&IPv4Fields{
+		TotalLength: uint16(len(b)),
+		TTL:         ttl,
+		Protocol:    uint8(TCPProtocolNumber),
+		SrcAddr:     src,
+		DstAddr:     dst,
+	})
+	ip.SetChecksum(ChecksumToField(ip.CalculateChecksum()))
+	encodeTCPSYN(TCP(ip.Payload()), src, dst, IPv4ProtocolNumber, srcPort, dstPort, isn, mss)
+	return b
+}
+
+// BuildTCPSYNv6 returns an IPv6 packet sent from src to dst with a hop limit
+// of hopLimit, carrying a SYN segment sent from srcPort to dstPort with the
+// initial sequence number isn, an MSS option advertising mss and the largest
+// unscaled window. The TCP checksum is computed over the IPv6 pseudo-header.
+func BuildTCPSYNv6(src, dst tcpip.Address, srcPort, dstPort uint16, isn uint32, mss uint16, hopLimit uint8) []byte {
+	const tcpLen = TCPMinimumSize + TCPOptionMSSLength
+	b := make([]byte, IPv6MinimumSize+tcpLen)
+	ip := IPv6(b)
+	ip.Encode(&IPv6Fields{
+		PayloadLength:     tcpLen,
+		TransportProtocol: TCPProtocolNumber,
+		HopLimit:          hopLimit,
+		SrcAddr:           src,
+		DstAddr:           dst,
+	})
+	encodeTCPSYN(TCP(ip.Payload()), src, dst, IPv6ProtocolNumber, srcPort, dstPort, isn, mss)
+	return b
+}
+
+// encodeTCPSYN encodes a SYN segment with an MSS option and the largest
+// unscaled window into b, which must be exactly large enough to hold it, and
+// computes its checksum over the pseudo-header of netProto.
+func encodeTCPSYN(b TCP, src, dst tcpip.Address, netProto tcpip.NetworkProtocolNumber, srcPort, dstPort uint16, isn uint32, mss uint16) {
+	b.Encode(&TCPFields{
+		SrcPort:    srcPort,
+		DstPort:    dstPort,
+		SeqNum:     isn,
+		DataOffset: uint8(len(b)),
+		Flags:      TCPFlagSyn,
+		WindowSize: math.MaxUint16,
+	})
+	EncodeMSSOption(uint32(mss), b.Options())
+	FillTCPChecksum(b, src, dst, netProto, buffer.VectorisedView{})
+}
+
+// buildTCPControl returns a segment with no payload and no options holding
+// the given fields, with its checksum computed over the pseudo-header of
+// netProto.
+func buildTCPControl(src, dst tcpip.Address, netProto tcpip.NetworkProtocolNumber, srcPort, dstPort uint16, seq, ack uint32, flags TCPFlags, window uint16) []byte {
+	b := TCP(make([]byte, TCPMinimumSize))
+	b.Encode(&TCPFields{
+		SrcPort:    srcPort,
+		DstPort:    dstPort,
+		SeqNum:     seq,
+		AckNum:     ack,
+		DataOffset: TCPMinimumSize,
+		Flags:      flags,
+		WindowSize: window,
+	})
+	FillTCPChecksum(b, src, dst, netProto, buffer.VectorisedView{})
+	return b
+}
+
+// SegmentLength returns the number of sequence numbers consumed by seg, which
+// carries payloadLen bytes of data.
+//
+// As per RFC 793 section 3.3, the SYN and FIN flags each occupy one sequence
+// number in addition to the payload.
+func SegmentLength(seg TCP, payloadLen int) uint32 {
+	l := uint32(payloadLen)
+	flags := seg.Flags()
+	if flags&TCPFlagSyn != 0 {
+		l++
+	}
+	if flags&TCPFlagFin != 0 {
+		l++
+	}
+	return l
+}
+
+// NextAck returns the acknowledgement number that acknowledges seg, which
+// carries payloadLen bytes of data.
+func NextAck(seg TCP, payloadLen int) uint32 {
+	return seg.SequenceNumber() + SegmentLength(seg, payloadLen)
+}
+
+// WindowScaler computes the value of the Window field of outgoing segments
+// from the receive window available, as per RFC 7323 section 2.3.
+//
+// Right-shifting the available window by the scale truncates the bytes that
+// are not a multiple of 2^Scale. WindowScaler tracks them so that the caller
+// can account for the receive space not advertised to the peer.
+type WindowScaler struct {
+	// Scale is the shift count sent in the Window Scale option. Values
+	// above MaxWndScale are treated as MaxWndScale.
+	Scale uint8
+
+	// hidden is the number of bytes of the last available window that
+	// are not covered by the advertised window.
+	hidden uint32
+}
+
+// Advertise returns the Window field advertising as much of available as
+// possible, in units of 2^Scale bytes. The window is rounded down, never up,
+// so that the peer is never allowed to send more than available bytes.
+func (w *WindowScaler) Advertise(available uint32) uint16 {
+	scale := w.Scale
+	if scale > MaxWndScale {
+		scale = MaxWndScale
+	}
+	wnd := available >> scale
+	if wnd > math.MaxUint16 {
+		wnd = math.MaxUint16
+	}
+	w.hidden = available - wnd<<scale
+	return uint16(wnd)
+}
+
+// Hidden returns the number of bytes of the window last passed to Advertise
+// that the advertised window does not cover, due to truncation by the scale
+// or to the window exceeding the largest value the Window field can hold.
+func (w *WindowScaler) Hidden() uint32 {
+	return w.hidden
+}
+
+// MaxTCPPayload returns the largest payload a segment carrying tcpOptionsLen
+// bytes of options can hold in an IP packet of netProto without extension
+// headers or IPv4 options that fits in mtu, or zero if the headers alone do
+// not fit.
+func MaxTCPPayload(mtu int, netProto tcpip.NetworkProtocolNumber, tcpOptionsLen int) int {
+	var ipHdrLen int
+	switch netProto {
+	case IPv4ProtocolNumber:
+		ipHdrLen = IPv4MinimumSize
+	case IPv6ProtocolNumber:
+		ipHdrLen = IPv6MinimumSize
+	default:
+		panic(fmt.Sprintf("unsupported network protocol number = %d", netProto))
+	}
+	if payload := mtu - ipHdrLen - TCPMinimumSize - tcpOptionsLen; payload > 0 {
+		return payload
+	}
+	return 0
+}
@@ -0,0 +1,142 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"fmt"
+	"io"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/buffer"
+)
+
+// SplitTCPSuperSegment splits the TCP segment held in the IPv4 or IPv6 packet
+// ipPacket, typically coalesced by a NIC performing GRO, into packets carrying
+// at most mss bytes of TCP payload each.
+//
+// Every resulting packet replicates the IP header and the TCP header,
+// including TCP options such as timestamps. Sequence numbers advance by the
+// payload carried by the preceding packets, IP lengths and checksums are
+// recomputed and, for IPv4, the identification field is incremented for each
+// packet. The FIN and PSH flags are only kept on the last packet.
+//
+// IPv6 packets carrying extension headers and IPv4 fragments are not
+// supported.
+func SplitTCPSuperSegment(ipPacket []byte, mss int) ([][]byte, error) {
+	if mss <= 0 {
+		panic(fmt.Sprintf("got mss = %d, want > 0", mss))
+	}
+
+	var (
+		ipHdr    []byte
+		ipData   []byte
+		src, dst tcpip.Address
+		netProto tcpip.NetworkProtocolNumber
+	)
+	switch IPVersion(ipPacket) {
+	case IPv4Version:
+		ipv4 := IPv4(ipPacket)
+		if !ipv4.IsValid(len(ipPacket)) {
+			return nil, fmt.Errorf("got invalid IPv4 packet of %d bytes", len(ipPacket))
+		}
+		if ipv4.More() || ipv4.FragmentOffset() != 0 {
+			return nil, fmt.Errorf("got IPv4 fragment with offset = %d, more fragments = %t", ipv4.FragmentOffset(), ipv4.More())
+		}
+		if proto := ipv4.TransportProtocol(); proto != TCPProtocolNumber {
+			return nil, fmt.Errorf("got IPv4 transport protocol = %d, want = %d", proto, TCPProtocolNumber)
+		}
+		ipHdr = ipPacket[:ipv4.HeaderLength()]
+		ipData = ipv4.Payload()
+		src, dst = ipv4.SourceAddress(), ipv4.DestinationAddress()
+		netProto = IPv4ProtocolNumber
+	case IPv6Version:
+		ipv6 := IPv6(ipPacket)
+		if !ipv6.IsValid(len(ipPacket)) {
+			return nil, fmt.Errorf("got invalid IPv6 packet of %d bytes", len(ipPacket))
+		}
+		if proto := ipv6.TransportProtocol(); proto != TCPProtocolNumber {
+			return nil, fmt.Errorf("got IPv6 next header = %d, want = %d", proto, TCPProtocolNumber)
+		}
+		ipHdr = ipPacket[:IPv6MinimumSize]
+		ipData = ipv6.Payload()
+		src, dst = ipv6.SourceAddress(), ipv6.DestinationAddress()
+		netProto = IPv6ProtocolNumber
+	default:
+		return nil, fmt.Errorf("got IP version = %d, want = %d or %d", IPVersion(ipPacket), IPv4Version, IPv6Version)
+	}
+
+	if len(ipData) < TCPMinimumSize {
+		return nil, fmt.Errorf("got %d bytes for TCP segment, want at least %d: %w", len(ipData), TCPMinimumSize, io.ErrUnexpectedEOF)
+	}
+	tcp := TCP(ipData)
+	tcpHdrLen := int(tcp.DataOffset())
+	if tcpHdrLen < TCPMinimumSize {
+		return nil, fmt.Errorf("got TCP data offset = %d, want at least %d", tcpHdrLen, TCPMinimumSize)
+	}
+	if tcpHdrLen > len(ipData) {
+		return nil, fmt.Errorf("got TCP data offset = %d for a segment of %d bytes: %w", tcpHdrLen, len(ipData), io.ErrUnexpectedEOF)
+	}
+	tcpHdr := ipData[:tcpHdrLen]
+	payload := ipData[tcpHdrLen:]
+
+	seq := tcp.SequenceNumber()
+	flags := tcp.Flags()
+	var id uint16
+	if netProto == IPv4ProtocolNumber {
+		id = IPv4(ipPacket).ID()
+	}
+
+	numSegs := (len(payload) + mss - 1) / mss
+	if numSegs == 0 {
+		numSegs = 1
+	}
+	segs := make([][]byte, 0, numSegs)
+	for i := 0; i < numSegs; i++ {
+		start := i * mss
+		end := start + mss
+		if end > len(payload) {
+			end = len(payload)
+		}
+		data := payload[start:end]
+
+		b := make([]byte, len(ipHdr)+tcpHdrLen+len(data))
+		copy(b, ipHdr)
+		copy(b[len(ipHdr):], tcpHdr)
+		copy(b[len(ipHdr)+tcpHdrLen:], data)
+
+		switch netProto {
+		case IPv4ProtocolNumber:
+			ipv4 := IPv4(b)
+			ipv4.SetTotalLength(uint16(len(b)))
+			ipv4.SetID(id + uint16(i))
+			ipv4.SetChecksum(0)
+			ipv4.SetChecksum(ChecksumToField(ipv4.CalculateChecksum()))
+		case IPv6ProtocolNumber:
+			IPv6(b).SetPayloadLength(uint16(tcpHdrLen + len(data)))
+		}
+
+		seg := TCP(b[len(ipHdr):])
+		seg.SetSequenceNumber(seq + uint32(start))
+		segFlags := flags
+		if i != numSegs-1 {
+			segFlags &^= TCPFlagFin | TCPFlagPsh
+		}
+		seg.SetFlags(uint8(segFlags))
+		FillTCPChecksum(seg, src, dst, netProto, buffer.View(data).ToVectorisedView())
+
+		segs = append(segs, b)
+	}
+	return segs, nil
+}
@@ -0,0 +1,237 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/buffer"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+// makeTCPSegment returns a TCP segment carrying payload with a timestamp
+// option, sent from src to dst, with a valid checksum.
+func makeTCPSegment(src, dst tcpip.Address, netProto tcpip.NetworkProtocolNumber, seq uint32, flags header.TCPFlags, payload []byte) []byte {
+	const hdrLen = header.TCPMinimumSize + 12
+	b := make([]byte, hdrLen+len(payload))
+	tcp := header.TCP(b)
+	tcp.Encode(&header.TCPFields{
+		SrcPort:    1234,
+		DstPort:    80,
+		SeqNum:     seq,
+		AckNum:     1,
+		DataOffset: hdrLen,
+		Flags:      flags,
+		WindowSize: 4096,
+	})
+	opts := b[header.TCPMinimumSize:hdrLen]
+	header.EncodeNOP(opts)
+	header.EncodeNOP(opts[1:])
+	header.EncodeTSOption(100, 200, opts[2:])
+	copy(b[hdrLen:], payload)
+	header.FillTCPChecksum(tcp, src, dst, netProto, buffer.View(payload).ToVectorisedView())
+	return b
+}
+
+func TestSplitTCPSuperSegment(t *testing.T) {
+	const (
+		mss    = 1000
+		seq    = 0xfffffc00
+		ipv4ID = 7
+	)
+	payload := make([]byte, 3500)
+	for i := range payload {
+		payload[i] = byte(i)
+	}
+
+	tests := []struct {
+		name     string
+		netProto tcpip.NetworkProtocolNumber
+		src, dst tcpip.Address
+		makePkt  func(tcp []byte) []byte
+	}{
+		{
+			name:     "IPv4",
+			netProto: header.IPv4ProtocolNumber,
+			src:      testIPv4SrcAddr,
+			dst:      testIPv4DstAddr,
+			makePkt: func(tcp []byte) []byte {
+				return makeIPv4Packet(header.IPv4Fields{
+					ID:       ipv4ID,
+					Flags:    header.IPv4FlagDontFragment,
+					Protocol: uint8(header.TCPProtocolNumber),
+				}, tcp)
+			},
+		},
+		{
+			name:     "IPv6",
+			netProto: header.IPv6ProtocolNumber,
+			src:      header.IPv6Loopback,
+			dst:      header.IPv6Loopback,
+			makePkt: func(tcp []byte) []byte {
+				return makeIPv6Packet(header.IPv6Fields{
+					TransportProtocol: header.TCPProtocolNumber,
+				}, tcp)
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			flags := header.TCPFlagAck | header.TCPFlagPsh | header.TCPFlagFin
+			pkt := test.makePkt(makeTCPSegment(test.src, test.dst, test.netProto, seq, flags, payload))
+
+			segs, err := header.SplitTCPSuperSegment(pkt, mss)
+			if err != nil {
+				t.Fatalf("header.SplitTCPSuperSegment(_, %d): %s", mss, err)
+			}
+			if got, want := len(segs), 4; got != want {
+				t.Fatalf("got len(segs) = %d, want = %d", got, want)
+			}
+
+			var reassembled []byte
+			wantSeq := uint32(seq)
+			for i, b := range segs {
+				var tcp header.TCP
+				switch test.netProto {
+				case header.IPv4ProtocolNumber:
+					ipv4 := header.IPv4(b)
+					if !ipv4.IsValid(len(b)) {
+						t.Fatalf("segment %d: got ipv4.IsValid(%d) = false, want = true", i, len(b))
+					}
+					if got := ipv4.TotalLength(); int(got) != len(b) {
+						t.Errorf("segment %d: got ipv4.TotalLength() = %d, want = %d", i, got, len(b))
+					}
+					if got, want := ipv4.ID(), uint16(ipv4ID+i); got != want {
+						t.Errorf("segment %d: got ipv4.ID() = %d, want = %d", i, got, want)
+					}
+					if got := ipv4.CalculateChecksum(); got != 0xffff {
+						t.Errorf("segment %d: got ipv4.CalculateChecksum() = %#x, want = 0xffff", i, got)
+					}
+					tcp = header.TCP(ipv4.Payload())
+				case header.IPv6ProtocolNumber:
+					ipv6 := header.IPv6(b)
+					if got, want := int(ipv6.PayloadLength()), len(b)-header.IPv6MinimumSize; got != want {
+						t.Errorf("segment %d: got ipv6.PayloadLength() = %d, want = %d", i, got, want)
+					}
+					tcp = header.TCP(ipv6.Payload())
+				}
+
+				if got := tcp.SequenceNumber(); got != wantSeq {
+					t.Errorf("segment %d: got tcp.SequenceNumber() = %d, want = %d", i, got, wantSeq)
+				}
+				if got := len(tcp.Payload()); got > mss {
+					t.Errorf("segment %d: got len(tcp.Payload()) = %d, want <= %d", i, got, mss)
+				}
+				wantFlags := header.TCPFlagAck
+				if i == len(segs)-1 {
+					wantFlags = flags
+				}
+				if got := tcp.Flags(); got != wantFlags {
+					t.Errorf("segment %d: got tcp.Flags() = %s, want = %s", i, got, wantFlags)
+				}
+				if opts := tcp.ParsedOptions(); !opts.TS || opts.TSVal != 100 || opts.TSEcr != 200 {
+					t.Errorf("segment %d: got tcp.ParsedOptions() = %+v, want TSVal = 100 and TSEcr = 200", i, opts)
+				}
+				payloadXsum := header.Checksum(tcp.Payload(), 0)
+				if !tcp.IsChecksumValid(test.src, test.dst, payloadXsum, uint16(len(tcp.Payload()))) {
+					t.Errorf("segment %d: got tcp.IsChecksumValid(...) = false, want = true", i)
+				}
+
+				wantSeq += uint32(len(tcp.Payload()))
+				reassembled = append(reassembled, tcp.Payload()...)
+			}
+			if !bytes.Equal(reassembled, payload) {
+				t.Error("reassembled payload does not match the original payload")
+			}
+		})
+	}
+}
+
+func TestSplitTCPSuperSegmentSmall(t *testing.T) {
+	payload := []byte{1, 2, 3, 4}
+	pkt := makeIPv4Packet(header.IPv4Fields{
+		Protocol: uint8(header.TCPProtocolNumber),
+	}, makeTCPSegment(testIPv4SrcAddr, testIPv4DstAddr, header.IPv4ProtocolNumber, 1000, header.TCPFlagAck|header.TCPFlagPsh, payload))
+
+	segs, err := header.SplitTCPSuperSegment(pkt, 1000)
+	if err != nil {
+		t.Fatalf("header.SplitTCPSuperSegment(_, 1000): %s", err)
+	}
+	if len(segs) != 1 {
+		t.Fatalf("got len(segs) = %d, want = 1", len(segs))
+	}
+	if !bytes.Equal(segs[0], pkt) {
+		t.Errorf("got segs[0] = %x, want = %x", segs[0], pkt)
+	}
+}
+
+func TestSplitTCPSuperSegmentErrors(t *testing.T) {
+	tcp := makeTCPSegment(testIPv4SrcAddr, testIPv4DstAddr, header.IPv4ProtocolNumber, 1000, header.TCPFlagAck, make([]byte, 100))
+
+	tests := []struct {
+		name    string
+		pkt     []byte
+		wantErr error
+	}{
+		{
+			name: "not TCP",
+			pkt: makeIPv4Packet(header.IPv4Fields{
+				Protocol: uint8(header.UDPProtocolNumber),
+			}, testUDPHeader),
+		},
+		{
+			name: "IPv4 fragment",
+			pkt: makeIPv4Packet(header.IPv4Fields{
+				Flags:    header.IPv4FlagMoreFragments,
+				Protocol: uint8(header.TCPProtocolNumber),
+			}, tcp),
+		},
+		{
+			name: "truncated TCP header",
+			pkt: makeIPv4Packet(header.IPv4Fields{
+				Protocol: uint8(header.TCPProtocolNumber),
+			}, tcp[:header.TCPMinimumSize-1]),
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name: "truncated TCP options",
+			pkt: makeIPv4Packet(header.IPv4Fields{
+				Protocol: uint8(header.TCPProtocolNumber),
+			}, tcp[:header.TCPMinimumSize+4]),
+			wantErr: io.ErrUnexpectedEOF,
+		},
+		{
+			name: "bad IP version",
+			pkt:  []byte{0x50, 0, 0, 0},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := header.SplitTCPSuperSegment(test.pkt, 10)
+			if err == nil {
+				t.Fatal("got header.SplitTCPSuperSegment(_, 10) = nil error, want non-nil")
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Errorf("got header.SplitTCPSuperSegment(_, 10) = %s, want error wrapping %s", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -15,10 +15,13 @@
 package header_test
 
 import (
+	"math"
 	"reflect"
 	"testing"
 
+	"gvisor.dev/gvisor/pkg/tcpip"
 	"gvisor.dev/gvisor/pkg/tcpip/header"
+	"gvisor.dev/gvisor/pkg/tcpip/seqnum"
 )
 
 func TestEncodeSACKBlocks(t *testing.T) {
@@ -166,3 +169,795 @@ func TestTCPFlags(t *testing.T) {
 		}
 	}
 }
+
+func TestTCPHasData(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		options []byte
+		payload []byte
+		want    bool
+	}{
+		{name: "pure ACK", want: false},
+		{name: "pure ACK with options", options: []byte{header.TCPOptionNOP, header.TCPOptionNOP, header.TCPOptionNOP, header.TCPOptionNOP}, want: false},
+		{name: "data", payload: []byte{1}, want: true},
+		{name: "data with options", options: []byte{header.TCPOptionNOP, header.TCPOptionNOP, header.TCPOptionNOP, header.TCPOptionNOP}, payload: []byte{1, 2, 3}, want: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			hdrLen := header.TCPMinimumSize + len(tt.options)
+			tcp := header.TCP(make([]byte, hdrLen+len(tt.payload)))
+			tcp.Encode(&header.TCPFields{
+				SeqNum:     1,
+				AckNum:     2,
+				DataOffset: uint8(hdrLen),
+				Flags:      header.TCPFlagAck,
+			})
+			copy(tcp[header.TCPMinimumSize:], tt.options)
+			copy(tcp[hdrLen:], tt.payload)
+
+			if got := tcp.HasData(); got != tt.want {
+				t.Errorf("got HasData() = %t, want = %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSegmentAcceptable(t *testing.T) {
+	const rcvNxt = seqnum.Value(1000)
+
+	tests := []struct {
+		name   string
+		segSeq seqnum.Value
+		segLen seqnum.Size
+		rcvWnd seqnum.Size
+		want   bool
+	}{
+		{name: "empty segment, zero window, at RCV.NXT", segSeq: rcvNxt, segLen: 0, rcvWnd: 0, want: true},
+		{name: "empty segment, zero window, after RCV.NXT", segSeq: rcvNxt + 1, segLen: 0, rcvWnd: 0, want: false},
+		{name: "empty segment, open window, at RCV.NXT", segSeq: rcvNxt, segLen: 0, rcvWnd: 100, want: true},
+		{name: "empty segment, open window, at last byte", segSeq: rcvNxt + 99, segLen: 0, rcvWnd: 100, want: true},
+		{name: "empty segment, open window, at right edge", segSeq: rcvNxt + 100, segLen: 0, rcvWnd: 100, want: false},
+		{name: "empty segment, open window, before RCV.NXT", segSeq: rcvNxt - 1, segLen: 0, rcvWnd: 100, want: false},
+		{name: "data, zero window", segSeq: rcvNxt, segLen: 10, rcvWnd: 0, want: false},
+		{name: "data, open window, in window", segSeq: rcvNxt + 10, segLen: 10, rcvWnd: 100, want: true},
+		{name: "data, open window, overlapping left edge", segSeq: rcvNxt - 5, segLen: 10, rcvWnd: 100, want: true},
+		{name: "data, open window, ending before RCV.NXT", segSeq: rcvNxt - 10, segLen: 10, rcvWnd: 100, want: false},
+		{name: "data, open window, overlapping right edge", segSeq: rcvNxt + 95, segLen: 10, rcvWnd: 100, want: true},
+		{name: "data, open window, at right edge", segSeq: rcvNxt + 100, segLen: 10, rcvWnd: 100, want: false},
+		{name: "data, open window, wrapping sequence space", segSeq: seqnum.Value(0xfffffffb), segLen: 10, rcvWnd: 100, want: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.SegmentAcceptable(test.segSeq, test.segLen, rcvNxt, test.rcvWnd); got != test.want {
+				t.Errorf("got header.SegmentAcceptable(%d, %d, %d, %d) = %t, want = %t", test.segSeq, test.segLen, rcvNxt, test.rcvWnd, got, test.want)
+			}
+		})
+	}
+
+	t.Run("wrapped window", func(t *testing.T) {
+		rcvNxt := seqnum.Value(0xfffffff0)
+		if got := header.SegmentAcceptable(5, 10, rcvNxt, 100); !got {
+			t.Errorf("got header.SegmentAcceptable(5, 10, %d, 100) = false, want = true", rcvNxt)
+		}
+	})
+}
+
+func TestRSTAcceptable(t *testing.T) {
+	const (
+		rcvNxt = seqnum.Value(1000)
+		rcvWnd = seqnum.Size(100)
+	)
+
+	for _, tt := range []struct {
+		name          string
+		seq           uint32
+		flags         header.TCPFlags
+		wantAccept    bool
+		wantChallenge bool
+	}{
+		{name: "exact match", seq: 1000, flags: header.TCPFlagRst, wantAccept: true},
+		{name: "exact match with ACK", seq: 1000, flags: header.TCPFlagRst | header.TCPFlagAck, wantAccept: true},
+		{name: "in window", seq: 1050, flags: header.TCPFlagRst, wantChallenge: true},
+		{name: "last in window", seq: 1099, flags: header.TCPFlagRst, wantChallenge: true},
+		{name: "after window", seq: 1100, flags: header.TCPFlagRst},
+		{name: "before window", seq: 999, flags: header.TCPFlagRst},
+		{name: "not RST", seq: 1000, flags: header.TCPFlagAck},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			seg := header.TCP(make([]byte, header.TCPMinimumSize))
+			seg.Encode(&header.TCPFields{
+				SeqNum:     tt.seq,
+				DataOffset: header.TCPMinimumSize,
+				Flags:      tt.flags,
+			})
+			accept, challenge := header.RSTAcceptable(seg, rcvNxt, rcvWnd)
+			if accept != tt.wantAccept || challenge != tt.wantChallenge {
+				t.Errorf("got RSTAcceptable(_, %d, %d) = (%t, %t), want = (%t, %t)", rcvNxt, rcvWnd, accept, challenge, tt.wantAccept, tt.wantChallenge)
+			}
+		})
+	}
+}
+
+func TestNeedsChallengeACK(t *testing.T) {
+	const (
+		sndUna    = seqnum.Value(3000)
+		sndNxt    = seqnum.Value(5000)
+		maxSndWnd = seqnum.Size(1000)
+	)
+
+	for _, tt := range []struct {
+		name  string
+		seq   uint32
+		ack   uint32
+		flags header.TCPFlags
+		want  bool
+	}{
+		{name: "in-window SYN", seq: 1000, flags: header.TCPFlagSyn, want: true},
+		{name: "out-of-window SYN", seq: 1 << 31, flags: header.TCPFlagSyn, want: true},
+		{name: "SYN-ACK", seq: 1000, ack: 5000, flags: header.TCPFlagSyn | header.TCPFlagAck, want: true},
+		{name: "ACK of unsent data", seq: 1000, ack: 5001, flags: header.TCPFlagAck, want: true},
+		{name: "ACK of sent data", seq: 1000, ack: 5000, flags: header.TCPFlagAck, want: false},
+		{name: "duplicate ACK", seq: 1000, ack: 3000, flags: header.TCPFlagAck, want: false},
+		{name: "old ACK within MAX.SND.WND", seq: 1000, ack: 2000, flags: header.TCPFlagAck, want: false},
+		{name: "ACK below SND.UNA - MAX.SND.WND", seq: 1000, ack: 1999, flags: header.TCPFlagAck, want: true},
+		{name: "no ACK", seq: 1000, ack: 1, flags: header.TCPFlagPsh, want: false},
+		{name: "RST", seq: 1000, ack: 5001, flags: header.TCPFlagRst | header.TCPFlagAck, want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			seg := header.TCP(make([]byte, header.TCPMinimumSize))
+			seg.Encode(&header.TCPFields{
+				SeqNum:     tt.seq,
+				AckNum:     tt.ack,
+				DataOffset: header.TCPMinimumSize,
+				Flags:      tt.flags,
+			})
+			if got := header.NeedsChallengeACK(seg, sndUna, sndNxt, maxSndWnd); got != tt.want {
+				t.Errorf("got NeedsChallengeACK(_, %d, %d, %d) = %t, want = %t", sndUna, sndNxt, maxSndWnd, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSynOptionsTSEcr(t *testing.T) {
+	for _, tt := range []struct {
+		name             string
+		tsEcr            uint32
+		isAck            bool
+		wantTSEcr        uint32
+		wantNonZeroTSEcr bool
+	}{
+		{name: "SYN", tsEcr: 0, isAck: false, wantTSEcr: 0, wantNonZeroTSEcr: false},
+		{name: "SYN with bogus TSEcr", tsEcr: 12345, isAck: false, wantTSEcr: 0, wantNonZeroTSEcr: true},
+		{name: "SYN-ACK", tsEcr: 12345, isAck: true, wantTSEcr: 12345, wantNonZeroTSEcr: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := make([]byte, header.TCPOptionTSLength)
+			header.EncodeTSOption(1, tt.tsEcr, opts)
+			synOpts := header.ParseSynOptions(opts, tt.isAck)
+			if !synOpts.TS {
+				t.Fatal("got TS = false, want = true")
+			}
+			if synOpts.TSEcr != tt.wantTSEcr {
+				t.Errorf("got TSEcr = %d, want = %d", synOpts.TSEcr, tt.wantTSEcr)
+			}
+			if synOpts.NonZeroTSEcr != tt.wantNonZeroTSEcr {
+				t.Errorf("got NonZeroTSEcr = %t, want = %t", synOpts.NonZeroTSEcr, tt.wantNonZeroTSEcr)
+			}
+		})
+	}
+}
+
+func TestBuildTCPFin(t *testing.T) {
+	const (
+		srcPort = 1234
+		dstPort = 80
+		seq     = 1000
+		ack     = 2000
+		window  = 4096
+	)
+
+	for _, tt := range []struct {
+		name     string
+		src, dst tcpip.Address
+		netProto tcpip.NetworkProtocolNumber
+	}{
+		{name: "IPv4", src: testIPv4SrcAddr, dst: testIPv4DstAddr, netProto: header.IPv4ProtocolNumber},
+		{name: "IPv6", src: uniqueLocalAddr1, dst: uniqueLocalAddr2, netProto: header.IPv6ProtocolNumber},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			seg := header.TCP(header.BuildTCPFin(tt.src, tt.dst, tt.netProto, srcPort, dstPort, seq, ack, window))
+			if got, want := len(seg), header.TCPMinimumSize; got != want {
+				t.Fatalf("got len(seg) = %d, want = %d", got, want)
+			}
+			if got, want := seg.Flags(), header.TCPFlagFin|header.TCPFlagAck; got != want {
+				t.Errorf("got seg.Flags() = %s, want = %s", got, want)
+			}
+			if got := seg.SourcePort(); got != srcPort {
+				t.Errorf("got seg.SourcePort() = %d, want = %d", got, srcPort)
+			}
+			if got := seg.DestinationPort(); got != dstPort {
+				t.Errorf("got seg.DestinationPort() = %d, want = %d", got, dstPort)
+			}
+			if got := seg.SequenceNumber(); got != seq {
+				t.Errorf("got seg.SequenceNumber() = %d, want = %d", got, seq)
+			}
+			if got := seg.AckNumber(); got != ack {
+				t.Errorf("got seg.AckNumber() = %d, want = %d", got, ack)
+			}
+			if got := seg.WindowSize(); got != window {
+				t.Errorf("got seg.WindowSize() = %d, want = %d", got, window)
+			}
+			if seg.HasData() {
+				t.Error("got seg.HasData() = true, want = false")
+			}
+			if !seg.IsChecksumValid(tt.src, tt.dst, 0, 0) {
+				t.Errorf("got seg.IsChecksumValid(%s, %s, 0, 0) = false, want = true", tt.src, tt.dst)
+			}
+		})
+	}
+}
+
+func TestBuildTCPSYNv4(t *testing.T) {
+	const (
+		srcPort = 1234
+		dstPort = 80
+		isn     = 1000
+		mss     = 1460
+		ttl     = 64
+	)
+
+	pkt := header.BuildTCPSYNv4(testIPv4SrcAddr, testIPv4DstAddr, srcPort, dstPort, isn, mss, ttl)
+	ip := header.IPv4(pkt)
+	if !ip.IsValid(len(pkt)) {
+		t.Fatalf("got invalid IPv4 packet = %x", pkt)
+	}
+	if got := ip.CalculateChecksum(); got != 0xffff {
+		t.Errorf("got IPv4 header checksum = %#x, want = 0xffff", got)
+	}
+	if got, want := ip.TransportProtocol(), header.TCPProtocolNumber; got != want {
+		t.Errorf("got ip.TransportProtocol() = %d, want = %d", got, want)
+	}
+	if got := ip.TTL(); got != ttl {
+		t.Errorf("got ip.TTL() = %d, want = %d", got, ttl)
+	}
+
+	seg := header.TCP(ip.Payload())
+	if got, want := seg.Flags(), header.TCPFlagSyn; got != want {
+		t.Errorf("got seg.Flags() = %s, want = %s", got, want)
+	}
+	if got := seg.SourcePort(); got != srcPort {
+		t.Errorf("got seg.SourcePort() = %d, want = %d", got, srcPort)
+	}
+	if got := seg.DestinationPort(); got != dstPort {
+		t.Errorf("got seg.DestinationPort() = %d, want = %d", got, dstPort)
+	}
+	if got := seg.SequenceNumber(); got != isn {
+		t.Errorf("got seg.SequenceNumber() = %d, want = %d", got, isn)
+	}
+	if got := header.ParseSynOptions(seg.Options(), false /* isAck */).MSS; got != mss {
+		t.Errorf("got MSS option = %d, want = %d", got, mss)
+	}
+	if seg.HasData() {
+		t.Error("got seg.HasData() = true, want = false")
+	}
+	if !seg.IsChecksumValid(testIPv4SrcAddr, testIPv4DstAddr, 0, 0) {
+		t.Errorf("got seg.IsChecksumValid(%s, %s, 0, 0) = false, want = true", testIPv4SrcAddr, testIPv4DstAddr)
+	}
+}
+
+func TestBuildTCPSYNv6(t *testing.T) {
+	const (
+		srcPort  = 1234
+		dstPort  = 80
+		isn      = 1000
+		mss      = 1440
+		hopLimit = 64
+	)
+
+	pkt := header.BuildTCPSYNv6(uniqueLocalAddr1, uniqueLocalAddr2, srcPort, dstPort, isn, mss, hopLimit)
+	ip := header.IPv6(pkt)
+	if !ip.IsValid(len(pkt)) {
+		t.Fatalf("got invalid IPv6 packet = %x", pkt)
+	}
+	if got, want := ip.TransportProtocol(), header.TCPProtocolNumber; got != want {
+		t.Errorf("got ip.TransportProtocol() = %d, want = %d", got, want)
+	}
+	if got := ip.HopLimit(); got != hopLimit {
+		t.Errorf("got ip.HopLimit() = %d, want = %d", got, hopLimit)
+	}
+	if got, want := ip.SourceAddress(), uniqueLocalAddr1; got != want {
+		t.Errorf("got ip.SourceAddress() = %s, want = %s", got, want)
+	}
+	if got, want := ip.DestinationAddress(), uniqueLocalAddr2; got != want {
+		t.Errorf("got ip.DestinationAddress() = %s, want = %s", got, want)
+	}
+
+	seg := header.TCP(ip.Payload())
+	if got, want := seg.Flags(), header.TCPFlagSyn; got != want {
+		t.Errorf("got seg.Flags() = %s, want = %s", got, want)
+	}
+	if got := seg.SourcePort(); got != srcPort {
+		t.Errorf("got seg.SourcePort() = %d, want = %d", got, srcPort)
+	}
+	if got := seg.DestinationPort(); got != dstPort {
+		t.Errorf("got seg.DestinationPort() = %d, want = %d", got, dstPort)
+	}
+	if got := seg.SequenceNumber(); got != isn {
+		t.Errorf("got seg.SequenceNumber() = %d, want = %d", got, isn)
+	}
+	if got := header.ParseSynOptions(seg.Options(), false /* isAck */).MSS; got != mss {
+		t.Errorf("got MSS option = %d, want = %d", got, mss)
+	}
+	if seg.HasData() {
+		t.Error("got seg.HasData() = true, want = false")
+	}
+	if !seg.IsChecksumValid(uniqueLocalAddr1, uniqueLocalAddr2, 0, 0) {
+		t.Errorf("got seg.IsChecksumValid(%s, %s, 0, 0) = false, want = true", uniqueLocalAddr1, uniqueLocalAddr2)
+	}
+	// The checksum covers the IPv6 pseudo-header, so it must not validate
+	// against other addresses.
+	if seg.IsChecksumValid(uniqueLocalAddr1, globalAddr, 0, 0) {
+		t.Errorf("got seg.IsChecksumValid(%s, %s, 0, 0) = true, want = false", uniqueLocalAddr1, globalAddr)
+	}
+}
+
+func TestSegmentLength(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		flags      header.TCPFlags
+		payloadLen int
+		want       uint32
+	}{
+		{name: "SYN", flags: header.TCPFlagSyn, want: 1},
+		{name: "FIN", flags: header.TCPFlagFin | header.TCPFlagAck, want: 1},
+		{name: "SYN-FIN", flags: header.TCPFlagSyn | header.TCPFlagFin, want: 2},
+		{name: "data", flags: header.TCPFlagAck | header.TCPFlagPsh, payloadLen: 100, want: 100},
+		{name: "FIN with data", flags: header.TCPFlagFin | header.TCPFlagAck, payloadLen: 10, want: 11},
+		{name: "bare ACK", flags: header.TCPFlagAck, want: 0},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			seg := header.TCP(make([]byte, header.TCPMinimumSize))
+			seg.Encode(&header.TCPFields{
+				SeqNum:     1000,
+				DataOffset: header.TCPMinimumSize,
+				Flags:      tt.flags,
+			})
+			if got := header.SegmentLength(seg, tt.payloadLen); got != tt.want {
+				t.Errorf("got SegmentLength(_, %d) = %d, want = %d", tt.payloadLen, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNextAck(t *testing.T) {
+	for _, tt := range []struct {
+		name       string
+		seq        uint32
+		flags      header.TCPFlags
+		payloadLen int
+		want       uint32
+	}{
+		{name: "SYN", seq: 1000, flags: header.TCPFlagSyn, want: 1001},
+		{name: "SYN-ACK", seq: 1000, flags: header.TCPFlagSyn | header.TCPFlagAck, want: 1001},
+		{name: "FIN", seq: 1000, flags: header.TCPFlagFin | header.TCPFlagAck, want: 1001},
+		{name: "FIN with data", seq: 1000, flags: header.TCPFlagFin | header.TCPFlagAck, payloadLen: 10, want: 1011},
+		{name: "data", seq: 1000, flags: header.TCPFlagAck | header.TCPFlagPsh, payloadLen: 100, want: 1100},
+		{name: "bare ACK", seq: 1000, flags: header.TCPFlagAck, want: 1000},
+		{name: "wraparound", seq: 0xffffffff, flags: header.TCPFlagAck, payloadLen: 2, want: 1},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			seg := header.TCP(make([]byte, header.TCPMinimumSize))
+			seg.Encode(&header.TCPFields{
+				SeqNum:     tt.seq,
+				DataOffset: header.TCPMinimumSize,
+				Flags:      tt.flags,
+			})
+			if got := header.NextAck(seg, tt.payloadLen); got != tt.want {
+				t.Errorf("got NextAck(_, %d) = %d, want = %d", tt.payloadLen, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTCPZeroChecksum(t *testing.T) {
+	seg := header.TCP(header.BuildTCPFin(testIPv4SrcAddr, testIPv4DstAddr, header.IPv4ProtocolNumber, 1234, 80, 1000, 2000, 4096))
+	if !seg.HasChecksum() {
+		t.Fatal("got seg.HasChecksum() = false, want = true")
+	}
+	if !seg.IsChecksumValid(testIPv4SrcAddr, testIPv4DstAddr, 0, 0) {
+		t.Fatal("got seg.IsChecksumValid(_, _, 0, 0) = false, want = true")
+	}
+
+	seg.SetChecksum(0)
+	if seg.HasChecksum() {
+		t.Error("got seg.HasChecksum() = true, want = false")
+	}
+	if seg.IsChecksumValid(testIPv4SrcAddr, testIPv4DstAddr, 0, 0) {
+		t.Error("got seg.IsChecksumValid(_, _, 0, 0) = true, want = false")
+	}
+}
+
+func TestAcksNewData(t *testing.T) {
+	for _, tt := range []struct {
+		name   string
+		sndUna seqnum.Value
+		sndNxt seqnum.Value
+		ack    uint32
+		flags  header.TCPFlags
+		want   bool
+	}{
+		{name: "duplicate ACK", sndUna: 1000, sndNxt: 2000, ack: 1000, flags: header.TCPFlagAck, want: false},
+		{name: "old ACK", sndUna: 1000, sndNxt: 2000, ack: 900, flags: header.TCPFlagAck, want: false},
+		{name: "partial ACK", sndUna: 1000, sndNxt: 2000, ack: 1500, flags: header.TCPFlagAck, want: true},
+		{name: "cumulative ACK", sndUna: 1000, sndNxt: 2000, ack: 2000, flags: header.TCPFlagAck, want: true},
+		{name: "ACK beyond sndNxt", sndUna: 1000, sndNxt: 2000, ack: 2001, flags: header.TCPFlagAck, want: false},
+		{name: "ACK flag not set", sndUna: 1000, sndNxt: 2000, ack: 1500, flags: header.TCPFlagPsh, want: false},
+		{name: "wraparound", sndUna: 0xffffff00, sndNxt: 0x100, ack: 0x10, flags: header.TCPFlagAck, want: true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			seg := header.TCP(make([]byte, header.TCPMinimumSize))
+			seg.Encode(&header.TCPFields{
+				AckNum:     tt.ack,
+				DataOffset: header.TCPMinimumSize,
+				Flags:      tt.flags,
+			})
+			if got := header.AcksNewData(seg, tt.sndUna, tt.sndNxt); got != tt.want {
+				t.Errorf("got AcksNewData(_, %d, %d) = %t, want = %t", tt.sndUna, tt.sndNxt, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsDuplicateAck(t *testing.T) {
+	const (
+		lastAck    = seqnum.Value(1000)
+		lastWindow = 4096
+	)
+
+	for _, tt := range []struct {
+		name       string
+		ack        uint32
+		window     uint16
+		flags      header.TCPFlags
+		payloadLen int
+		sack       []header.SACKBlock
+		want       bool
+	}{
+		{name: "duplicate ACK", ack: 1000, window: lastWindow, flags: header.TCPFlagAck, want: true},
+		{name: "duplicate ACK with SACK", ack: 1000, window: lastWindow, flags: header.TCPFlagAck, sack: []header.SACKBlock{{Start: 1500, End: 2000}}, want: true},
+		{name: "window update", ack: 1000, window: lastWindow * 2, flags: header.TCPFlagAck, want: false},
+		{name: "new ACK", ack: 1500, window: lastWindow, flags: header.TCPFlagAck, want: false},
+		{name: "with data", ack: 1000, window: lastWindow, flags: header.TCPFlagAck, payloadLen: 100, want: false},
+		{name: "FIN", ack: 1000, window: lastWindow, flags: header.TCPFlagAck | header.TCPFlagFin, want: false},
+		{name: "SYN", ack: 1000, window: lastWindow, flags: header.TCPFlagAck | header.TCPFlagSyn, want: false},
+		{name: "ACK flag not set", ack: 1000, window: lastWindow, flags: header.TCPFlagRst, want: false},
+		{name: "D-SACK", ack: 1000, window: lastWindow, flags: header.TCPFlagAck, sack: []header.SACKBlock{{Start: 500, End: 1000}}, want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var opts [header.TCPOptionsMaximumSize]byte
+			optsLen := header.EncodeSACKBlocks(tt.sack, opts[:])
+			optsLen += header.AddTCPOptionPadding(opts[:], optsLen)
+			seg := header.TCP(make([]byte, header.TCPMinimumSize+optsLen))
+			seg.Encode(&header.TCPFields{
+				AckNum:     tt.ack,
+				DataOffset: uint8(len(seg)),
+				Flags:      tt.flags,
+				WindowSize: tt.window,
+			})
+			copy(seg.Options(), opts[:optsLen])
+			if got := header.IsDuplicateAck(seg, tt.payloadLen, lastAck, lastWindow); got != tt.want {
+				t.Errorf("got IsDuplicateAck(_, %d, %d, %d) = %t, want = %t", tt.payloadLen, lastAck, lastWindow, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTCPAck(t *testing.T) {
+	payload := []byte{1, 2, 3, 4, 5}
+
+	for _, tt := range []struct {
+		name    string
+		flags   header.TCPFlags
+		payload []byte
+		wantAck uint32
+	}{
+		{name: "SYN", flags: header.TCPFlagSyn, wantAck: 1001},
+		{name: "data", flags: header.TCPFlagAck | header.TCPFlagPsh, payload: payload, wantAck: 1005},
+		{name: "data and FIN", flags: header.TCPFlagAck | header.TCPFlagFin, payload: payload, wantAck: 1006},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			seg := header.TCP(make([]byte, header.TCPMinimumSize+len(tt.payload)))
+			seg.Encode(&header.TCPFields{
+				SrcPort:    1234,
+				DstPort:    80,
+				SeqNum:     1000,
+				DataOffset: header.TCPMinimumSize,
+				Flags:      tt.flags,
+			})
+			copy(seg.Payload(), tt.payload)
+
+			const (
+				seq    = 5000
+				window = 4096
+			)
+			ack := header.TCP(header.BuildTCPAck(seg, testIPv4DstAddr, testIPv4SrcAddr, header.IPv4ProtocolNumber, seq, window))
+			if got := ack.AckNumber(); got != tt.wantAck {
+				t.Errorf("got ack.AckNumber() = %d, want = %d", got, tt.wantAck)
+			}
+			if got := ack.Flags(); got != header.TCPFlagAck {
+				t.Errorf("got ack.Flags() = %s, want = %s", got, header.TCPFlagAck)
+			}
+			if ack.HasData() {
+				t.Errorf("got ack.HasData() = true, want = false")
+			}
+			if got := ack.SequenceNumber(); got != seq {
+				t.Errorf("got ack.SequenceNumber() = %d, want = %d", got, seq)
+			}
+			if got := ack.WindowSize(); got != window {
+				t.Errorf("got ack.WindowSize() = %d, want = %d", got, window)
+			}
+			if ack.SourcePort() != seg.DestinationPort() || ack.DestinationPort() != seg.SourcePort() {
+				t.Errorf("got ports = (%d, %d), want = (%d, %d)", ack.SourcePort(), ack.DestinationPort(), seg.DestinationPort(), seg.SourcePort())
+			}
+			if !ack.IsChecksumValid(testIPv4DstAddr, testIPv4SrcAddr, 0, 0) {
+				t.Error("got ack.IsChecksumValid(_, _, 0, 0) = false, want = true")
+			}
+		})
+	}
+}
+
+func TestIsDSACK(t *testing.T) {
+	const cumAck = seqnum.Value(2000)
+
+	for _, tt := range []struct {
+		name  string
+		block header.SACKBlock
+		want  bool
+	}{
+		{name: "D-SACK", block: header.SACKBlock{Start: 1000, End: 1500}, want: true},
+		{name: "D-SACK ending at cumulative ACK", block: header.SACKBlock{Start: 1500, End: 2000}, want: true},
+		{name: "SACK", block: header.SACKBlock{Start: 3000, End: 3500}, want: false},
+		{name: "straddling cumulative ACK", block: header.SACKBlock{Start: 1500, End: 2500}, want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := header.IsDSACK(tt.block, cumAck); got != tt.want {
+				t.Errorf("got IsDSACK(%+v, %d) = %t, want = %t", tt.block, cumAck, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTCPIsTimestampAligned(t *testing.T) {
+	ts := []byte{header.TCPOptionTS, header.TCPOptionTSLength, 0, 0, 0, 1, 0, 0, 0, 2}
+	nop := byte(header.TCPOptionNOP)
+
+	for _, tt := range []struct {
+		name string
+		opts []byte
+		want bool
+	}{
+		{name: "no options", want: false},
+		{name: "NOP NOP TS", opts: append([]byte{nop, nop}, ts...), want: true},
+		{name: "MSS NOP NOP TS", opts: append([]byte{header.TCPOptionMSS, header.TCPOptionMSSLength, 5, 0xb4, nop, nop}, ts...), want: true},
+		{name: "TS NOP NOP", opts: append(append([]byte{}, ts...), nop, nop), want: false},
+		{name: "NOP TS NOP", opts: append(append([]byte{nop}, ts...), nop), want: false},
+		{name: "SACK permitted TS", opts: append([]byte{header.TCPOptionSACKPermitted, header.TCPOptionSackPermittedLength}, ts...), want: true},
+		{name: "no TS", opts: []byte{header.TCPOptionMSS, header.TCPOptionMSSLength, 5, 0xb4}, want: false},
+		{name: "truncated TS", opts: []byte{nop, nop, header.TCPOptionTS, header.TCPOptionTSLength}, want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			// Pad the options to a multiple of 4 bytes with EOL.
+			opts := make([]byte, (len(tt.opts)+3)&^3)
+			copy(opts, tt.opts)
+			seg := header.TCP(make([]byte, header.TCPMinimumSize+len(opts)))
+			seg.Encode(&header.TCPFields{
+				DataOffset: uint8(len(seg)),
+			})
+			copy(seg.Options(), opts)
+			if got := seg.IsTimestampAligned(); got != tt.want {
+				t.Errorf("got seg.IsTimestampAligned() = %t, want = %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTCPOptionsConsistent(t *testing.T) {
+	nop := byte(header.TCPOptionNOP)
+	eol := byte(header.TCPOptionEOL)
+	mss := []byte{header.TCPOptionMSS, header.TCPOptionMSSLength, 5, 0xb4}
+
+	for _, tt := range []struct {
+		name string
+		opts []byte
+		want bool
+	}{
+		{name: "no options", want: true},
+		{name: "MSS", opts: mss, want: true},
+		{name: "NOP NOP TS", opts: []byte{nop, nop, header.TCPOptionTS, header.TCPOptionTSLength, 0, 0, 0, 1, 0, 0, 0, 2}, want: true},
+		{name: "EOL padding", opts: []byte{header.TCPOptionWS, header.TCPOptionWSLength, 7, eol}, want: true},
+		{name: "EOL and zero padding", opts: []byte{header.TCPOptionWS, header.TCPOptionWSLength, 7, eol, 0, 0, 0, 0}, want: true},
+		{name: "NOP padding", opts: []byte{header.TCPOptionWS, header.TCPOptionWSLength, 7, nop}, want: true},
+		{name: "garbage after EOL", opts: []byte{eol, 0, 0xaa, 0}, want: false},
+		{name: "garbage after NOP padding", opts: []byte{header.TCPOptionWS, header.TCPOptionWSLength, 7, nop, nop, 0xaa, 0, 0}, want: false},
+		{name: "trailing garbage", opts: append(append([]byte{}, mss...), 0xaa, 0xbb, 0xcc, 0xdd), want: false},
+		{name: "overrun", opts: []byte{nop, nop, 0xfe, 6}, want: false},
+		{name: "zero length", opts: []byte{0xfe, 0, nop, nop}, want: false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			seg := header.TCP(make([]byte, header.TCPMinimumSize+len(tt.opts)))
+			seg.Encode(&header.TCPFields{
+				DataOffset: uint8(len(seg)),
+			})
+			copy(seg.Options(), tt.opts)
+			if got := seg.OptionsConsistent(); got != tt.want {
+				t.Errorf("got seg.OptionsConsistent() = %t, want = %t", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("data offset beyond segment", func(t *testing.T) {
+		seg := header.TCP(make([]byte, header.TCPMinimumSize))
+		seg.Encode(&header.TCPFields{
+			DataOffset: header.TCPMinimumSize + 4,
+		})
+		if seg.OptionsConsistent() {
+			t.Error("got seg.OptionsConsistent() = true, want = false")
+		}
+	})
+}
+
+func TestWindowScaler(t *testing.T) {
+	tests := []struct {
+		name       string
+		scale      uint8
+		available  uint32
+		wantWindow uint16
+		wantHidden uint32
+	}{
+		{
+			name:       "no scale",
+			scale:      0,
+			available:  1000,
+			wantWindow: 1000,
+		},
+		{
+			name:       "divides evenly",
+			scale:      7,
+			available:  128 * 100,
+			wantWindow: 100,
+		},
+		{
+			name:       "does not divide evenly",
+			scale:      7,
+			available:  128*100 + 127,
+			wantWindow: 100,
+			wantHidden: 127,
+		},
+		{
+			name:       "smaller than scale factor",
+			scale:      10,
+			available:  1000,
+			wantWindow: 0,
+			wantHidden: 1000,
+		},
+		{
+			name:       "exceeds maximum",
+			scale:      0,
+			available:  math.MaxUint16 + 10,
+			wantWindow: math.MaxUint16,
+			wantHidden: 10,
+		},
+		{
+			name:       "scale above maximum",
+			scale:      header.MaxWndScale + 1,
+			available:  1<<header.MaxWndScale + 1,
+			wantWindow: 1,
+			wantHidden: 1,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := header.WindowScaler{Scale: test.scale}
+			if got := w.Advertise(test.available); got != test.wantWindow {
+				t.Errorf("got w.Advertise(%d) = %d, want = %d", test.available, got, test.wantWindow)
+			}
+			if got := w.Hidden(); got != test.wantHidden {
+				t.Errorf("got w.Hidden() = %d, want = %d", got, test.wantHidden)
+			}
+		})
+	}
+}
+
+func TestTCPFlagCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		flags uint8
+		want  int
+	}{
+		{name: "null", flags: 0, want: 0},
+		{name: "SYN", flags: uint8(header.TCPFlagSyn), want: 1},
+		{name: "SYN-ACK", flags: uint8(header.TCPFlagSyn | header.TCPFlagAck), want: 2},
+		{name: "XMAS", flags: uint8(header.TCPFlagFin | header.TCPFlagPsh | header.TCPFlagUrg), want: 3},
+		{name: "all", flags: 0xff, want: 8},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			seg := header.TCP(make([]byte, header.TCPMinimumSize))
+			seg[header.TCPFlagsOffset] = test.flags
+			if got := seg.FlagCount(); got != test.want {
+				t.Errorf("got seg.FlagCount() = %d, want = %d", got, test.want)
+			}
+		})
+	}
+}
+
+func TestMaxTCPPayload(t *testing.T) {
+	// The Timestamps option padded to a 4-byte boundary.
+	const tsOptionsLen = 12
+
+	tests := []struct {
+		name           string
+		mtu            int
+		netProto       tcpip.NetworkProtocolNumber
+		tcpOptionsLen  int
+		wantMaxPayload int
+	}{
+		{
+			name:           "IPv4 without options",
+			mtu:            1500,
+			netProto:       header.IPv4ProtocolNumber,
+			wantMaxPayload: 1460,
+		},
+		{
+			name:           "IPv4 with timestamps",
+			mtu:            1500,
+			netProto:       header.IPv4ProtocolNumber,
+			tcpOptionsLen:  tsOptionsLen,
+			wantMaxPayload: 1448,
+		},
+		{
+			name:           "IPv6 without options",
+			mtu:            1500,
+			netProto:       header.IPv6ProtocolNumber,
+			wantMaxPayload: 1440,
+		},
+		{
+			name:           "IPv6 with timestamps",
+			mtu:            1500,
+			netProto:       header.IPv6ProtocolNumber,
+			tcpOptionsLen:  tsOptionsLen,
+			wantMaxPayload: 1428,
+		},
+		{
+			name:           "IPv6 with timestamps at minimum MTU",
+			mtu:            header.IPv6MinimumMTU,
+			netProto:       header.IPv6ProtocolNumber,
+			tcpOptionsLen:  tsOptionsLen,
+			wantMaxPayload: 1208,
+		},
+		{
+			name:           "headers exactly fill MTU",
+			mtu:            header.IPv4MinimumSize + header.TCPMinimumSize + tsOptionsLen,
+			netProto:       header.IPv4ProtocolNumber,
+			tcpOptionsLen:  tsOptionsLen,
+			wantMaxPayload: 0,
+		},
+		{
+			name:           "headers exceed MTU",
+			mtu:            50,
+			netProto:       header.IPv6ProtocolNumber,
+			tcpOptionsLen:  tsOptionsLen,
+			wantMaxPayload: 0,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := header.MaxTCPPayload(test.mtu, test.netProto, test.tcpOptionsLen); got != test.wantMaxPayload {
+				t.Errorf("got MaxTCPPayload(%d, %d, %d) = %d, want = %d", test.mtu, test.netProto, test.tcpOptionsLen, got, test.wantMaxPayload)
+			}
+		})
+	}
+}
@@ -16,9 +16,14 @@ package header
 
 import (
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
 	"math"
 
 	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/buffer"
 )
 
 const (
@@ -64,6 +69,27 @@ const (
 	UDPProtocolNumber tcpip.TransportProtocolNumber = 17
 )
 
+// tunnelSourcePortMin and tunnelSourcePortRange describe the dynamic port range
+// (49152-65535) used for the source port of UDP-encapsulated tunnel packets,
+// as per RFC 7510 section 3.
+const (
+	tunnelSourcePortMin   = 49152
+	tunnelSourcePortRange = math.MaxUint16 - tunnelSourcePortMin + 1
+)
+
+// ErrUDPLengthMismatch indicates that the length field of a UDP header does
+// not match the length of the IP payload carrying it.
+var ErrUDPLengthMismatch = errors.New("UDP length does not match the IP payload length")
+
+// ErrUDPZeroChecksumV6 indicates that a UDP datagram carried over IPv6 has a
+// zero checksum field, which is not allowed as per RFC 8200 section 8.1
+// unless explicitly permitted for tunnels as per RFC 6935.
+var ErrUDPZeroChecksumV6 = errors.New("zero UDP checksum over IPv6")
+
+// ErrUDPChecksumMismatch indicates that the checksum field of a UDP header
+// does not match the checksum computed over the datagram.
+var ErrUDPChecksumMismatch = errors.New("UDP checksum mismatch")
+
 // SourcePort returns the "source port" field of the udp header.
 func (b UDP) SourcePort() uint16 {
 	return binary.BigEndian.Uint16(b[udpSrcPort:])
@@ -74,6 +100,15 @@ func (b UDP) DestinationPort() uint16 {
 	return binary.BigEndian.Uint16(b[udpDstPort:])
 }
 
+// ExpectsReply returns false iff the "source port" field of the udp header is
+// zero.
+//
+// As per RFC 768, the source port is optional and a value of zero indicates
+// that the sender does not expect a reply. Such datagrams are otherwise valid.
+func (b UDP) ExpectsReply() bool {
+	return b.SourcePort() != 0
+}
+
 // Length returns the "length" field of the udp header.
 func (b UDP) Length() uint16 {
 	return binary.BigEndian.Uint16(b[udpLength:])
@@ -116,6 +151,58 @@ func (b UDP) CalculateChecksum(partialChecksum uint16) uint16 {
 	return Checksum(b[:UDPMinimumSize], partialChecksum)
 }
 
+// IsChecksumValid returns true iff the UDP header's checksum is valid.
+//
+// payloadChecksum is the checksum of the datagram's payload.
+func (b UDP) IsChecksumValid(src, dst tcpip.Address, payloadChecksum uint16) bool {
+	xsum := PseudoHeaderChecksum(UDPProtocolNumber, src, dst, b.Length())
+	xsum = ChecksumCombine(xsum, payloadChecksum)
+	return b.CalculateChecksum(xsum) == 0xffff
+}
+
+// ValidateChecksum checks the checksum field of the UDP header b, carried by
+// a netProto packet sent from src to dst.
+//
+// payloadChecksum is the checksum of the datagram's payload.
+//
+// A zero checksum field means that the sender did not compute a checksum. It
+// is accepted over IPv4 but, over IPv6, only if allowZeroV6 is set;
+// otherwise ErrUDPZeroChecksumV6 is returned so that the caller can account
+// for such drops separately. Any other invalid checksum is reported as
+// ErrUDPChecksumMismatch.
+func (b UDP) ValidateChecksum(src, dst tcpip.Address, netProto tcpip.NetworkProtocolNumber, payloadChecksum uint16, allowZeroV6 bool) error {
+	checkPseudoHeaderAddresses(src, dst, netProto)
+	if b.Checksum() == 0 {
+		if netProto == IPv6ProtocolNumber && !allowZeroV6 {
+			return fmt.Errorf("got UDP checksum = 0 from %s to %s: %w", src, dst, ErrUDPZeroChecksumV6)
+		}
+		return nil
+	}
+	if !b.IsChecksumValid(src, dst, payloadChecksum) {
+		return fmt.Errorf("got UDP checksum = %#04x from %s to %s: %w", b.Checksum(), src, dst, ErrUDPChecksumMismatch)
+	}
+	return nil
+}
+
+// FillUDPChecksum calculates the checksum of the UDP datagram made of the
+// header b followed by payload and writes it into b's checksum field.
+//
+// The length field of b must already be set.
+//
+// A calculated checksum of zero is written as all ones since, as per RFC 768,
+// a zero checksum field means that the sender did not compute a checksum.
+func FillUDPChecksum(b UDP, src, dst tcpip.Address, netProto tcpip.NetworkProtocolNumber, payload buffer.VectorisedView) {
+	checkPseudoHeaderAddresses(src, dst, netProto)
+	b.SetChecksum(0)
+	xsum := PseudoHeaderChecksum(UDPProtocolNumber, src, dst, b.Length())
+	xsum = ChecksumVV(payload, xsum)
+	xsum = ChecksumToField(b.CalculateChecksum(xsum))
+	if xsum == 0 {
+		xsum = 0xffff
+	}
+	b.SetChecksum(xsum)
+}
+
 // Encode encodes all the fields of the udp header.
 func (b UDP) Encode(u *UDPFields) {
 	binary.BigEndian.PutUint16(b[udpSrcPort:], u.SrcPort)
@@ -123,3 +210,139 @@ func (b UDP) Encode(u *UDPFields) {
 	binary.BigEndian.PutUint16(b[udpLength:], u.Length)
 	binary.BigEndian.PutUint16(b[udpChecksum:], u.Checksum)
 }
+
+// ValidateUDPLength checks that the "length" field of the udp header equals
+// ipPayloadLen, the length of the payload of the unfragmented IP packet
+// carrying it.
+//
+// A longer length indicates that the datagram was truncated and a shorter one
+// that the datagram was crafted to carry trailing data.
+func ValidateUDPLength(udp UDP, ipPayloadLen int) error {
+	if len(udp) < UDPMinimumSize || ipPayloadLen < UDPMinimumSize {
+		return fmt.Errorf("got %d bytes of UDP header with an IP payload of %d bytes, want at least %d: %w", len(udp), ipPayloadLen, UDPMinimumSize, io.ErrUnexpectedEOF)
+	}
+	if length := int(udp.Length()); length != ipPayloadLen {
+		return fmt.Errorf("got UDP length = %d, want = %d: %w", length, ipPayloadLen, ErrUDPLengthMismatch)
+	}
+	return nil
+}
+
+// BuildUDPv4Packet returns an IPv4 packet sent from src to dst with a time to
+// live of ttl, carrying a UDP datagram sent from srcPort to dstPort with the
+// given payload.
+//
+// Both the IPv4 header checksum and the UDP checksum are computed.
+func BuildUDPv4Packet(src, dst tcpip.Address, srcPort, dstPort uint16, payload []byte, ttl uint8) []byte {
+	totalLen := IPv4MinimumSize + UDPMinimumSize + len(payload)
+	if totalLen > math.MaxUint16 {
+		panic(fmt.Sprintf("got payload of %d bytes, want at most %d bytes", len(payload), math.MaxUint16-IPv4MinimumSize-UDPMinimumSize))
+	}
+
+	b := make([]byte, totalLen)
+	ip := IPv4(b)
+	ip.Encode(&IPv4Fields{
+		TotalLength: uint16(totalLen),
+		TTL:         ttl,
+		Protocol:    uint8(UDPProtocolNumber),
+		SrcAddr:     src,
+		DstAddr:     dst,
+	})
+	ip.SetChecksum(ChecksumToField(ip.CalculateChecksum()))
+	encodeUDP(ip.Payload(), src, dst, IPv4ProtocolNumber, srcPort, dstPort, payload)
+	return b
+}
+
+// BuildUDPv6Packet returns an IPv6 packet sent from src to dst with a hop
+// limit of hopLimit, carrying a UDP datagram sent from srcPort to dstPort with
+// the given payload.
+//
+// The UDP checksum is mandatory over IPv6, as per RFC 8200 section 8.1, so it
+// is always computed and never written as zero.
+func BuildUDPv6Packet(src, dst tcpip.Address, srcPort, dstPort uint16, payload []byte, hopLimit uint8) []byte {
+	payloadLen := UDPMinimumSize + len(payload)
+	if payloadLen > IPv6MaximumPayloadSize {
+		panic(fmt.Sprintf("got payload of %d bytes, want at most %d bytes", len(payload), IPv6MaximumPayloadSize-UDPMinimumSize))
+	}
+
+	b := make([]byte, IPv6MinimumSize+payloadLen)
+	ip := IPv6(b)
+	ip.Encode(&IPv6Fields{
+		PayloadLength:     uint16(payloadLen),
+		TransportProtocol: UDPProtocolNumber,
+		HopLimit:          hopLimit,
+		SrcAddr:           src,
+		DstAddr:           dst,
+	})
+	encodeUDP(ip.Payload(), src, dst, IPv6ProtocolNumber, srcPort, dstPort, payload)
+	return b
+}
+
+// encodeUDP encodes a UDP datagram sent from srcPort to dstPort with the given
+// payload into b, which must be exactly large enough to hold it, and computes
+// its checksum.
+func encodeUDP(b UDP, src, dst tcpip.Address, netProto tcpip.NetworkProtocolNumber, srcPort, dstPort uint16, payload []byte) {
+	b.Encode(&UDPFields{
+		SrcPort: srcPort,
+		DstPort: dstPort,
+		Length:  uint16(len(b)),
+	})
+	copy(b.Payload(), payload)
+	FillUDPChecksum(b[:UDPMinimumSize], src, dst, netProto, buffer.View(payload).ToVectorisedView())
+}
+
+// TunnelSourcePort returns the UDP source port to use when encapsulating
+// innerPacket, a netProto packet, in a UDP tunnel.
+//
+// As per RFC 6935 section 5 and RFC 7510 section 3, the port is derived from a
+// hash of the inner flow so that routers balancing traffic over equal-cost
+// paths keep each inner flow on a single path. The hash covers the inner
+// addresses, transport protocol and, for TCP, UDP and UDP-Lite, the ports.
+// Fragments are hashed on their addresses only so that all fragments of a
+// datagram map to the same port. The result is in the dynamic port range.
+func TunnelSourcePort(innerPacket []byte, netProto tcpip.NetworkProtocolNumber) uint16 {
+	// The flow key holds the addresses, the transport protocol number and the
+	// ports.
+	key := make([]byte, 0, 2*IPv6AddressSize+5)
+	var fragment bool
+	switch netProto {
+	case IPv4ProtocolNumber:
+		ip := IPv4(innerPacket)
+		if !ip.IsValid(len(innerPacket)) {
+			return tunnelSourcePortMin
+		}
+		key = append(key, ip.SourceAddress()...)
+		key = append(key, ip.DestinationAddress()...)
+		fragment = ip.More() || ip.FragmentOffset() != 0
+	case IPv6ProtocolNumber:
+		ip := IPv6(innerPacket)
+		if !ip.IsValid(len(innerPacket)) {
+			return tunnelSourcePortMin
+		}
+		key = append(key, ip.SourceAddress()...)
+		key = append(key, ip.DestinationAddress()...)
+		fragment = ipv6HasFragmentExtHdr(ip)
+	default:
+		panic(fmt.Sprintf("unsupported network protocol number = %d", netProto))
+	}
+
+	// Only the first fragment holds the transport header.
+	if proto, transport, ok := TransportHeader(innerPacket, netProto); ok && !fragment {
+		key = append(key, proto)
+		switch tcpip.TransportProtocolNumber(proto) {
+		case TCPProtocolNumber, UDPProtocolNumber, UDPLiteProtocolNumber:
+			// The ports are the first 4 bytes of the transport header.
+			if len(transport) >= 4 {
+				key = append(key, transport[:4]...)
+			}
+		}
+	}
+
+	// The FNV-1a was chosen because it is a fast hashing algorithm, and
+	// cryptographic properties are not needed here.
+	h := fnv.New32a()
+	if _, err := h.Write(key); err != nil {
+		panic(fmt.Sprintf("Hash.Write: %s, but Hash' implementation of Write is not expected to ever return an error", err))
+	}
+	sum := h.Sum32()
+	return tunnelSourcePortMin + uint16((sum^sum>>16)%tunnelSourcePortRange)
+}
@@ -0,0 +1,340 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestUDPExpectsReply(t *testing.T) {
+	tests := []struct {
+		name    string
+		srcPort uint16
+		want    bool
+	}{
+		{name: "source port 0", srcPort: 0, want: false},
+		{name: "source port 1", srcPort: 1, want: true},
+		{name: "ephemeral source port", srcPort: 49152, want: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			udp := header.UDP(make([]byte, header.UDPMinimumSize))
+			udp.Encode(&header.UDPFields{
+				SrcPort: test.srcPort,
+				DstPort: 514,
+				Length:  header.UDPMinimumSize,
+			})
+			if got := udp.ExpectsReply(); got != test.want {
+				t.Errorf("got ExpectsReply() = %t, want = %t", got, test.want)
+			}
+
+			// A source port of 0 must not make the datagram unparsable.
+			proto, transport, ok := header.TransportHeader(makeIPv4Packet(header.IPv4Fields{Protocol: uint8(header.UDPProtocolNumber)}, udp), header.IPv4ProtocolNumber)
+			if !ok || proto != uint8(header.UDPProtocolNumber) {
+				t.Fatalf("got header.TransportHeader(_, _) = (%d, _, %t), want = (%d, _, true)", proto, ok, header.UDPProtocolNumber)
+			}
+			if got := header.UDP(transport).SourcePort(); got != test.srcPort {
+				t.Errorf("got SourcePort() = %d, want = %d", got, test.srcPort)
+			}
+		})
+	}
+}
+
+func TestValidateUDPLength(t *testing.T) {
+	tests := []struct {
+		name         string
+		udpLength    uint16
+		ipPayloadLen int
+		wantErr      error
+	}{
+		{name: "matching", udpLength: 12, ipPayloadLen: 12},
+		{name: "header only", udpLength: header.UDPMinimumSize, ipPayloadLen: header.UDPMinimumSize},
+		{name: "over-declared", udpLength: 20, ipPayloadLen: 12, wantErr: header.ErrUDPLengthMismatch},
+		{name: "under-declared", udpLength: 10, ipPayloadLen: 12, wantErr: header.ErrUDPLengthMismatch},
+		{name: "below minimum", udpLength: 4, ipPayloadLen: 4, wantErr: io.ErrUnexpectedEOF},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			udp := header.UDP(make([]byte, header.UDPMinimumSize))
+			udp.SetLength(test.udpLength)
+			if err := header.ValidateUDPLength(udp, test.ipPayloadLen); !errors.Is(err, test.wantErr) {
+				t.Errorf("got header.ValidateUDPLength(_, %d) = %v, want = %v", test.ipPayloadLen, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestUDPValidateChecksum(t *testing.T) {
+	v6Src := tcpip.Address("\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+	v6Dst := tcpip.Address("\xfe\x80\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02")
+	payload := []byte{1, 2, 3, 4}
+
+	tests := []struct {
+		name        string
+		netProto    tcpip.NetworkProtocolNumber
+		checksum    func(header.UDP) uint16
+		allowZeroV6 bool
+		wantErr     error
+	}{
+		{name: "IPv4 valid", netProto: header.IPv4ProtocolNumber},
+		{name: "IPv4 zero", netProto: header.IPv4ProtocolNumber, checksum: func(header.UDP) uint16 { return 0 }},
+		{name: "IPv4 corrupt", netProto: header.IPv4ProtocolNumber, checksum: func(u header.UDP) uint16 { return ^u.Checksum() }, wantErr: header.ErrUDPChecksumMismatch},
+		{name: "IPv6 valid", netProto: header.IPv6ProtocolNumber},
+		{name: "IPv6 zero", netProto: header.IPv6ProtocolNumber, checksum: func(header.UDP) uint16 { return 0 }, wantErr: header.ErrUDPZeroChecksumV6},
+		{name: "IPv6 zero allowed", netProto: header.IPv6ProtocolNumber, checksum: func(header.UDP) uint16 { return 0 }, allowZeroV6: true},
+		{name: "IPv6 corrupt", netProto: header.IPv6ProtocolNumber, checksum: func(u header.UDP) uint16 { return ^u.Checksum() }, wantErr: header.ErrUDPChecksumMismatch},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var src, dst tcpip.Address
+			var udp header.UDP
+			switch test.netProto {
+			case header.IPv4ProtocolNumber:
+				src, dst = testIPv4SrcAddr, testIPv4DstAddr
+				udp = header.UDP(header.IPv4(header.BuildUDPv4Packet(src, dst, 1234, 53, payload, 64)).Payload())
+			case header.IPv6ProtocolNumber:
+				src, dst = v6Src, v6Dst
+				udp = header.UDP(header.IPv6(header.BuildUDPv6Packet(src, dst, 1234, 53, payload, 64)).Payload())
+			}
+			if test.checksum != nil {
+				udp.SetChecksum(test.checksum(udp))
+			}
+
+			if err := udp.ValidateChecksum(src, dst, test.netProto, header.Checksum(udp.Payload(), 0), test.allowZeroV6); !errors.Is(err, test.wantErr) {
+				t.Errorf("got udp.ValidateChecksum(_, _, %d, _, %t) = %v, want = %v", test.netProto, test.allowZeroV6, err, test.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildUDPv4Packet(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{name: "empty", payload: nil},
+		{name: "even", payload: []byte{1, 2, 3, 4}},
+		{name: "odd", payload: []byte{1, 2, 3, 4, 5}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pkt := header.BuildUDPv4Packet(testIPv4SrcAddr, testIPv4DstAddr, 1234, 53, test.payload, 32)
+
+			ip := header.IPv4(pkt)
+			if !ip.IsValid(len(pkt)) {
+				t.Fatalf("got IsValid(%d) = false, want = true", len(pkt))
+			}
+			if got := ip.CalculateChecksum(); got != 0xffff {
+				t.Errorf("got IPv4 CalculateChecksum() = 0x%04x, want = 0xffff", got)
+			}
+			if got, want := ip.TTL(), uint8(32); got != want {
+				t.Errorf("got TTL() = %d, want = %d", got, want)
+			}
+			if got, want := ip.TransportProtocol(), header.UDPProtocolNumber; got != want {
+				t.Errorf("got TransportProtocol() = %d, want = %d", got, want)
+			}
+			if got := ip.SourceAddress(); got != testIPv4SrcAddr {
+				t.Errorf("got SourceAddress() = %s, want = %s", got, testIPv4SrcAddr)
+			}
+			if got := ip.DestinationAddress(); got != testIPv4DstAddr {
+				t.Errorf("got DestinationAddress() = %s, want = %s", got, testIPv4DstAddr)
+			}
+
+			udp := header.UDP(ip.Payload())
+			if err := header.ValidateUDPLength(udp, len(udp)); err != nil {
+				t.Errorf("header.ValidateUDPLength(_, %d): %s", len(udp), err)
+			}
+			if got, want := udp.SourcePort(), uint16(1234); got != want {
+				t.Errorf("got SourcePort() = %d, want = %d", got, want)
+			}
+			if got, want := udp.DestinationPort(), uint16(53); got != want {
+				t.Errorf("got DestinationPort() = %d, want = %d", got, want)
+			}
+			if got := udp.Payload(); !bytes.Equal(got, test.payload) {
+				t.Errorf("got Payload() = %x, want = %x", got, test.payload)
+			}
+			if !udp.IsChecksumValid(testIPv4SrcAddr, testIPv4DstAddr, header.Checksum(udp.Payload(), 0)) {
+				t.Error("got invalid UDP checksum")
+			}
+		})
+	}
+}
+
+func TestBuildUDPv6Packet(t *testing.T) {
+	const (
+		src = tcpip.Address("\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x01")
+		dst = tcpip.Address("\x20\x01\x0d\xb8\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x02")
+	)
+
+	tests := []struct {
+		name    string
+		payload []byte
+	}{
+		{name: "empty", payload: nil},
+		{name: "even", payload: []byte{1, 2, 3, 4}},
+		{name: "odd", payload: []byte{1, 2, 3, 4, 5}},
+		// The checksum of this datagram computes to zero, which must be
+		// transmitted as all ones.
+		{name: "zero checksum", payload: []byte{0x9f, 0x5a, 0x00, 0x00}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pkt := header.BuildUDPv6Packet(src, dst, 1234, 53, test.payload, 32)
+
+			ip := header.IPv6(pkt)
+			if !ip.IsValid(len(pkt)) {
+				t.Fatalf("got IsValid(%d) = false, want = true", len(pkt))
+			}
+			if got, want := ip.HopLimit(), uint8(32); got != want {
+				t.Errorf("got HopLimit() = %d, want = %d", got, want)
+			}
+			if got, want := ip.TransportProtocol(), header.UDPProtocolNumber; got != want {
+				t.Errorf("got TransportProtocol() = %d, want = %d", got, want)
+			}
+			if got := ip.SourceAddress(); got != src {
+				t.Errorf("got SourceAddress() = %s, want = %s", got, src)
+			}
+			if got := ip.DestinationAddress(); got != dst {
+				t.Errorf("got DestinationAddress() = %s, want = %s", got, dst)
+			}
+
+			udp := header.UDP(ip.Payload())
+			if err := header.ValidateUDPLength(udp, int(ip.PayloadLength())); err != nil {
+				t.Errorf("header.ValidateUDPLength(_, %d): %s", ip.PayloadLength(), err)
+			}
+			if got := udp.Payload(); !bytes.Equal(got, test.payload) {
+				t.Errorf("got Payload() = %x, want = %x", got, test.payload)
+			}
+			if got := udp.Checksum(); got == 0 {
+				t.Error("got Checksum() = 0, want non-zero")
+			}
+			if !udp.IsChecksumValid(src, dst, header.Checksum(udp.Payload(), 0)) {
+				t.Error("got invalid UDP checksum")
+			}
+		})
+	}
+}
+
+func TestTunnelSourcePort(t *testing.T) {
+	udpHeader := func(srcPort, dstPort uint16) []byte {
+		b := header.UDP(make([]byte, header.UDPMinimumSize))
+		b.Encode(&header.UDPFields{SrcPort: srcPort, DstPort: dstPort, Length: header.UDPMinimumSize})
+		return b
+	}
+	v4Packet := func(fields header.IPv4Fields, srcPort, dstPort uint16) []byte {
+		fields.Protocol = uint8(header.UDPProtocolNumber)
+		return makeIPv4Packet(fields, udpHeader(srcPort, dstPort))
+	}
+	v6Packet := func(extHdrs header.IPv6ExtHdrSerializer, srcPort, dstPort uint16) []byte {
+		return makeIPv6Packet(header.IPv6Fields{
+			TransportProtocol: header.UDPProtocolNumber,
+			SrcAddr:           uniqueLocalAddr1,
+			DstAddr:           uniqueLocalAddr2,
+			ExtensionHeaders:  extHdrs,
+		}, udpHeader(srcPort, dstPort))
+	}
+	fragment := func(offset uint16, more bool) header.IPv6ExtHdrSerializer {
+		return header.IPv6ExtHdrSerializer{
+			&header.IPv6SerializableFragmentExtHdr{
+				FragmentOffset: offset,
+				M:              more,
+				Identification: 1,
+			},
+		}
+	}
+
+	tests := []struct {
+		name     string
+		netProto tcpip.NetworkProtocolNumber
+		a, b     []byte
+		wantSame bool
+	}{
+		{
+			name:     "IPv4 same flow",
+			netProto: header.IPv4ProtocolNumber,
+			a:        v4Packet(header.IPv4Fields{ID: 1}, 1234, 53),
+			b:        v4Packet(header.IPv4Fields{ID: 2}, 1234, 53),
+			wantSame: true,
+		},
+		{
+			name:     "IPv4 different source port",
+			netProto: header.IPv4ProtocolNumber,
+			a:        v4Packet(header.IPv4Fields{}, 1234, 53),
+			b:        v4Packet(header.IPv4Fields{}, 1235, 53),
+		},
+		{
+			name:     "IPv4 different destination address",
+			netProto: header.IPv4ProtocolNumber,
+			a:        v4Packet(header.IPv4Fields{}, 1234, 53),
+			b:        v4Packet(header.IPv4Fields{DstAddr: "\x0a\x00\x00\x03"}, 1234, 53),
+		},
+		{
+			name:     "IPv4 fragments",
+			netProto: header.IPv4ProtocolNumber,
+			a:        v4Packet(header.IPv4Fields{Flags: header.IPv4FlagMoreFragments}, 1234, 53),
+			b:        v4Packet(header.IPv4Fields{FragmentOffset: 8}, 5678, 80),
+			wantSame: true,
+		},
+		{
+			name:     "IPv6 same flow",
+			netProto: header.IPv6ProtocolNumber,
+			a:        v6Packet(nil, 1234, 53),
+			b:        v6Packet(nil, 1234, 53),
+			wantSame: true,
+		},
+		{
+			name:     "IPv6 different destination port",
+			netProto: header.IPv6ProtocolNumber,
+			a:        v6Packet(nil, 1234, 53),
+			b:        v6Packet(nil, 1234, 54),
+		},
+		{
+			name:     "IPv6 fragments",
+			netProto: header.IPv6ProtocolNumber,
+			a:        v6Packet(fragment(0, true), 1234, 53),
+			b:        v6Packet(fragment(1, false), 5678, 80),
+			wantSame: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := header.TunnelSourcePort(test.a, test.netProto)
+			b := header.TunnelSourcePort(test.b, test.netProto)
+			for _, port := range []uint16{a, b} {
+				if port < 49152 {
+					t.Errorf("got port = %d, want >= 49152", port)
+				}
+			}
+			if got := a == b; got != test.wantSame {
+				t.Errorf("got ports %d and %d, want same = %t", a, b, test.wantSame)
+			}
+			if got := header.TunnelSourcePort(test.a, test.netProto); got != a {
+				t.Errorf("got header.TunnelSourcePort(_, %d) = %d on second call, want = %d", test.netProto, got, a)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import (
+	"encoding/binary"
+
+	"gvisor.dev/gvisor/pkg/tcpip"
+)
+
+const (
+	udpLiteSrcPort          = 0
+	udpLiteDstPort          = 2
+	udpLiteChecksumCoverage = 4
+	udpLiteChecksum         = 6
+)
+
+const (
+	// UDPLiteMinimumSize is the minimum size of a valid UDP-Lite packet.
+	UDPLiteMinimumSize = 8
+
+	// UDPLiteProtocolNumber is UDP-Lite's transport protocol number.
+	UDPLiteProtocolNumber tcpip.TransportProtocolNumber = 136
+)
+
+// UDPLite represents a UDP-Lite packet stored in a byte array, as per RFC
+// 3828.
+//
+// The header has the same layout as the UDP header, but the "length" field is
+// replaced by the "checksum coverage" field; the length of the packet is that
+// of the IP payload carrying it.
+type UDPLite []byte
+
+// SourcePort returns the "source port" field of the UDP-Lite header.
+func (b UDPLite) SourcePort() uint16 {
+	return binary.BigEndian.Uint16(b[udpLiteSrcPort:])
+}
+
+// DestinationPort returns the "destination port" field of the UDP-Lite header.
+func (b UDPLite) DestinationPort() uint16 {
+	return binary.BigEndian.Uint16(b[udpLiteDstPort:])
+}
+
+// ChecksumCoverage returns the "checksum coverage" field of the UDP-Lite
+// header.
+func (b UDPLite) ChecksumCoverage() uint16 {
+	return binary.BigEndian.Uint16(b[udpLiteChecksumCoverage:])
+}
+
+// Checksum returns the "checksum" field of the UDP-Lite header.
+func (b UDPLite) Checksum() uint16 {
+	return binary.BigEndian.Uint16(b[udpLiteChecksum:])
+}
+
+// Payload returns the data contained in the UDP-Lite packet.
+func (b UDPLite) Payload() []byte {
+	return b[UDPLiteMinimumSize:]
+}
+
+// CoveredLength returns the number of bytes, starting from the header, covered
+// by the checksum of the UDP-Lite packet.
+//
+// As per RFC 3828 section 3.1, a checksum coverage of zero covers the whole
+// packet. ok is false if the checksum coverage is illegal, that is if it does
+// not cover the header or extends past the end of the packet.
+func (b UDPLite) CoveredLength() (length int, ok bool) {
+	if len(b) < UDPLiteMinimumSize {
+		return 0, false
+	}
+	coverage := int(b.ChecksumCoverage())
+	if coverage == 0 {
+		return len(b), true
+	}
+	if coverage < UDPLiteMinimumSize || coverage > len(b) {
+		return 0, false
+	}
+	return coverage, true
+}
+
+// IsChecksumValid returns true iff the UDP-Lite packet has a legal checksum
+// coverage and a valid checksum over the covered bytes.
+//
+// As per RFC 3828 section 3.2, the pseudo-header holds the length of the whole
+// packet rather than the checksum coverage.
+func (b UDPLite) IsChecksumValid(src, dst tcpip.Address) bool {
+	coverage, ok := b.CoveredLength()
+	if !ok {
+		return false
+	}
+	xsum := PseudoHeaderChecksum(UDPLiteProtocolNumber, src, dst, uint16(len(b)))
+	return Checksum(b[:coverage], xsum) == 0xffff
+}
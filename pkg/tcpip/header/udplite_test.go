@@ -0,0 +1,115 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestUDPLite(t *testing.T) {
+	// makeUDPLite returns a UDP-Lite packet with a payload of payloadLen bytes
+	// and the given checksum coverage, with the checksum computed over the
+	// first checksumLen bytes.
+	makeUDPLite := func(payloadLen int, coverage uint16, checksumLen int) header.UDPLite {
+		b := make([]byte, header.UDPLiteMinimumSize+payloadLen)
+		binary.BigEndian.PutUint16(b[0:], 1234)
+		binary.BigEndian.PutUint16(b[2:], 5678)
+		binary.BigEndian.PutUint16(b[4:], coverage)
+		for i := header.UDPLiteMinimumSize; i < len(b); i++ {
+			b[i] = uint8(i)
+		}
+		xsum := header.PseudoHeaderChecksum(header.UDPLiteProtocolNumber, testIPv4SrcAddr, testIPv4DstAddr, uint16(len(b)))
+		binary.BigEndian.PutUint16(b[6:], ^header.Checksum(b[:checksumLen], xsum))
+		return b
+	}
+
+	tests := []struct {
+		name                  string
+		pkt                   header.UDPLite
+		corrupt               int
+		wantCoverage          int
+		wantCoverageOK        bool
+		wantValid             bool
+		wantValidAfterCorrupt bool
+	}{
+		{
+			name:                  "full coverage",
+			pkt:                   makeUDPLite(16, 0, 24),
+			corrupt:               20,
+			wantCoverage:          24,
+			wantCoverageOK:        true,
+			wantValid:             true,
+			wantValidAfterCorrupt: false,
+		},
+		{
+			name:                  "explicit full coverage",
+			pkt:                   makeUDPLite(16, 24, 24),
+			corrupt:               20,
+			wantCoverage:          24,
+			wantCoverageOK:        true,
+			wantValid:             true,
+			wantValidAfterCorrupt: false,
+		},
+		{
+			name:                  "partial coverage",
+			pkt:                   makeUDPLite(16, 12, 12),
+			corrupt:               20,
+			wantCoverage:          12,
+			wantCoverageOK:        true,
+			wantValid:             true,
+			wantValidAfterCorrupt: true,
+		},
+		{
+			name:                  "header only coverage",
+			pkt:                   makeUDPLite(16, 8, 8),
+			corrupt:               8,
+			wantCoverage:          8,
+			wantCoverageOK:        true,
+			wantValid:             true,
+			wantValidAfterCorrupt: true,
+		},
+		{
+			name:    "illegal coverage",
+			pkt:     makeUDPLite(16, 7, 7),
+			corrupt: 20,
+		},
+		{
+			name:    "coverage past the end",
+			pkt:     makeUDPLite(16, 25, 24),
+			corrupt: 20,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			coverage, ok := test.pkt.CoveredLength()
+			if coverage != test.wantCoverage || ok != test.wantCoverageOK {
+				t.Errorf("got CoveredLength() = (%d, %t), want = (%d, %t)", coverage, ok, test.wantCoverage, test.wantCoverageOK)
+			}
+			if got := test.pkt.IsChecksumValid(testIPv4SrcAddr, testIPv4DstAddr); got != test.wantValid {
+				t.Errorf("got IsChecksumValid(_, _) = %t, want = %t", got, test.wantValid)
+			}
+
+			// Corrupting a byte must only be detected if it is covered.
+			test.pkt[test.corrupt] ^= 0xff
+			if got := test.pkt.IsChecksumValid(testIPv4SrcAddr, testIPv4DstAddr); got != test.wantValidAfterCorrupt {
+				t.Errorf("got IsChecksumValid(_, _) = %t after corrupting byte %d, want = %t", got, test.corrupt, test.wantValidAfterCorrupt)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header
+
+import "encoding/binary"
+
+// draft-ietf-nvo3-vxlan-gpe section 3.2 defines the VXLAN-GPE header that
+// follows the outer UDP header as:
+//
+//	 0                   1                   2                   3
+//	 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1 2 3 4 5 6 7 8 9 0 1
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|R|R|Ver|I|P|B|O|       Reserved                |Next Protocol  |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//	|                VXLAN Network Identifier (VNI) |   Reserved    |
+//	+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+-+
+//
+// The header has the same layout as the VXLAN header of RFC 7348, where all
+// flags but I are reserved and the Next Protocol field is reserved.
+const (
+	vxlanGPEFlags        = 0
+	vxlanGPENextProtocol = 3
+	vxlanGPEVNI          = 4
+
+	vxlanGPEVersionMask  = 0x30
+	vxlanGPEVersionShift = 4
+	vxlanGPEVNIShift     = 8
+)
+
+const (
+	// VXLANGPEPort is the UDP destination port for VXLAN-GPE, as per
+	// draft-ietf-nvo3-vxlan-gpe section 3.3.
+	VXLANGPEPort = 4790
+
+	// VXLANGPEHeaderSize is the size of the VXLAN-GPE header.
+	VXLANGPEHeaderSize = 8
+)
+
+// The flags carried in the first byte of the VXLAN-GPE header.
+const (
+	// VXLANGPEFlagInstance is the I flag, indicating a valid VNI. It is the
+	// only flag defined by VXLAN and must always be set.
+	VXLANGPEFlagInstance uint8 = 1 << 3
+
+	// VXLANGPEFlagNextProtocol is the P flag, indicating that the Next
+	// Protocol field is present.
+	VXLANGPEFlagNextProtocol uint8 = 1 << 2
+
+	// VXLANGPEFlagBUM is the B flag, indicating ingress-replicated
+	// broadcast, unknown unicast or multicast traffic.
+	VXLANGPEFlagBUM uint8 = 1 << 1
+
+	// VXLANGPEFlagOAM is the O flag, indicating an OAM packet.
+	VXLANGPEFlagOAM uint8 = 1 << 0
+)
+
+// The values of the Next Protocol field, as per draft-ietf-nvo3-vxlan-gpe
+// section 3.2.
+const (
+	VXLANGPENextProtocolIPv4     uint8 = 1
+	VXLANGPENextProtocolIPv6     uint8 = 2
+	VXLANGPENextProtocolEthernet uint8 = 3
+	VXLANGPENextProtocolNSH      uint8 = 4
+)
+
+// VXLANGPE represents a VXLAN-GPE header stored in a byte array.
+//
+// VXLAN-GPE shares the flags byte with VXLAN: a header without the P flag is
+// a plain VXLAN header, whose payload is always an Ethernet frame.
+type VXLANGPE []byte
+
+// IsValid performs basic validation on the VXLAN-GPE header.
+//
+// The header must hold a valid VNI (I flag set) and its version must be 0.
+func (b VXLANGPE) IsValid() bool {
+	if len(b) < VXLANGPEHeaderSize {
+		return false
+	}
+	return b.Flags()&VXLANGPEFlagInstance != 0 && b.Version() == 0
+}
+
+// Flags returns the flags byte of the VXLAN-GPE header.
+func (b VXLANGPE) Flags() uint8 {
+	return b[vxlanGPEFlags]
+}
+
+// Version returns the version of the VXLAN-GPE header.
+func (b VXLANGPE) Version() uint8 {
+	return (b[vxlanGPEFlags] & vxlanGPEVersionMask) >> vxlanGPEVersionShift
+}
+
+// NextProtocol returns the protocol of the encapsulated packet, one of the
+// VXLANGPENextProtocol* values.
+//
+// If the P flag is not set, the header is a VXLAN header and
+// VXLANGPENextProtocolEthernet is returned regardless of the reserved Next
+// Protocol field.
+func (b VXLANGPE) NextProtocol() uint8 {
+	if b.Flags()&VXLANGPEFlagNextProtocol == 0 {
+		return VXLANGPENextProtocolEthernet
+	}
+	return b[vxlanGPENextProtocol]
+}
+
+// VNI returns the 24-bit VXLAN Network Identifier.
+func (b VXLANGPE) VNI() uint32 {
+	return binary.BigEndian.Uint32(b[vxlanGPEVNI:]) >> vxlanGPEVNIShift
+}
+
+// Payload returns the packet encapsulated by the VXLAN-GPE header.
+func (b VXLANGPE) Payload() []byte {
+	return b[VXLANGPEHeaderSize:]
+}
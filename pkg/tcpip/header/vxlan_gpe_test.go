@@ -0,0 +1,93 @@
+// Copyright 2021 The gVisor Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package header_test
+
+import (
+	"testing"
+
+	"gvisor.dev/gvisor/pkg/tcpip/header"
+)
+
+func TestVXLANGPE(t *testing.T) {
+	tests := []struct {
+		name             string
+		buf              []byte
+		wantValid        bool
+		wantNextProtocol uint8
+		wantVNI          uint32
+	}{
+		{
+			name:             "GPE carrying IPv4",
+			buf:              []byte{0x0c, 0, 0, 0x01, 0x12, 0x34, 0x56, 0, 0x45},
+			wantValid:        true,
+			wantNextProtocol: header.VXLANGPENextProtocolIPv4,
+			wantVNI:          0x123456,
+		},
+		{
+			name:             "GPE carrying Ethernet",
+			buf:              []byte{0x0c, 0, 0, 0x03, 0, 0, 0x2a, 0},
+			wantValid:        true,
+			wantNextProtocol: header.VXLANGPENextProtocolEthernet,
+			wantVNI:          42,
+		},
+		{
+			name:             "GPE carrying NSH",
+			buf:              []byte{0x0c, 0, 0, 0x04, 0, 0, 0x2a, 0},
+			wantValid:        true,
+			wantNextProtocol: header.VXLANGPENextProtocolNSH,
+			wantVNI:          42,
+		},
+		{
+			name:             "VXLAN",
+			buf:              []byte{0x08, 0, 0, 0x01, 0, 0, 0x2a, 0},
+			wantValid:        true,
+			wantNextProtocol: header.VXLANGPENextProtocolEthernet,
+			wantVNI:          42,
+		},
+		{
+			name: "no VNI",
+			buf:  []byte{0x04, 0, 0, 0x01, 0, 0, 0x2a, 0},
+		},
+		{
+			name: "non-zero version",
+			buf:  []byte{0x1c, 0, 0, 0x01, 0, 0, 0x2a, 0},
+		},
+		{
+			name: "too small",
+			buf:  []byte{0x0c, 0, 0, 0x01, 0, 0, 0x2a},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			gpe := header.VXLANGPE(test.buf)
+			if got := gpe.IsValid(); got != test.wantValid {
+				t.Fatalf("got IsValid() = %t, want = %t", got, test.wantValid)
+			}
+			if !test.wantValid {
+				return
+			}
+			if got := gpe.NextProtocol(); got != test.wantNextProtocol {
+				t.Errorf("got NextProtocol() = %d, want = %d", got, test.wantNextProtocol)
+			}
+			if got := gpe.VNI(); got != test.wantVNI {
+				t.Errorf("got VNI() = 0x%x, want = 0x%x", got, test.wantVNI)
+			}
+			if got, want := len(gpe.Payload()), len(test.buf)-header.VXLANGPEHeaderSize; got != want {
+				t.Errorf("got len(Payload()) = %d, want = %d", got, want)
+			}
+		})
+	}
+}
@@ -63,108 +63,6 @@ const (
 	buckets = 2048
 )
 
-// policyTable is the default policy table defined in RFC 6724 section 2.1.
-//
-// A more human-readable version:
-//
-//  Prefix        Precedence Label
-//  ::1/128               50     0
-//  ::/0                  40     1
-//  ::ffff:0:0/96         35     4
-//  2002::/16             30     2
-//  2001::/32              5     5
-//  fc00::/7               3    13
-//  ::/96                  1     3
-//  fec0::/10              1    11
-//  3ffe::/16              1    12
-//
-// The table is sorted by prefix length so longest-prefix match can be easily
-// achieved.
-//
-// We willingly left out ::/96, fec0::/10 and 3ffe::/16 since those prefix
-// assignments are deprecated.
-//
-// As per RFC 4291 section 2.5.5.1 (for ::/96),
-//
-//   The "IPv4-Compatible IPv6 address" is now deprecated because the
-//   current IPv6 transition mechanisms no longer use these addresses.
-//   New or updated implementations are not required to support this
-//   address type.
-//
-// As per RFC 3879 section 4 (for fec0::/10),
-//
-//    This document formally deprecates the IPv6 site-local unicast prefix
-//    defined in [RFC3513], i.e., 1111111011 binary or FEC0::/10.
-//
-// As per RFC 3701 section 1 (for 3ffe::/16),
-//
-//   As clearly stated in [TEST-NEW], the addresses for the 6bone are
-//   temporary and will be reclaimed in the future. It further states
-//   that all users of these addresses (within the 3FFE::/16 prefix) will
-//   be required to renumber at some time in the future.
-//
-// and section 2,
-//
-//   Thus after the pTLA allocation cutoff date January 1, 2004, it is
-//   REQUIRED that no new 6bone 3FFE pTLAs be allocated.
-//
-// MUST NOT BE MODIFIED.
-var policyTable = [...]struct {
-	subnet tcpip.Subnet
-
-	label uint8
-}{
-	// ::1/128
-	{
-		subnet: header.IPv6Loopback.WithPrefix().Subnet(),
-		label:  0,
-	},
-	// ::ffff:0:0/96
-	{
-		subnet: header.IPv4MappedIPv6Subnet,
-		label:  4,
-	},
-	// 2001::/32 (Teredo prefix as per RFC 4380 section 2.6).
-	{
-		subnet: tcpip.AddressWithPrefix{
-			Address:   "\x20\x01\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
-			PrefixLen: 32,
-		}.Subnet(),
-		label: 5,
-	},
-	// 2002::/16 (6to4 prefix as per RFC 3056 section 2).
-	{
-		subnet: tcpip.AddressWithPrefix{
-			Address:   "\x20\x02\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
-			PrefixLen: 16,
-		}.Subnet(),
-		label: 2,
-	},
-	// fc00::/7 (Unique local addresses as per RFC 4193 section 3.1).
-	{
-		subnet: tcpip.AddressWithPrefix{
-			Address:   "\xfc\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00\x00",
-			PrefixLen: 7,
-		}.Subnet(),
-		label: 13,
-	},
-	// ::/0
-	{
-		subnet: header.IPv6EmptySubnet,
-		label:  1,
-	},
-}
-
-func getLabel(addr tcpip.Address) uint8 {
-	for _, p := range policyTable {
-		if p.subnet.Contains(addr) {
-			return p.label
-		}
-	}
-
-	panic(fmt.Sprintf("should have a label for address = %s", addr))
-}
-
 var _ stack.DuplicateAddressDetector = (*endpoint)(nil)
 var _ stack.LinkAddressResolver = (*endpoint)(nil)
 var _ stack.LinkResolvableNetworkEndpoint = (*endpoint)(nil)
@@ -1121,6 +1019,19 @@ func (e *endpoint) handleValidatedPacket(h header.IPv6, pkt *stack.PacketBuffer)
 			break
 		}
 
+		// The stack does not support Mobile IPv6 so the Mobility header, which is
+		// always the last header as per RFC 6275 section 6.1.1, is handled like
+		// any other upper-layer header and handed to the transport dispatcher
+		// along with whatever follows it.
+		if mh, ok := extHdr.(header.IPv6Mobility); ok {
+			buf := buffer.View(mh).ToVectorisedView()
+			buf.Append(it.AsRawHeader(true /* consume */).Buf)
+			extHdr = header.IPv6RawPayloadHeader{
+				Identifier: header.IPv6MobilityExtHdrIdentifier,
+				Buf:        buf,
+			}
+		}
+
 		switch extHdr := extHdr.(type) {
 		case header.IPv6HopByHopOptionsExtHdr:
 			// As per RFC 8200 section 4.1, the Hop By Hop extension header is
@@ -1264,8 +1175,11 @@ func (e *endpoint) handleValidatedPacket(h header.IPv6, pkt *stack.PacketBuffer)
 				// TODO(#2196): Support IPv6 Authentication and Encapsulated
 				// Security Payload extension headers.
 				// TODO(#2333): Validate that the upper layer header is valid.
+				//
+				// The Mobility header is always the last header, as per RFC 6275
+				// section 6.1.1, so it may also end the first fragment.
 				switch lastHdr.(type) {
-				case header.IPv6RawPayloadHeader:
+				case header.IPv6RawPayloadHeader, header.IPv6Mobility:
 				default:
 					stats.MalformedPacketsReceived.Increment()
 					stats.MalformedFragmentsReceived.Increment()
@@ -1692,7 +1606,7 @@ func (e *endpoint) acquireOutgoingPrimaryAddressRLocked(remoteAddr tcpip.Address
 			addressEndpoint: addressEndpoint,
 			addr:            addr,
 			scope:           scope,
-			label:           getLabel(addr),
+			label:           header.IPv6AddressLabel(addr),
 			matchingPrefix:  remoteAddr.MatchingPrefix(addr),
 		})
 
@@ -1705,7 +1619,7 @@ func (e *endpoint) acquireOutgoingPrimaryAddressRLocked(remoteAddr tcpip.Address
 		panic(fmt.Sprintf("header.ScopeForIPv6Address(%s): %s", remoteAddr, err))
 	}
 
-	remoteLabel := getLabel(remoteAddr)
+	remoteLabel := header.IPv6AddressLabel(remoteAddr)
 
 	// Sort the addresses as per RFC 6724 section 5 rules 1-3.
 	//
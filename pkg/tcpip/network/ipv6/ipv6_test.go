@@ -55,6 +55,7 @@ const (
 	destinationExtHdrID = uint8(header.IPv6DestinationOptionsExtHdrIdentifier)
 	noNextHdrID         = uint8(header.IPv6NoNextHeaderIdentifier)
 	unknownHdrID        = uint8(header.IPv6UnknownExtHdrIdentifier)
+	mobilityHdrID       = uint8(header.IPv6MobilityExtHdrIdentifier)
 
 	extraHeaderReserve = 50
 )
@@ -644,6 +645,43 @@ func TestReceiveIPv6ExtHdrs(t *testing.T) {
 			ICMPCode:     header.ICMPv6UnknownHeader,
 			pointer:      header.IPv6FixedHeaderSize,
 		},
+		{
+			name: "mobility (first)",
+			extHdr: func(nextHdr uint8) ([]byte, uint8) {
+				return []byte{
+					// Binding Refresh Request, the Payload Proto field is ignored.
+					nextHdr, 0, 0, 0, 0, 0, 0, 0,
+				}, mobilityHdrID
+			},
+			shouldAccept: false,
+			countersToBeIncremented: func(stats *tcpip.Stats) []*tcpip.StatCounter {
+				return []*tcpip.StatCounter{stats.UnknownProtocolRcvdPackets}
+			},
+			expectICMP: true,
+			ICMPType:   header.ICMPv6ParamProblem,
+			ICMPCode:   header.ICMPv6UnknownHeader,
+			pointer:    header.IPv6NextHeaderOffset,
+		},
+		{
+			name: "hopbyhop - mobility",
+			extHdr: func(nextHdr uint8) ([]byte, uint8) {
+				return []byte{
+					// Hop By Hop extension header with skippable unknown option.
+					mobilityHdrID, 0, 63, 4, 1, 2, 3, 4,
+
+					// Binding Refresh Request, the Payload Proto field is ignored.
+					nextHdr, 0, 0, 0, 0, 0, 0, 0,
+				}, hopByHopExtHdrID
+			},
+			shouldAccept: false,
+			countersToBeIncremented: func(stats *tcpip.Stats) []*tcpip.StatCounter {
+				return []*tcpip.StatCounter{stats.UnknownProtocolRcvdPackets}
+			},
+			expectICMP: true,
+			ICMPType:   header.ICMPv6ParamProblem,
+			ICMPCode:   header.ICMPv6UnknownHeader,
+			pointer:    header.IPv6FixedHeaderSize,
+		},
 		{
 			name: "destination with unknown option skippable action",
 			extHdr: func(nextHdr uint8) ([]byte, uint8) {
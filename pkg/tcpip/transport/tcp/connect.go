@@ -719,6 +719,11 @@ type tcpFields struct {
 }
 
 func (e *endpoint) sendSynTCP(r *stack.Route, tf tcpFields, opts header.TCPSynOptions) tcpip.Error {
+	// There is nothing to echo in a SYN without an ACK so its TSEcr must be
+	// zero, as per RFC 7323 section 3.2.
+	if tf.flags&header.TCPFlagAck == 0 {
+		opts.TSEcr = 0
+	}
 	tf.opts = makeSynOptions(opts)
 	// We ignore SYN send errors and let the callers re-attempt send.
 	if err := e.sendTCP(r, tf, buffer.VectorisedView{}, nil); err != nil {